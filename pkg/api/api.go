@@ -0,0 +1,183 @@
+// Package api is the REST API of the datasource's resources, which lists Numaflow resources as JSON for custom panels
+// and scripts, without building queries.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dseapy/numaflow-datasource/pkg/cache"
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/gorilla/mux"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/strings/slices"
+)
+
+const (
+	V1Prefix = "/api/v1"
+
+	// DefaultLimit and MaxLimit are the number of items of a page, when the limit is not given, and at most.
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+type v1API struct {
+	client  *client.Client
+	lookups *cache.Cache
+	// namespace is the namespace the datasource is restricted to, if any.
+	namespace string
+}
+
+// RegisterV1 registers version 1 of the API on a router of its prefix. Lists are cached in lookups.
+// namespace is the namespace the datasource is restricted to, if any.
+func RegisterV1(r *mux.Router, c *client.Client, lookups *cache.Cache, namespace string) {
+	a := &v1API{client: c, lookups: lookups, namespace: namespace}
+	get := func(path string, h http.HandlerFunc) {
+		r.HandleFunc(path, h).Methods(http.MethodGet)
+	}
+	get("/namespaces", a.listNamespaces)
+	get("/pipelines", a.listPipelines)
+	get("/namespaces/{namespace}/pipelines", a.listPipelines)
+	get("/namespaces/{namespace}/pipelines/{pipeline}", a.getPipeline)
+	get("/namespaces/{namespace}/pipelines/{pipeline}/vertices", a.listVertices)
+	get("/namespaces/{namespace}/pipelines/{pipeline}/vertices/{vertex}", a.getVertex)
+	get("/namespaces/{namespace}/pipelines/{pipeline}/vertices/{vertex}/pods", a.listVertexPods)
+	get("/namespaces/{namespace}/pipelines/{pipeline}/vertices/{vertex}/metrics", a.getVertexMetrics)
+	get("/namespaces/{namespace}/pipelines/{pipeline}/edges", a.listEdges)
+	get("/isbsvcs", a.listInterStepBufferServices)
+	get("/namespaces/{namespace}/isbsvcs", a.listInterStepBufferServices)
+	get("/namespaces/{namespace}/isbsvcs/{isbsvc}", a.getInterStepBufferService)
+	get("/namespaces/{namespace}/isbsvcs/{isbsvc}/pods", a.listInterStepBufferServicePods)
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeError(w, query.Errorf(query.NotFoundError, "no API resource at %s", req.URL.Path))
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusMethodNotAllowed, &errorBody{Error: "only GET is supported", Kind: query.ValidationError})
+	})
+}
+
+// namespaceOf returns the namespace of a request, or all namespaces for requests without one.
+// Requests of other namespaces than the one the datasource is restricted to are forbidden.
+func (a *v1API) namespaceOf(req *http.Request) (string, error) {
+	ns, ok := mux.Vars(req)["namespace"]
+	if !ok {
+		if a.namespace != "" {
+			return a.namespace, nil
+		}
+		return v1.NamespaceAll, nil
+	}
+	if a.namespace != "" && ns != a.namespace {
+		return "", query.Errorf(query.ForbiddenError, "the datasource is restricted to namespace %q", a.namespace)
+	}
+	return ns, nil
+}
+
+// cached returns the value listed by list, cached by key unless the request has noCache=true.
+func (a *v1API) cached(req *http.Request, key string, list func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	noCache, _ := strconv.ParseBool(req.URL.Query().Get("noCache"))
+	return a.lookups.Get(req.Context(), "api", "api "+key, noCache, list)
+}
+
+// filter selects items by the query parameters of a request:
+// name, a comma-separated list of names, phase, and labelSelector, a Kubernetes label selector.
+type filter struct {
+	names    []string
+	phase    string
+	selector labels.Selector
+}
+
+func parseFilter(req *http.Request) (*filter, error) {
+	params := req.URL.Query()
+	f := &filter{phase: params.Get("phase"), selector: labels.Everything()}
+	if names := params.Get("name"); names != "" {
+		f.names = strings.Split(names, ",")
+	}
+	if s := params.Get("labelSelector"); s != "" {
+		selector, err := labels.Parse(s)
+		if err != nil {
+			return nil, query.Errorf(query.ValidationError, "invalid labelSelector %q, %w", s, err)
+		}
+		f.selector = selector
+	}
+	return f, nil
+}
+
+func (f *filter) matches(name string, phase string, l map[string]string) bool {
+	if len(f.names) > 0 && !slices.Contains(f.names, name) {
+		return false
+	}
+	if f.phase != "" && !strings.EqualFold(f.phase, phase) {
+		return false
+	}
+	return f.selector.Matches(labels.Set(l))
+}
+
+// page is a page of a list, with the total number of items matching the filters.
+type page struct {
+	Items  interface{} `json:"items"`
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+}
+
+// paginate returns the page selected by the offset and limit query parameters of a request, and the bounds of its
+// items in the list of total items.
+func paginate(req *http.Request, total int) (_ *page, start int, end int, _ error) {
+	params := req.URL.Query()
+	p := &page{Total: total, Limit: DefaultLimit}
+	if s := params.Get("offset"); s != "" {
+		offset, err := strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return nil, 0, 0, query.Errorf(query.ValidationError, "invalid offset %q, must be a non-negative integer", s)
+		}
+		p.Offset = offset
+	}
+	if s := params.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return nil, 0, 0, query.Errorf(query.ValidationError, "invalid limit %q, must be between 1 and %d", s, MaxLimit)
+		}
+		p.Limit = limit
+	}
+	start = p.Offset
+	if start > total {
+		start = total
+	}
+	end = start + p.Limit
+	if end > total {
+		end = total
+	}
+	return p, start, end, nil
+}
+
+type errorBody struct {
+	Error string          `json:"error"`
+	Kind  query.ErrorKind `json:"kind"`
+}
+
+func writeError(w http.ResponseWriter, err *query.Error) {
+	if err.Kind == query.InternalError {
+		backend.Logger.Error("API request failed", "kind", err.Kind, "object", err.Object, "err", err.Err)
+	}
+	writeJSON(w, int(err.Status()), &errorBody{Error: err.Error(), Kind: err.Kind})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		backend.Logger.Error("failed to marshal API response", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(j); err != nil {
+		backend.Logger.Error("failed to write API response", "err", err)
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		params     string
+		total      int
+		start, end int
+		limit      int
+		wantErr    bool
+	}{
+		{name: "defaults", total: 250, start: 0, end: DefaultLimit, limit: DefaultLimit},
+		{name: "short list", total: 3, start: 0, end: 3, limit: DefaultLimit},
+		{name: "empty list", total: 0, start: 0, end: 0, limit: DefaultLimit},
+		{name: "offset and limit", params: "offset=2&limit=3", total: 10, start: 2, end: 5, limit: 3},
+		{name: "last page", params: "offset=8&limit=5", total: 10, start: 8, end: 10, limit: 5},
+		{name: "offset at the end", params: "offset=10", total: 10, start: 10, end: 10, limit: DefaultLimit},
+		{name: "offset past the end", params: "offset=50&limit=5", total: 10, start: 10, end: 10, limit: 5},
+		{name: "max limit", params: "limit=1000", total: 2000, start: 0, end: MaxLimit, limit: MaxLimit},
+		{name: "limit 0", params: "limit=0", total: 10, wantErr: true},
+		{name: "limit above max", params: "limit=1001", total: 10, wantErr: true},
+		{name: "negative limit", params: "limit=-1", total: 10, wantErr: true},
+		{name: "negative offset", params: "offset=-1", total: 10, wantErr: true},
+		{name: "non-integer offset", params: "offset=a", total: 10, wantErr: true},
+		{name: "non-integer limit", params: "limit=1.5", total: 10, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/pipelines?"+tc.params, nil)
+			p, start, end, err := paginate(req, tc.total)
+			if tc.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, query.ValidationError, query.ClassifyError(err, "").Kind)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.start, start, "start")
+			assert.Equal(t, tc.end, end, "end")
+			assert.Equal(t, tc.limit, p.Limit, "limit")
+			assert.Equal(t, tc.total, p.Total, "total")
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		params  string
+		matches bool
+		wantErr bool
+	}{
+		{name: "no filter", matches: true},
+		{name: "name", params: "name=a,pl", matches: true},
+		{name: "other name", params: "name=a,b", matches: false},
+		{name: "phase ignores case", params: "phase=running", matches: true},
+		{name: "other phase", params: "phase=Paused", matches: false},
+		{name: "label selector", params: "labelSelector=team%3Dcore,tier!%3Dbatch", matches: true},
+		{name: "set based label selector", params: "labelSelector=team+in+(core,infra)", matches: true},
+		{name: "unmatched label selector", params: "labelSelector=team%3Dother", matches: false},
+		{name: "invalid label selector", params: "labelSelector=team%3D%3D%3D", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/pipelines?"+tc.params, nil)
+			f, err := parseFilter(req)
+			if tc.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, query.ValidationError, query.ClassifyError(err, "").Kind)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.matches, f.matches("pl", "Running", map[string]string{"team": "core"}))
+		})
+	}
+}
+
+// newTestRouter returns the API of a simulated cluster, restricted to namespace if set.
+func newTestRouter(t *testing.T, namespace string) http.Handler {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	c, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Pipelines: 3, Vertices: 3})
+	require.NoError(t, err)
+	r := mux.NewRouter()
+	RegisterV1(r.PathPrefix(V1Prefix).Subrouter(), c, nil, namespace)
+	return r
+}
+
+func TestRoutes(t *testing.T) {
+	r := newTestRouter(t, "")
+	restricted := newTestRouter(t, "other")
+	for _, tc := range []struct {
+		name   string
+		router http.Handler
+		method string
+		path   string
+		status int
+		kind   query.ErrorKind
+		// list is set for lists, with the total and the number of items of the page
+		list  bool
+		total int
+		items int
+	}{
+		{name: "pipelines", path: "/api/v1/namespaces/ns/pipelines", status: http.StatusOK, list: true, total: 3, items: 3},
+		{name: "page of pipelines", path: "/api/v1/pipelines?offset=1&limit=1", status: http.StatusOK, list: true, total: 3, items: 1},
+		{name: "offset past the end", path: "/api/v1/pipelines?offset=10", status: http.StatusOK, list: true, total: 3, items: 0},
+		{name: "limit 0", path: "/api/v1/pipelines?limit=0", status: http.StatusBadRequest, kind: query.ValidationError},
+		{name: "negative offset", path: "/api/v1/pipelines?offset=-1", status: http.StatusBadRequest, kind: query.ValidationError},
+		{name: "label selector", path: "/api/v1/pipelines?labelSelector=missing%3Dlabel", status: http.StatusOK, list: true, total: 0, items: 0},
+		{name: "invalid label selector", path: "/api/v1/pipelines?labelSelector=%3D%3D", status: http.StatusBadRequest, kind: query.ValidationError},
+		{name: "vertex", path: "/api/v1/namespaces/ns/pipelines/orders/vertices/in", status: http.StatusOK},
+		{name: "missing vertex", path: "/api/v1/namespaces/ns/pipelines/orders/vertices/missing", status: http.StatusNotFound, kind: query.NotFoundError},
+		{name: "missing pipeline", path: "/api/v1/namespaces/ns/pipelines/missing", status: http.StatusNotFound, kind: query.NotFoundError},
+		{name: "unknown route", path: "/api/v1/namespaces/ns/sinks", status: http.StatusNotFound, kind: query.NotFoundError},
+		{name: "unsupported method", method: http.MethodPost, path: "/api/v1/pipelines", status: http.StatusMethodNotAllowed, kind: query.ValidationError},
+		{name: "other namespace", router: restricted, path: "/api/v1/namespaces/ns/pipelines", status: http.StatusForbidden, kind: query.ForbiddenError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router, method := tc.router, tc.method
+			if router == nil {
+				router = r
+			}
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(method, tc.path, nil))
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			if tc.kind != "" {
+				var body errorBody
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, tc.kind, body.Kind)
+				return
+			}
+			if !tc.list {
+				return
+			}
+			var p struct {
+				Items []json.RawMessage `json:"items"`
+				Total int               `json:"total"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+			assert.Equal(t, tc.total, p.Total, "total")
+			assert.Len(t, p.Items, tc.items)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"time"
+
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/numaproj/numaflow/pkg/apis/proto/daemon"
+	"github.com/numaproj/numaflow/pkg/isb"
+	v1 "k8s.io/api/core/v1"
+)
+
+// The API returns summaries of resources rather than the resources themselves, which keeps specs, e.g. environment
+// variables, out of the API, as specs are only returned by Spec queries after redaction.
+
+type Pipeline struct {
+	Namespace    string            `json:"namespace"`
+	Name         string            `json:"name"`
+	Phase        string            `json:"phase"`
+	Message      string            `json:"message,omitempty"`
+	Vertices     *uint32           `json:"vertices,omitempty"`
+	Sources      *uint32           `json:"sources,omitempty"`
+	Sinks        *uint32           `json:"sinks,omitempty"`
+	UDFs         *uint32           `json:"udfs,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CreationTime time.Time         `json:"creationTime"`
+}
+
+type Vertex struct {
+	Namespace       string            `json:"namespace"`
+	Pipeline        string            `json:"pipeline"`
+	Name            string            `json:"name"`
+	Type            string            `json:"type"`
+	Phase           string            `json:"phase"`
+	Message         string            `json:"message,omitempty"`
+	Replicas        uint32            `json:"replicas"`
+	DesiredReplicas *int32            `json:"desiredReplicas,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	CreationTime    time.Time         `json:"creationTime"`
+}
+
+type Edge struct {
+	From             string   `json:"from"`
+	To               string   `json:"to"`
+	Buffer           string   `json:"buffer"`
+	Pending          *int64   `json:"pending,omitempty"`
+	AckPending       *int64   `json:"ackPending,omitempty"`
+	TotalMessages    *int64   `json:"totalMessages,omitempty"`
+	BufferLength     *int64   `json:"bufferLength,omitempty"`
+	BufferUsage      *float64 `json:"bufferUsage,omitempty"`
+	BufferUsageLimit *float64 `json:"bufferUsageLimit,omitempty"`
+	IsFull           *bool    `json:"isFull,omitempty"`
+}
+
+type InterStepBufferService struct {
+	Namespace    string            `json:"namespace"`
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`
+	Phase        string            `json:"phase"`
+	Message      string            `json:"message,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CreationTime time.Time         `json:"creationTime"`
+}
+
+type Pod struct {
+	Namespace    string            `json:"namespace"`
+	Name         string            `json:"name"`
+	Phase        string            `json:"phase"`
+	Ready        bool              `json:"ready"`
+	Restarts     int32             `json:"restarts"`
+	Node         string            `json:"node,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CreationTime time.Time         `json:"creationTime"`
+}
+
+// VertexMetrics are the processing rates and pendings of a vertex by window, as read from the daemon service, with rates
+// and pendings that are not available left out.
+type VertexMetrics struct {
+	Namespace       string             `json:"namespace"`
+	Pipeline        string             `json:"pipeline"`
+	Vertex          string             `json:"vertex"`
+	ProcessingRates map[string]float64 `json:"processingRates"`
+	Pendings        map[string]int64   `json:"pendings"`
+	Watermark       *time.Time         `json:"watermark,omitempty"`
+}
+
+func newPipeline(pl *dfv1.Pipeline) Pipeline {
+	return Pipeline{
+		Namespace:    pl.Namespace,
+		Name:         pl.Name,
+		Phase:        string(pl.Status.Phase),
+		Message:      pl.Status.Message,
+		Vertices:     pl.Status.VertexCount,
+		Sources:      pl.Status.SourceCount,
+		Sinks:        pl.Status.SinkCount,
+		UDFs:         pl.Status.UDFCount,
+		Labels:       pl.Labels,
+		CreationTime: pl.CreationTimestamp.Time,
+	}
+}
+
+func newVertex(v *dfv1.Vertex) Vertex {
+	vtype := ""
+	if v.IsASource() {
+		vtype = "source"
+	} else if v.IsASink() {
+		vtype = "sink"
+	} else if v.IsMapUDF() {
+		vtype = "udf (map)"
+	} else if v.IsReduceUDF() {
+		vtype = "udf (reduce)"
+	}
+	return Vertex{
+		Namespace:       v.Namespace,
+		Pipeline:        v.Spec.PipelineName,
+		Name:            v.Spec.Name,
+		Type:            vtype,
+		Phase:           string(v.Status.Phase),
+		Message:         v.Status.Message,
+		Replicas:        v.Status.Replicas,
+		DesiredReplicas: v.Spec.Replicas,
+		Labels:          v.Labels,
+		CreationTime:    v.CreationTimestamp.Time,
+	}
+}
+
+func newEdge(b *daemon.BufferInfo) Edge {
+	return Edge{
+		From:             b.GetFromVertex(),
+		To:               b.GetToVertex(),
+		Buffer:           b.GetBufferName(),
+		Pending:          b.PendingCount,
+		AckPending:       b.AckPendingCount,
+		TotalMessages:    b.TotalMessages,
+		BufferLength:     b.BufferLength,
+		BufferUsage:      b.BufferUsage,
+		BufferUsageLimit: b.BufferUsageLimit,
+		IsFull:           b.IsFull,
+	}
+}
+
+func newInterStepBufferService(i *dfv1.InterStepBufferService) InterStepBufferService {
+	itype := ""
+	if i.Spec.JetStream != nil {
+		itype = "jetstream"
+	} else if i.Spec.Redis != nil {
+		itype = "redis"
+	}
+	return InterStepBufferService{
+		Namespace:    i.Namespace,
+		Name:         i.Name,
+		Type:         itype,
+		Phase:        string(i.Status.Phase),
+		Message:      i.Status.Message,
+		Labels:       i.Labels,
+		CreationTime: i.CreationTimestamp.Time,
+	}
+}
+
+func newPod(p *v1.Pod) Pod {
+	pod := Pod{
+		Namespace:    p.Namespace,
+		Name:         p.Name,
+		Phase:        string(p.Status.Phase),
+		Node:         p.Spec.NodeName,
+		Labels:       p.Labels,
+		CreationTime: p.CreationTimestamp.Time,
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == v1.PodReady {
+			pod.Ready = c.Status == v1.ConditionTrue
+		}
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		pod.Restarts += cs.RestartCount
+	}
+	return pod
+}
+
+func newVertexMetrics(ns, pipeline, vertex string, m *daemon.VertexMetrics, wm *daemon.VertexWatermark) VertexMetrics {
+	vm := VertexMetrics{
+		Namespace:       ns,
+		Pipeline:        pipeline,
+		Vertex:          vertex,
+		ProcessingRates: make(map[string]float64),
+		Pendings:        make(map[string]int64),
+	}
+	for w, r := range m.ProcessingRates {
+		if r >= 0 && r != isb.RateNotAvailable {
+			vm.ProcessingRates[w] = r
+		}
+	}
+	for w, p := range m.Pendings {
+		if p >= 0 && p != isb.PendingNotAvailable {
+			vm.Pendings[w] = p
+		}
+	}
+	if wm != nil && wm.Watermark != nil {
+		t := time.UnixMilli(*wm.Watermark)
+		vm.Watermark = &t
+	}
+	return vm
+}
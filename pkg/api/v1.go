@@ -0,0 +1,359 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/gorilla/mux"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"k8s.io/utils/strings/slices"
+)
+
+func objectName(ns, name string) string {
+	if ns == "" {
+		return name
+	}
+	return ns + "/" + name
+}
+
+// writePage writes the page of the n selected items requested, as sliced by items.
+func writePage(w http.ResponseWriter, req *http.Request, n int, items func(start, end int) interface{}) {
+	p, start, end, err := paginate(req, n)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	p.Items = items(start, end)
+	writeJSON(w, http.StatusOK, p)
+}
+
+// listNamespaces lists the namespaces with resources of the type given by the resource query parameter,
+// i.e. pipelines, the default, vertices or isbsvcs.
+func (a *v1API) listNamespaces(w http.ResponseWriter, req *http.Request) {
+	resource := req.URL.Query().Get("resource")
+	if resource == "" {
+		resource = "pipelines"
+	}
+	if !slices.Contains([]string{"pipelines", "vertices", "isbsvcs"}, resource) {
+		writeError(w, query.Errorf(query.ValidationError, "unsupported resource %q, must be one of pipelines, vertices or isbsvcs", resource))
+		return
+	}
+	v, err := a.cached(req, "namespaces "+resource+" "+a.namespace, func(ctx context.Context) (interface{}, error) {
+		if a.namespace != "" {
+			return []string{a.namespace}, nil
+		}
+		switch resource {
+		case "vertices":
+			return a.client.ListNamespacesWithVertices(ctx)
+		case "isbsvcs":
+			return a.client.ListNamespacesWithInterStepBufferServices(ctx)
+		}
+		return a.client.ListNamespacesWithPipelines(ctx)
+	})
+	if err != nil {
+		writeError(w, query.ClassifyError(err, "namespaces"))
+		return
+	}
+	f, err := parseFilter(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	namespaces := []string{}
+	for _, ns := range v.([]string) {
+		if f.matches(ns, "", nil) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	writePage(w, req, len(namespaces), func(start, end int) interface{} { return namespaces[start:end] })
+}
+
+func (a *v1API) listPipelines(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	f, err := parseFilter(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	v, err := a.cached(req, "pipelines "+ns, func(ctx context.Context) (interface{}, error) {
+		pls, err := a.client.ListPipelines(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+		pipelines := make([]Pipeline, len(pls))
+		for i := range pls {
+			pipelines[i] = newPipeline(&pls[i])
+		}
+		return pipelines, nil
+	})
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("pipelines %q", ns)))
+		return
+	}
+	pipelines := []Pipeline{}
+	for _, pl := range v.([]Pipeline) {
+		if f.matches(pl.Name, pl.Phase, pl.Labels) {
+			pipelines = append(pipelines, pl)
+		}
+	}
+	sort.Slice(pipelines, func(i, j int) bool {
+		return objectName(pipelines[i].Namespace, pipelines[i].Name) < objectName(pipelines[j].Namespace, pipelines[j].Name)
+	})
+	writePage(w, req, len(pipelines), func(start, end int) interface{} { return pipelines[start:end] })
+}
+
+func (a *v1API) getPipeline(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	name := mux.Vars(req)["pipeline"]
+	pl, err := a.client.GetPipeline(req.Context(), ns, name)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("pipeline %q", objectName(ns, name))))
+		return
+	}
+	writeJSON(w, http.StatusOK, newPipeline(pl))
+}
+
+func (a *v1API) listVertices(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	f, err := parseFilter(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	pipeline := mux.Vars(req)["pipeline"]
+	v, err := a.cached(req, "vertices "+objectName(ns, pipeline), func(ctx context.Context) (interface{}, error) {
+		vs, err := a.client.ListPipelineVertices(ctx, ns, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		vertices := make([]Vertex, len(vs))
+		for i := range vs {
+			vertices[i] = newVertex(&vs[i])
+		}
+		return vertices, nil
+	})
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("vertices of pipeline %q", objectName(ns, pipeline))))
+		return
+	}
+	vertices := []Vertex{}
+	for _, vertex := range v.([]Vertex) {
+		if f.matches(vertex.Name, vertex.Phase, vertex.Labels) {
+			vertices = append(vertices, vertex)
+		}
+	}
+	sort.Slice(vertices, func(i, j int) bool {
+		return vertices[i].Name < vertices[j].Name
+	})
+	writePage(w, req, len(vertices), func(start, end int) interface{} { return vertices[start:end] })
+}
+
+func (a *v1API) getVertex(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	pipeline, name := mux.Vars(req)["pipeline"], mux.Vars(req)["vertex"]
+	vertex, err := a.client.GetPipelineVertex(req.Context(), ns, pipeline, name)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("vertex %q of pipeline %q", name, objectName(ns, pipeline))))
+		return
+	}
+	writeJSON(w, http.StatusOK, newVertex(vertex))
+}
+
+func (a *v1API) listVertexPods(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	pipeline, vertex := mux.Vars(req)["pipeline"], mux.Vars(req)["vertex"]
+	v, err := a.cached(req, "vertex pods "+objectName(ns, pipeline)+"/"+vertex, func(ctx context.Context) (interface{}, error) {
+		ps, err := a.client.ListVertexPods(ctx, ns, pipeline, vertex)
+		if err != nil {
+			return nil, err
+		}
+		pods := make([]Pod, len(ps))
+		for i := range ps {
+			pods[i] = newPod(&ps[i])
+		}
+		return pods, nil
+	})
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("pods of vertex %q of pipeline %q", vertex, objectName(ns, pipeline))))
+		return
+	}
+	a.writePods(w, req, v.([]Pod))
+}
+
+func (a *v1API) getVertexMetrics(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	pipeline, vertex := mux.Vars(req)["pipeline"], mux.Vars(req)["vertex"]
+	object := fmt.Sprintf("vertex %q of pipeline %q", vertex, objectName(ns, pipeline))
+	m, err := a.client.GetVertexMetrics(req.Context(), ns, pipeline, vertex)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, object))
+		return
+	}
+	wm, err := a.client.GetVertexWatermark(req.Context(), ns, pipeline, vertex)
+	if err != nil {
+		// the metrics are still useful without the watermark
+		backend.Logger.Error("failed to retrieve watermark for vertex", "namespace", ns, "pipeline", pipeline, "vertex", vertex, "err", err)
+	}
+	writeJSON(w, http.StatusOK, newVertexMetrics(ns, pipeline, vertex, m, wm))
+}
+
+// listEdges lists the buffers between the vertices of a pipeline, filtered by the from and to query parameters.
+func (a *v1API) listEdges(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	pipeline := mux.Vars(req)["pipeline"]
+	v, err := a.cached(req, "edges "+objectName(ns, pipeline), func(ctx context.Context) (interface{}, error) {
+		bs, err := a.client.ListPipelineEdges(ctx, ns, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		edges := make([]Edge, len(bs))
+		for i := range bs {
+			edges[i] = newEdge(bs[i])
+		}
+		return edges, nil
+	})
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("edges of pipeline %q", objectName(ns, pipeline))))
+		return
+	}
+	from, to := req.URL.Query().Get("from"), req.URL.Query().Get("to")
+	edges := []Edge{}
+	for _, e := range v.([]Edge) {
+		if (from == "" || e.From == from) && (to == "" || e.To == to) {
+			edges = append(edges, e)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].Buffer < edges[j].Buffer
+	})
+	writePage(w, req, len(edges), func(start, end int) interface{} { return edges[start:end] })
+}
+
+func (a *v1API) listInterStepBufferServices(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	f, err := parseFilter(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	v, err := a.cached(req, "isbsvcs "+ns, func(ctx context.Context) (interface{}, error) {
+		is, err := a.client.ListInterStepBufferServices(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+		isbsvcs := make([]InterStepBufferService, len(is))
+		for i := range is {
+			isbsvcs[i] = newInterStepBufferService(&is[i])
+		}
+		return isbsvcs, nil
+	})
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("isbsvcs %q", ns)))
+		return
+	}
+	isbsvcType := req.URL.Query().Get("type")
+	isbsvcs := []InterStepBufferService{}
+	for _, i := range v.([]InterStepBufferService) {
+		if f.matches(i.Name, i.Phase, i.Labels) && (isbsvcType == "" || i.Type == isbsvcType) {
+			isbsvcs = append(isbsvcs, i)
+		}
+	}
+	sort.Slice(isbsvcs, func(i, j int) bool {
+		return objectName(isbsvcs[i].Namespace, isbsvcs[i].Name) < objectName(isbsvcs[j].Namespace, isbsvcs[j].Name)
+	})
+	writePage(w, req, len(isbsvcs), func(start, end int) interface{} { return isbsvcs[start:end] })
+}
+
+func (a *v1API) getInterStepBufferService(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	name := mux.Vars(req)["isbsvc"]
+	isbsvc, err := a.client.GetInterStepBufferService(req.Context(), ns, name)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("isbsvc %q", objectName(ns, name))))
+		return
+	}
+	writeJSON(w, http.StatusOK, newInterStepBufferService(isbsvc))
+}
+
+func (a *v1API) listInterStepBufferServicePods(w http.ResponseWriter, req *http.Request) {
+	ns, err := a.namespaceOf(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	isbsvc := mux.Vars(req)["isbsvc"]
+	v, err := a.cached(req, "isbsvc pods "+objectName(ns, isbsvc), func(ctx context.Context) (interface{}, error) {
+		ps, err := a.client.ListInterStepBufferServicePods(ctx, ns, isbsvc)
+		if err != nil {
+			return nil, err
+		}
+		pods := make([]Pod, len(ps))
+		for i := range ps {
+			pods[i] = newPod(&ps[i])
+		}
+		return pods, nil
+	})
+	if err != nil {
+		writeError(w, query.ClassifyError(err, fmt.Sprintf("pods of isbsvc %q", objectName(ns, isbsvc))))
+		return
+	}
+	a.writePods(w, req, v.([]Pod))
+}
+
+func (a *v1API) writePods(w http.ResponseWriter, req *http.Request, all []Pod) {
+	f, err := parseFilter(req)
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	pods := []Pod{}
+	for _, p := range all {
+		if f.matches(p.Name, p.Phase, p.Labels) {
+			pods = append(pods, p)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	writePage(w, req, len(pods), func(start, end int) interface{} { return pods[start:end] })
+}
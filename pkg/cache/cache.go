@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+var requests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "numaflow_datasource",
+	Subsystem: "cache",
+	Name:      "requests_total",
+	Help:      "Number of cache lookups by result, i.e. hit, miss, shared with a concurrent identical request or bypass.",
+}, []string{"kind", "result"})
+
+// computeTimeout bounds a computation, which runs on its own since it is shared by the callers of a key.
+const computeTimeout = time.Minute
+
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Cache holds computed values for a TTL, and deduplicates concurrent computations of the same key.
+// Errors are not cached. A nil Cache computes every value.
+type Cache struct {
+	ttl   time.Duration
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// New returns a cache of values kept for ttl, or nil when ttl is not positive.
+func New(ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &Cache{ttl: ttl, entries: make(map[string]entry)}
+}
+
+// TTL is how long values are kept.
+func (c *Cache) TTL() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.ttl
+}
+
+// Get returns the cached value of key, or computes and caches it. Values of a kind, e.g. a query type, are counted
+// together. With bypass the value is computed even if cached, and replaces the cached value.
+// Concurrent callers of the same key share a single computation, and must not modify the returned value. The
+// computation gets the values of ctx but not its cancellation, so that a caller that goes away does not fail the
+// others, and has its own timeout instead. A caller whose ctx is done returns without waiting for it.
+func (c *Cache) Get(ctx context.Context, kind string, key string, bypass bool, compute func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return compute(ctx)
+	}
+	if bypass {
+		requests.WithLabelValues(kind, "bypass").Inc()
+	} else if v, ok := c.lookup(key); ok {
+		requests.WithLabelValues(kind, "hit").Inc()
+		return v, nil
+	}
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		computeCtx, cancel := context.WithTimeout(detachedContext{ctx}, computeTimeout)
+		defer cancel()
+		v, err := compute(computeCtx)
+		if err == nil {
+			c.store(key, v)
+		}
+		return v, err
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		if !bypass {
+			result := "miss"
+			if r.Shared {
+				result = "shared"
+			}
+			requests.WithLabelValues(kind, result).Inc()
+		}
+		return r.Val, r.Err
+	}
+}
+
+// detachedContext has the values, e.g. the span, of its parent but is never done.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c *Cache) lookup(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *Cache) store(key string, v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	// expired entries are only dropped on writes, which bounds the map by the keys written within a TTL
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry{value: v, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+func TestGetComputesOnDetachedContext(t *testing.T) {
+	c := New(time.Minute)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	first, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "first"))
+
+	firstErr := make(chan error)
+	go func() {
+		_, err := c.Get(first, "test", "key", false, func(ctx context.Context) (interface{}, error) {
+			assert.Equal(t, "first", ctx.Value(ctxKey{}))
+			_, ok := ctx.Deadline()
+			assert.True(t, ok, "computation has its own deadline")
+			close(started)
+			<-release
+			return "value", ctx.Err()
+		})
+		firstErr <- err
+	}()
+	<-started
+
+	secondVal := make(chan interface{})
+	go func() {
+		v, err := c.Get(context.Background(), "test", "key", false, func(ctx context.Context) (interface{}, error) {
+			return nil, errors.New("computed twice")
+		})
+		assert.NoError(t, err)
+		secondVal <- v
+	}()
+
+	// the first caller going away neither fails the computation nor waits for it
+	cancel()
+	require.ErrorIs(t, <-firstErr, context.Canceled)
+	close(release)
+	assert.Equal(t, "value", <-secondVal)
+
+	v, err := c.Get(context.Background(), "test", "key", false, func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("not cached")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", v)
+}
+
+func TestGetDoesNotCacheErrors(t *testing.T) {
+	c := New(time.Minute)
+	_, err := c.Get(context.Background(), "test", "key", false, func(ctx context.Context) (interface{}, error) {
+		return nil, context.DeadlineExceeded
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	v, err := c.Get(context.Background(), "test", "key", false, func(ctx context.Context) (interface{}, error) {
+		return "value", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", v)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Access is a resource and verb the datasource needs to be allowed.
+type Access struct {
+	Group       string
+	Resource    string
+	Subresource string
+	Verb        string
+	// ClusterScoped resources, e.g. nodes, are not checked in the namespace scope of the client.
+	ClusterScoped bool
+	// Optional access is only needed by some query types or fallbacks.
+	Optional bool
+}
+
+// RequiredAccess lists every resource and verb used by the datasource.
+var RequiredAccess = []Access{
+	{Group: "numaflow.numaproj.io", Resource: "pipelines", Verb: "get"},
+	{Group: "numaflow.numaproj.io", Resource: "pipelines", Verb: "list"},
+	{Group: "numaflow.numaproj.io", Resource: "pipelines", Verb: "watch", Optional: true},
+	{Group: "numaflow.numaproj.io", Resource: "vertices", Verb: "list"},
+	{Group: "numaflow.numaproj.io", Resource: "vertices", Verb: "watch", Optional: true},
+	{Group: "numaflow.numaproj.io", Resource: "interstepbufferservices", Verb: "get"},
+	{Group: "numaflow.numaproj.io", Resource: "interstepbufferservices", Verb: "list"},
+	{Group: "", Resource: "pods", Verb: "list"},
+	{Group: "metrics.k8s.io", Resource: "pods", Verb: "get", Optional: true},
+	{Group: "metrics.k8s.io", Resource: "pods", Verb: "list", Optional: true},
+	{Group: "", Resource: "nodes", Subresource: "proxy", Verb: "get", ClusterScoped: true, Optional: true},
+	{Group: "", Resource: "pods", Subresource: "proxy", Verb: "get", Optional: true},
+	{Group: "", Resource: "pods", Subresource: "log", Verb: "get", Optional: true},
+	{Group: "", Resource: "events", Verb: "list", Optional: true},
+	{Group: "", Resource: "secrets", Verb: "get", Optional: true},
+	{Group: "apps", Resource: "deployments", Verb: "list", Optional: true},
+	{Group: "apps", Resource: "replicasets", Verb: "list", Optional: true},
+	{Group: "apps", Resource: "statefulsets", Verb: "list", Optional: true},
+	{Group: "", Resource: "services", Verb: "list", Optional: true},
+	{Group: "batch", Resource: "jobs", Verb: "list", Optional: true},
+}
+
+// Namespace returns the namespace scope of the client, empty for all namespaces.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// CheckAccess asks the API server whether the datasource is allowed the given access in the namespace scope of the client,
+// or cluster-wide for cluster-scoped resources.
+func (c *Client) CheckAccess(ctx context.Context, a Access) (_ bool, _ string, err error) {
+	ctx, end := startCall(ctx, "CheckAccess", attribute.String("namespace", c.namespace), attribute.String("resource", a.Resource), attribute.String("verb", a.Verb))
+	defer end(&err)
+	namespace := c.namespace
+	if a.ClusterScoped {
+		namespace = ""
+	}
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Group:       a.Group,
+				Resource:    a.Resource,
+				Subresource: a.Subresource,
+				Verb:        a.Verb,
+			},
+		},
+	}
+	r, err := c.kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", err
+	}
+	return r.Status.Allowed, r.Status.Reason, nil
+}
+
+// ProbeMetricsAPI checks that the metrics.k8s.io API is served and readable.
+func (c *Client) ProbeMetricsAPI(ctx context.Context) (err error) {
+	ctx, end := startCall(ctx, "ProbeMetricsAPI", attribute.String("namespace", c.namespace))
+	defer end(&err)
+	_, err = c.metricsClient.MetricsV1beta1().PodMetricses(c.namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	return err
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckAccessScopesReviews(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	reviewed := make(map[string]string)
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		reviewed[attrs.Resource+"/"+attrs.Subresource] = attrs.Namespace
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+	c := &Client{kubeClient: kubeClient, namespace: "ns"}
+
+	for _, a := range RequiredAccess {
+		allowed, _, err := c.CheckAccess(context.Background(), a)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Equal(t, "", reviewed["nodes/proxy"])
+	assert.Equal(t, "ns", reviewed["pods/proxy"])
+	assert.Equal(t, "ns", reviewed["pipelines/"])
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+)
+
+// ListEvents lists the events in a namespace, or in all namespaces.
+func (c *Client) ListEvents(ctx context.Context, ns string) (_ []v1.Event, err error) {
+	ctx, end := startCall(ctx, "ListEvents", attribute.String("namespace", ns))
+	defer end(&err)
+	events, err := c.kubeClient.CoreV1().Events(ns).List(ctx, c.listOptions)
+	if err != nil {
+		return nil, err
+	}
+	return events.Items, nil
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StreamStats are the JetStream server's view of the stream and consumer backing a single buffer.
+type StreamStats struct {
+	Buffer string
+	Stream string
+	// Err is set when the stream or its consumer could not be read, the other fields are then partially or not set.
+	Err error
+
+	Messages      uint64
+	Bytes         uint64
+	FirstSequence uint64
+	LastSequence  uint64
+
+	ConsumerPending    uint64
+	ConsumerAckPending int
+	Redelivered        int
+
+	Leader          string
+	Replicas        int
+	CurrentReplicas int
+}
+
+// JetStreamStreamName is the name of the stream Numaflow creates for a buffer, see "pkg/isbsvc/jetstream_service.go".
+// The stream's durable consumer has the same name.
+func JetStreamStreamName(pipeline, buffer string) string {
+	return fmt.Sprintf("%s-%s", pipeline, buffer)
+}
+
+// JetStreamConn is a connection to a JetStream server, i.e. a *nats.Conn unless the cluster is simulated.
+type JetStreamConn interface {
+	JetStream(opts ...nats.JSOpt) (nats.JetStreamContext, error)
+	Close()
+}
+
+// ConnectJetStream connects to the JetStream isbsvc with the client credentials referenced in its status.
+// The connection must be closed by the caller.
+func (c *Client) ConnectJetStream(ctx context.Context, isbsvc *dfv1.InterStepBufferService) (_ JetStreamConn, err error) {
+	ctx, end := startCall(ctx, "ConnectJetStream", attribute.String("namespace", isbsvc.Namespace), attribute.String("isbsvc", isbsvc.Name))
+	defer end(&err)
+	if c.simulator != nil {
+		return c.simulator.connectJetStream(isbsvc)
+	}
+	config := isbsvc.Status.Config.JetStream
+	if config == nil || config.URL == "" {
+		return nil, fmt.Errorf("isbsvc %s/%s is not a configured jetstream isbsvc", isbsvc.Namespace, isbsvc.Name)
+	}
+	opts := []nats.Option{nats.Name("numaflow-datasource")}
+	if config.Auth != nil {
+		user, err := c.secretValue(ctx, isbsvc.Namespace, config.Auth.User)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jetstream user, %w", err)
+		}
+		password, err := c.secretValue(ctx, isbsvc.Namespace, config.Auth.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jetstream password, %w", err)
+		}
+		opts = append(opts, nats.UserInfo(user, password))
+	}
+	if config.TLSEnabled {
+		// same as Numaflow's own clients, the server certificate is self-signed
+		opts = append(opts, nats.Secure(&tls.Config{InsecureSkipVerify: true}))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		opts = append(opts, nats.Timeout(time.Until(deadline)))
+	}
+	nc, err := nats.Connect(config.URL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return nc, nil
+}
+
+func (c *Client) secretValue(ctx context.Context, ns string, selector *v1.SecretKeySelector) (string, error) {
+	if selector == nil {
+		return "", errors.New("no secret key selector")
+	}
+	secret, err := c.kubeClient.CoreV1().Secrets(ns).Get(ctx, selector.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	v, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret %s/%s", selector.Key, ns, selector.Name)
+	}
+	return string(v), nil
+}
+
+// GetJetStreamBufferStats reads the streams and consumers of the given buffers of a pipeline.
+// It only needs a JetStream context, so it can run against any server, e.g. an embedded one.
+func GetJetStreamBufferStats(ctx context.Context, js nats.JetStreamContext, pipeline string, buffers []string) (_ []StreamStats, err error) {
+	ctx, end := startCall(ctx, "GetJetStreamBufferStats", attribute.String("pipeline", pipeline))
+	defer end(&err)
+	stats := make([]StreamStats, len(buffers))
+	for i, buffer := range buffers {
+		s := &stats[i]
+		s.Buffer = buffer
+		s.Stream = JetStreamStreamName(pipeline, buffer)
+		stream, err := js.StreamInfo(s.Stream, nats.Context(ctx))
+		if err != nil {
+			s.Err = fmt.Errorf("failed to get information of stream %q, %w", s.Stream, err)
+			continue
+		}
+		s.Messages = stream.State.Msgs
+		s.Bytes = stream.State.Bytes
+		s.FirstSequence = stream.State.FirstSeq
+		s.LastSequence = stream.State.LastSeq
+		s.Replicas = stream.Config.Replicas
+		if s.Replicas == 0 {
+			s.Replicas = 1
+		}
+		if stream.Cluster != nil {
+			s.Leader = stream.Cluster.Leader
+			s.CurrentReplicas = 1 // the leader
+			for _, peer := range stream.Cluster.Replicas {
+				if peer.Current {
+					s.CurrentReplicas++
+				}
+			}
+		} else {
+			s.CurrentReplicas = 1
+		}
+		consumer, err := js.ConsumerInfo(s.Stream, s.Stream, nats.Context(ctx))
+		if err != nil {
+			s.Err = fmt.Errorf("failed to get consumer information of stream %q, %w", s.Stream, err)
+			continue
+		}
+		s.ConsumerPending = consumer.NumPending
+		s.ConsumerAckPending = consumer.NumAckPending
+		s.Redelivered = consumer.NumRedelivered
+	}
+	return stats, nil
+}
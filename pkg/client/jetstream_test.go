@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runJetStreamServer(t *testing.T) *nats.Conn {
+	t.Helper()
+	opts := natstest.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	s := natstest.RunServer(&opts)
+	t.Cleanup(s.Shutdown)
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func TestGetJetStreamBufferStats(t *testing.T) {
+	nc := runJetStreamServer(t)
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	// the stream and durable consumer of a buffer, as Numaflow creates them
+	name := JetStreamStreamName("pl", "pl-in-map")
+	_, err = js.AddStream(&nats.StreamConfig{Name: name, Subjects: []string{name}, Retention: nats.WorkQueuePolicy})
+	require.NoError(t, err)
+	_, err = js.AddConsumer(name, &nats.ConsumerConfig{Durable: name, AckPolicy: nats.AckExplicitPolicy, AckWait: time.Minute})
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = js.Publish(name, []byte("msg"))
+		require.NoError(t, err)
+	}
+
+	sub, err := js.PullSubscribe(name, name, nats.Bind(name, name))
+	require.NoError(t, err)
+	msgs, err := sub.Fetch(3)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	require.NoError(t, msgs[1].Nak())
+	// acknowledged synchronously after the nak, so that the server has processed both
+	require.NoError(t, msgs[0].AckSync())
+	// the third message stays unacknowledged, the nacked one is redelivered
+	msgs, err = sub.Fetch(1)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	stats, err := GetJetStreamBufferStats(context.Background(), js, "pl", []string{"pl-in-map", "pl-map-out"})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	s := stats[0]
+	require.NoError(t, s.Err)
+	assert.Equal(t, name, s.Stream)
+	assert.Equal(t, uint64(4), s.Messages)
+	assert.Equal(t, uint64(5), s.LastSequence)
+	assert.Equal(t, uint64(2), s.ConsumerPending)
+	assert.Equal(t, 2, s.ConsumerAckPending)
+	assert.Equal(t, 1, s.Redelivered)
+	assert.Equal(t, 1, s.Replicas)
+	assert.Equal(t, 1, s.CurrentReplicas)
+
+	assert.Equal(t, "pl-map-out", stats[1].Buffer)
+	assert.Error(t, stats[1].Err, "the stream of the buffer does not exist")
+}
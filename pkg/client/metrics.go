@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "numaflow_datasource",
+		Subsystem: "client",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of Kubernetes, metrics and daemon service calls made by the datasource.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"call", "outcome"})
+	daemonDialFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "numaflow_datasource",
+		Subsystem: "client",
+		Name:      "daemon_dial_failures_total",
+		Help:      "Number of failed attempts to create a daemon service client.",
+	}, []string{"call"})
+)
+
+// Outcome classifies the result of a call for use as a metric label.
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, context.DeadlineExceeded), apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return "timeout"
+	case apierrors.IsNotFound(err):
+		return "not_found"
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+		return "forbidden"
+	}
+	// status.FromError does not unwrap errors, unlike the Kubernetes error helpers
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		s := grpcErr.GRPCStatus()
+		switch s.Code() {
+		case codes.DeadlineExceeded:
+			return "timeout"
+		case codes.Unavailable:
+			return "unavailable"
+		case codes.NotFound:
+			return "not_found"
+		}
+	}
+	return "error"
+}
+
+// observe records the duration and outcome of a call, it is meant to be deferred with a pointer to the named error result.
+func observe(call string, start time.Time, err *error) {
+	requestDuration.WithLabelValues(call, Outcome(*err)).Observe(time.Since(start).Seconds())
+}
@@ -5,7 +5,6 @@ package client
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 
@@ -14,9 +13,12 @@ import (
 	dfv1versiond "github.com/numaproj/numaflow/pkg/client/clientset/versioned"
 	dfv1clients "github.com/numaproj/numaflow/pkg/client/clientset/versioned/typed/numaflow/v1alpha1"
 	daemonclient "github.com/numaproj/numaflow/pkg/daemon/client"
+	"go.opentelemetry.io/otel/attribute"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -26,13 +28,26 @@ import (
 
 type Client struct {
 	kubeClient     kubernetes.Interface
-	metricsClient  *metricsversiond.Clientset
+	metricsClient  metricsversiond.Interface
 	numaflowClient dfv1clients.NumaflowV1alpha1Interface
-	listOptions    metav1.ListOptions
-	namespace      string
+	podUsage       PodUsageSource
+	dialDaemon     func(ns, pipeline string) (daemonService, error)
+	// simulator is set for clients of a simulated cluster, see NewSimulatedClient.
+	simulator   *simulator
+	listOptions metav1.ListOptions
+	namespace   string
 }
 
-func NewClient(namespace string) (*Client, error) {
+// daemonService is the subset of the daemon service client used by the datasource.
+type daemonService interface {
+	ListPipelineBuffers(ctx context.Context, pipeline string) ([]*daemon.BufferInfo, error)
+	GetPipelineBuffer(ctx context.Context, pipeline, buffer string) (*daemon.BufferInfo, error)
+	GetVertexMetrics(ctx context.Context, pipeline, vertex string) (*daemon.VertexMetrics, error)
+	GetVertexWatermark(ctx context.Context, pipeline, vertex string) (*daemon.VertexWatermark, error)
+	Close() error
+}
+
+func NewClient(namespace string, podUsageSource PodUsageSourceType) (*Client, error) {
 	var restConfig *rest.Config
 	var err error
 	kubeconfig := os.Getenv("KUBECONFIG")
@@ -57,18 +72,26 @@ func NewClient(namespace string) (*Client, error) {
 	}
 	metricsClient := metricsversiond.NewForConfigOrDie(restConfig)
 	numaflowClient := dfv1versiond.NewForConfigOrDie(restConfig).NumaflowV1alpha1()
+	podUsage, err := newPodUsageSource(podUsageSource, kubeClient, metricsClient)
+	if err != nil {
+		return nil, err
+	}
 	return &Client{
 		kubeClient:     kubeClient,
 		metricsClient:  metricsClient,
 		numaflowClient: numaflowClient,
+		podUsage:       podUsage,
+		dialDaemon:     dialDaemonService,
 		// for now hard-code default limit, in future can allow overriding in data source or in each data query
 		listOptions: metav1.ListOptions{Limit: 1000},
 		namespace:   namespace,
 	}, nil
 }
 
-func (c *Client) ListNamespacesWithPipelines() ([]string, error) {
-	l, err := c.numaflowClient.Pipelines(c.namespace).List(context.Background(), c.listOptions)
+func (c *Client) ListNamespacesWithPipelines(ctx context.Context) (_ []string, err error) {
+	ctx, end := startCall(ctx, "ListNamespacesWithPipelines")
+	defer end(&err)
+	l, err := c.numaflowClient.Pipelines(c.namespace).List(ctx, c.listOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -83,8 +106,10 @@ func (c *Client) ListNamespacesWithPipelines() ([]string, error) {
 	return namespaces, nil
 }
 
-func (c *Client) ListNamespacesWithVertices() ([]string, error) {
-	l, err := c.numaflowClient.Vertices(c.namespace).List(context.Background(), c.listOptions)
+func (c *Client) ListNamespacesWithVertices(ctx context.Context) (_ []string, err error) {
+	ctx, end := startCall(ctx, "ListNamespacesWithVertices")
+	defer end(&err)
+	l, err := c.numaflowClient.Vertices(c.namespace).List(ctx, c.listOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -99,8 +124,10 @@ func (c *Client) ListNamespacesWithVertices() ([]string, error) {
 	return namespaces, nil
 }
 
-func (c *Client) ListNamespacesWithInterStepBufferServices() ([]string, error) {
-	l, err := c.numaflowClient.InterStepBufferServices(c.namespace).List(context.Background(), c.listOptions)
+func (c *Client) ListNamespacesWithInterStepBufferServices(ctx context.Context) (_ []string, err error) {
+	ctx, end := startCall(ctx, "ListNamespacesWithInterStepBufferServices")
+	defer end(&err)
+	l, err := c.numaflowClient.InterStepBufferServices(c.namespace).List(ctx, c.listOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -115,166 +142,235 @@ func (c *Client) ListNamespacesWithInterStepBufferServices() ([]string, error) {
 	return namespaces, nil
 }
 
-func (c *Client) ListPipelines(ns string) ([]dfv1.Pipeline, error) {
-	plList, err := c.numaflowClient.Pipelines(ns).List(context.Background(), c.listOptions)
+func (c *Client) ListPipelines(ctx context.Context, ns string) (_ []dfv1.Pipeline, err error) {
+	ctx, end := startCall(ctx, "ListPipelines", attribute.String("namespace", ns))
+	defer end(&err)
+	plList, err := c.numaflowClient.Pipelines(ns).List(ctx, c.listOptions)
 	if err != nil {
 		return nil, err
 	}
 	return plList.Items, nil
 }
 
-func (c *Client) ListVertices(ns string) ([]dfv1.Vertex, error) {
-	vertexList, err := c.numaflowClient.Vertices(ns).List(context.Background(), metav1.ListOptions{})
+func (c *Client) ListVertices(ctx context.Context, ns string) (_ []dfv1.Vertex, err error) {
+	ctx, end := startCall(ctx, "ListVertices", attribute.String("namespace", ns))
+	defer end(&err)
+	vertexList, err := c.numaflowClient.Vertices(ns).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 	return vertexList.Items, nil
 }
 
-func (c *Client) ListPipelineVertices(ns, pipeline string) ([]dfv1.Vertex, error) {
+func (c *Client) ListPipelineVertices(ctx context.Context, ns, pipeline string) (_ []dfv1.Vertex, err error) {
+	ctx, end := startCall(ctx, "ListPipelineVertices", attribute.String("namespace", ns), attribute.String("pipeline", pipeline))
+	defer end(&err)
 	lo := c.listOptions.DeepCopy()
 	lo.LabelSelector = fmt.Sprintf("%s=%s", dfv1.KeyPipelineName, pipeline)
-	vertices, err := c.numaflowClient.Vertices(ns).List(context.Background(), *lo)
+	vertices, err := c.numaflowClient.Vertices(ns).List(ctx, *lo)
 	if err != nil {
 		return nil, err
 	}
 	return vertices.Items, nil
 }
 
-func (c *Client) ListInterStepBufferServices(ns string) ([]dfv1.InterStepBufferService, error) {
-	isbsvcList, err := c.numaflowClient.InterStepBufferServices(ns).List(context.Background(), c.listOptions)
+func (c *Client) ListInterStepBufferServices(ctx context.Context, ns string) (_ []dfv1.InterStepBufferService, err error) {
+	ctx, end := startCall(ctx, "ListInterStepBufferServices", attribute.String("namespace", ns))
+	defer end(&err)
+	isbsvcList, err := c.numaflowClient.InterStepBufferServices(ns).List(ctx, c.listOptions)
 	if err != nil {
 		return nil, err
 	}
 	return isbsvcList.Items, nil
 }
 
-func (c *Client) GetPipeline(ns, pipeline string) (*dfv1.Pipeline, error) {
-	pl, err := c.numaflowClient.Pipelines(ns).Get(context.Background(), pipeline, metav1.GetOptions{})
+func (c *Client) GetPipeline(ctx context.Context, ns, pipeline string) (_ *dfv1.Pipeline, err error) {
+	ctx, end := startCall(ctx, "GetPipeline", attribute.String("namespace", ns), attribute.String("pipeline", pipeline))
+	defer end(&err)
+	pl, err := c.numaflowClient.Pipelines(ns).Get(ctx, pipeline, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 	return pl, nil
 }
 
-func (c *Client) GetPipelineVertex(ns, pipeline, vertex string) (*dfv1.Vertex, error) {
+func (c *Client) GetPipelineVertex(ctx context.Context, ns, pipeline, vertex string) (_ *dfv1.Vertex, err error) {
+	ctx, end := startCall(ctx, "GetPipelineVertex", attribute.String("namespace", ns), attribute.String("pipeline", pipeline), attribute.String("vertex", vertex))
+	defer end(&err)
 	lo := c.listOptions.DeepCopy()
 	lo.LabelSelector = fmt.Sprintf("%s=%s,%s=%s", dfv1.KeyPipelineName, pipeline, dfv1.KeyVertexName, vertex)
-	vertices, err := c.numaflowClient.Vertices(ns).List(context.Background(), *lo)
+	vertices, err := c.numaflowClient.Vertices(ns).List(ctx, *lo)
 	if err != nil {
 		return nil, err
 	}
 	if len(vertices.Items) == 0 {
-		return nil, errors.New(fmt.Sprintf("Vertex %q not found", vertex))
+		return nil, apierrors.NewNotFound(dfv1.Resource("vertices"), vertex)
 	}
 	return &vertices.Items[0], err
 }
 
-func (c *Client) GetInterStepBufferService(ns, isbsvc string) (*dfv1.InterStepBufferService, error) {
-	i, err := c.numaflowClient.InterStepBufferServices(ns).Get(context.Background(), isbsvc, metav1.GetOptions{})
+func (c *Client) GetInterStepBufferService(ctx context.Context, ns, isbsvc string) (_ *dfv1.InterStepBufferService, err error) {
+	ctx, end := startCall(ctx, "GetInterStepBufferService", attribute.String("namespace", ns), attribute.String("isbsvc", isbsvc))
+	defer end(&err)
+	i, err := c.numaflowClient.InterStepBufferServices(ns).Get(ctx, isbsvc, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 	return i, nil
 }
 
-func (c *Client) ListVertexPods(ns, pipeline, vertex string) ([]v1.Pod, error) {
+// WatchPipelines watches pipelines in the namespace scope of the client, starting at resourceVersion.
+func (c *Client) WatchPipelines(ctx context.Context, resourceVersion string) (watch.Interface, error) {
+	return c.numaflowClient.Pipelines(c.namespace).Watch(ctx, metav1.ListOptions{ResourceVersion: resourceVersion})
+}
+
+// WatchVertices watches vertices in the namespace scope of the client, starting at resourceVersion.
+func (c *Client) WatchVertices(ctx context.Context, resourceVersion string) (watch.Interface, error) {
+	return c.numaflowClient.Vertices(c.namespace).Watch(ctx, metav1.ListOptions{ResourceVersion: resourceVersion})
+}
+
+// ListPipelinePods lists the pods of every vertex and of the daemon service of a pipeline.
+func (c *Client) ListPipelinePods(ctx context.Context, ns, pipeline string) (_ []v1.Pod, err error) {
+	ctx, end := startCall(ctx, "ListPipelinePods", attribute.String("namespace", ns), attribute.String("pipeline", pipeline))
+	defer end(&err)
+	lo := c.listOptions.DeepCopy()
+	lo.LabelSelector = fmt.Sprintf("%s=%s", dfv1.KeyPipelineName, pipeline)
+	pods, err := c.kubeClient.CoreV1().Pods(ns).List(ctx, *lo)
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, err
+}
+
+func (c *Client) ListVertexPods(ctx context.Context, ns, pipeline, vertex string) (_ []v1.Pod, err error) {
+	ctx, end := startCall(ctx, "ListVertexPods", attribute.String("namespace", ns), attribute.String("pipeline", pipeline), attribute.String("vertex", vertex))
+	defer end(&err)
 	lo := c.listOptions.DeepCopy()
 	lo.LabelSelector = fmt.Sprintf("%s=%s,%s=%s", dfv1.KeyPipelineName, pipeline, dfv1.KeyVertexName, vertex)
-	pods, err := c.kubeClient.CoreV1().Pods(ns).List(context.Background(), *lo)
+	pods, err := c.kubeClient.CoreV1().Pods(ns).List(ctx, *lo)
 	if err != nil {
 		return nil, err
 	}
 	return pods.Items, err
 }
 
-func (c *Client) ListInterStepBufferServicePods(ns, isbsvc string) ([]v1.Pod, error) {
+func (c *Client) ListInterStepBufferServicePods(ctx context.Context, ns, isbsvc string) (_ []v1.Pod, err error) {
+	ctx, end := startCall(ctx, "ListInterStepBufferServicePods", attribute.String("namespace", ns), attribute.String("isbsvc", isbsvc))
+	defer end(&err)
 	lo := c.listOptions.DeepCopy()
 	lo.LabelSelector = fmt.Sprintf("%s=%s", dfv1.KeyISBSvcName, isbsvc)
-	pods, err := c.kubeClient.CoreV1().Pods(ns).List(context.Background(), *lo)
+	pods, err := c.kubeClient.CoreV1().Pods(ns).List(ctx, *lo)
 	if err != nil {
 		return nil, err
 	}
 	return pods.Items, err
 }
 
-func (c *Client) ListPodsMetrics(ns string) ([]v1beta1.PodMetrics, error) {
-	l, err := c.metricsClient.MetricsV1beta1().PodMetricses(ns).List(context.Background(), c.listOptions)
+func (c *Client) ListPodsMetrics(ctx context.Context, ns string) (_ []v1beta1.PodMetrics, err error) {
+	ctx, end := startCall(ctx, "ListPodsMetrics", attribute.String("namespace", ns))
+	defer end(&err)
+	l, err := c.metricsClient.MetricsV1beta1().PodMetricses(ns).List(ctx, c.listOptions)
 	if err != nil {
 		return nil, err
 	}
 	return l.Items, nil
 }
 
-func (c *Client) GetPodMetrics(ns, po string) (*v1beta1.PodMetrics, error) {
-	m, err := c.metricsClient.MetricsV1beta1().PodMetricses(ns).Get(context.Background(), po, metav1.GetOptions{})
+func (c *Client) GetPodMetrics(ctx context.Context, ns, po string) (_ *v1beta1.PodMetrics, err error) {
+	ctx, end := startCall(ctx, "GetPodMetrics", attribute.String("namespace", ns), attribute.String("pod", po))
+	defer end(&err)
+	m, err := c.metricsClient.MetricsV1beta1().PodMetricses(ns).Get(ctx, po, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-func (c *Client) ListPipelineEdges(ns, pipeline string) ([]*daemon.BufferInfo, error) {
-	client, err := daemonclient.NewDaemonServiceClient(daemonSvcAddress(ns, pipeline))
+func (c *Client) ListPipelineEdges(ctx context.Context, ns, pipeline string) (_ []*daemon.BufferInfo, err error) {
+	ctx, end := startCall(ctx, "ListPipelineEdges", attribute.String("namespace", ns), attribute.String("pipeline", pipeline))
+	defer end(&err)
+	client, err := c.dialDaemon(ns, pipeline)
 	if err != nil {
+		daemonDialFailures.WithLabelValues("ListPipelineEdges").Inc()
 		return nil, err
 	}
 	defer func() {
 		_ = client.Close()
 	}()
-	l, err := client.ListPipelineBuffers(context.Background(), pipeline)
+	ctx = injectTraceContext(ctx)
+	l, err := client.ListPipelineBuffers(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
 	return l, nil
 }
 
-func (c *Client) GetPipelineEdge(ns, pipeline, edge string) (*daemon.BufferInfo, error) {
-	client, err := daemonclient.NewDaemonServiceClient(daemonSvcAddress(ns, pipeline))
+func (c *Client) GetPipelineEdge(ctx context.Context, ns, pipeline, edge string) (_ *daemon.BufferInfo, err error) {
+	ctx, end := startCall(ctx, "GetPipelineEdge", attribute.String("namespace", ns), attribute.String("pipeline", pipeline), attribute.String("edge", edge))
+	defer end(&err)
+	client, err := c.dialDaemon(ns, pipeline)
 	if err != nil {
+		daemonDialFailures.WithLabelValues("GetPipelineEdge").Inc()
 		return nil, err
 	}
 	defer func() {
 		_ = client.Close()
 	}()
+	ctx = injectTraceContext(ctx)
 	// Assume edge is the buffer name
-	i, err := client.GetPipelineBuffer(context.Background(), pipeline, edge)
+	i, err := client.GetPipelineBuffer(ctx, pipeline, edge)
 	if err != nil {
 		return nil, err
 	}
 	return i, nil
 }
 
-func (c *Client) GetVertexMetrics(ns, pipeline, vertex string) (*daemon.VertexMetrics, error) {
-	client, err := daemonclient.NewDaemonServiceClient(daemonSvcAddress(ns, pipeline))
+func (c *Client) GetVertexMetrics(ctx context.Context, ns, pipeline, vertex string) (_ *daemon.VertexMetrics, err error) {
+	ctx, end := startCall(ctx, "GetVertexMetrics", attribute.String("namespace", ns), attribute.String("pipeline", pipeline), attribute.String("vertex", vertex))
+	defer end(&err)
+	client, err := c.dialDaemon(ns, pipeline)
 	if err != nil {
+		daemonDialFailures.WithLabelValues("GetVertexMetrics").Inc()
 		return nil, err
 	}
 	defer func() {
 		_ = client.Close()
 	}()
-	l, err := client.GetVertexMetrics(context.Background(), pipeline, vertex)
+	ctx = injectTraceContext(ctx)
+	l, err := client.GetVertexMetrics(ctx, pipeline, vertex)
 	if err != nil {
 		return nil, err
 	}
 	return l, nil
 }
 
-func (c *Client) GetVertexWatermark(ns, pipeline, vertex string) (*daemon.VertexWatermark, error) {
-	client, err := daemonclient.NewDaemonServiceClient(daemonSvcAddress(ns, pipeline))
+func (c *Client) GetVertexWatermark(ctx context.Context, ns, pipeline, vertex string) (_ *daemon.VertexWatermark, err error) {
+	ctx, end := startCall(ctx, "GetVertexWatermark", attribute.String("namespace", ns), attribute.String("pipeline", pipeline), attribute.String("vertex", vertex))
+	defer end(&err)
+	client, err := c.dialDaemon(ns, pipeline)
 	if err != nil {
+		daemonDialFailures.WithLabelValues("GetVertexWatermark").Inc()
 		return nil, err
 	}
 	defer func() {
 		_ = client.Close()
 	}()
-	l, err := client.GetVertexWatermark(context.Background(), pipeline, vertex)
+	ctx = injectTraceContext(ctx)
+	l, err := client.GetVertexWatermark(ctx, pipeline, vertex)
 	if err != nil {
 		return nil, err
 	}
 	return l, nil
 }
 
+func dialDaemonService(ns, pipeline string) (daemonService, error) {
+	client, err := daemonclient.NewDaemonServiceClient(daemonSvcAddress(ns, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
 func daemonSvcAddress(ns, pipeline string) string {
 	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", fmt.Sprintf("%s-daemon-svc", pipeline), ns, dfv1.DaemonServicePort)
 }
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestGetPipelineVertexNotFound(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := NewSimulatedClient(ctx, "ns", SimulationOptions{Pipelines: 1, Vertices: 3})
+	require.NoError(t, err)
+
+	v, err := c.GetPipelineVertex(ctx, "ns", "orders", "in")
+	require.NoError(t, err)
+	assert.Equal(t, "in", v.Spec.Name)
+
+	_, err = c.GetPipelineVertex(ctx, "ns", "orders", "missing")
+	require.Error(t, err)
+	assert.True(t, apierrors.IsNotFound(err), "%v is not a not found error", err)
+	assert.Equal(t, "not_found", Outcome(err))
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetPodLogs returns the log of a container of a pod since the given time, at most its last tailLines lines and
+// limitBytes bytes. Each line starts with its RFC3339 timestamp, followed by a space.
+func (c *Client) GetPodLogs(ctx context.Context, ns, pod, container string, since time.Time, tailLines, limitBytes int64) (_ []byte, err error) {
+	ctx, end := startCall(ctx, "GetPodLogs", attribute.String("namespace", ns), attribute.String("pod", pod), attribute.String("container", container))
+	defer end(&err)
+	opts := &v1.PodLogOptions{
+		Container:  container,
+		Timestamps: true,
+		TailLines:  &tailLines,
+		LimitBytes: &limitBytes,
+	}
+	if !since.IsZero() {
+		t := metav1.NewTime(since)
+		opts.SinceTime = &t
+	}
+	return c.kubeClient.CoreV1().Pods(ns).GetLogs(pod, opts).DoRaw(ctx)
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/numaproj/numaflow/pkg/apis/proto/daemon"
+	"github.com/prometheus/common/expfmt"
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Metric and label names exposed by vertex pods, see "pkg/metrics/metrics.go" in Numaflow.
+const (
+	vertexProcessingRateMetric  = "vertex_processing_rate"
+	vertexPendingMessagesMetric = "vertex_pending_messages"
+	periodLabel                 = "period"
+)
+
+// GetVertexPodMetrics scrapes the processing rates and pendings of a single vertex pod through the API server pod proxy.
+// The daemon service only scrapes the first pod of a vertex, which for a keyed reduce vertex is only its first partition.
+func (c *Client) GetVertexPodMetrics(ctx context.Context, pod *v1.Pod) (_ *daemon.VertexMetrics, err error) {
+	ctx, end := startCall(ctx, "GetVertexPodMetrics", attribute.String("namespace", pod.Namespace), attribute.String("pod", pod.Name))
+	defer end(&err)
+	b, err := c.kubeClient.CoreV1().Pods(pod.Namespace).
+		ProxyGet("https", pod.Name, strconv.Itoa(dfv1.VertexMetricsPort), "metrics", nil).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	families, err := (&expfmt.TextParser{}).TextToMetricFamilies(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics of pod %s/%s, %w", pod.Namespace, pod.Name, err)
+	}
+	pipeline, vertex := pod.Labels[dfv1.KeyPipelineName], pod.Labels[dfv1.KeyVertexName]
+	vMetrics := &daemon.VertexMetrics{
+		Pipeline:        &pipeline,
+		Vertex:          &vertex,
+		ProcessingRates: make(map[string]float64),
+		Pendings:        make(map[string]int64),
+	}
+	// same parsing as the daemon service, see "pkg/daemon/server/service/pipeline_metrics_query.go"
+	if family, ok := families[vertexProcessingRateMetric]; ok {
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == periodLabel {
+					vMetrics.ProcessingRates[l.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	if family, ok := families[vertexPendingMessagesMetric]; ok {
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == periodLabel {
+					vMetrics.Pendings[l.GetValue()] = int64(m.GetGauge().GetValue())
+				}
+			}
+		}
+	}
+	return vMetrics, nil
+}
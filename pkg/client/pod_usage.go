@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	metricsversiond "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+type PodUsageSourceType string
+
+const (
+	// AutoPodUsageSource uses the metrics API, falling back to the kubelet summary API per pod.
+	AutoPodUsageSource          PodUsageSourceType = "auto"
+	MetricsServerPodUsageSource PodUsageSourceType = "metrics-server"
+	KubeletPodUsageSource       PodUsageSourceType = "kubelet"
+)
+
+// kubeletSummaryTTL is how long a node's summary is reused for the pods scheduled on it.
+const kubeletSummaryTTL = 10 * time.Second
+
+// ContainerUsage is the resource usage of a single container.
+type ContainerUsage struct {
+	Name        string
+	CPUMilli    int64
+	MemoryBytes int64
+}
+
+// PodUsage is the resource usage of a single pod.
+type PodUsage struct {
+	Containers []ContainerUsage
+}
+
+func (u *PodUsage) CPUMilli() int64 {
+	total := int64(0)
+	for _, c := range u.Containers {
+		total += c.CPUMilli
+	}
+	return total
+}
+
+func (u *PodUsage) MemoryBytes() int64 {
+	total := int64(0)
+	for _, c := range u.Containers {
+		total += c.MemoryBytes
+	}
+	return total
+}
+
+// PodUsageSource returns the current resource usage of a pod.
+type PodUsageSource interface {
+	PodUsage(ctx context.Context, pod *v1.Pod) (*PodUsage, error)
+}
+
+func newPodUsageSource(t PodUsageSourceType, kubeClient kubernetes.Interface, metricsClient metricsversiond.Interface) (PodUsageSource, error) {
+	metricsServer := &metricsServerUsageSource{metricsClient: metricsClient}
+	kubelet := &kubeletSummaryUsageSource{kubeClient: kubeClient, summaries: make(map[string]*cachedSummary)}
+	switch t {
+	case AutoPodUsageSource, "":
+		return &fallbackUsageSource{primary: metricsServer, fallback: kubelet}, nil
+	case MetricsServerPodUsageSource:
+		return metricsServer, nil
+	case KubeletPodUsageSource:
+		return kubelet, nil
+	}
+	return nil, fmt.Errorf("unknown pod usage source %q", t)
+}
+
+// GetPodUsage returns the resource usage of a pod from the configured pod usage source.
+func (c *Client) GetPodUsage(ctx context.Context, pod *v1.Pod) (_ *PodUsage, err error) {
+	ctx, end := startCall(ctx, "GetPodUsage", attribute.String("namespace", pod.Namespace), attribute.String("pod", pod.Name))
+	defer end(&err)
+	return c.podUsage.PodUsage(ctx, pod)
+}
+
+type metricsServerUsageSource struct {
+	metricsClient metricsversiond.Interface
+}
+
+func (s *metricsServerUsageSource) PodUsage(ctx context.Context, pod *v1.Pod) (*PodUsage, error) {
+	m, err := s.metricsClient.MetricsV1beta1().PodMetricses(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	usage := &PodUsage{}
+	for _, c := range m.Containers {
+		usage.Containers = append(usage.Containers, ContainerUsage{
+			Name:        c.Name,
+			CPUMilli:    c.Usage.Cpu().MilliValue(),
+			MemoryBytes: c.Usage.Memory().Value(),
+		})
+	}
+	return usage, nil
+}
+
+// kubeletSummaryUsageSource reads the kubelet "/stats/summary" of the pod's node through the API server node proxy.
+type kubeletSummaryUsageSource struct {
+	kubeClient kubernetes.Interface
+	mu         sync.Mutex
+	summaries  map[string]*cachedSummary
+}
+
+type cachedSummary struct {
+	fetched time.Time
+	summary *kubeletSummary
+}
+
+// kubeletSummary is the subset of the kubelet stats summary API used by the datasource.
+type kubeletSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Containers []struct {
+			Name string `json:"name"`
+			CPU  *struct {
+				UsageNanoCores *uint64 `json:"usageNanoCores"`
+			} `json:"cpu"`
+			Memory *struct {
+				WorkingSetBytes *uint64 `json:"workingSetBytes"`
+			} `json:"memory"`
+		} `json:"containers"`
+	} `json:"pods"`
+}
+
+func (s *kubeletSummaryUsageSource) PodUsage(ctx context.Context, pod *v1.Pod) (*PodUsage, error) {
+	if pod.Spec.NodeName == "" {
+		return nil, fmt.Errorf("pod %s/%s is not scheduled", pod.Namespace, pod.Name)
+	}
+	summary, err := s.nodeSummary(ctx, pod.Spec.NodeName)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range summary.Pods {
+		if p.PodRef.Namespace != pod.Namespace || p.PodRef.Name != pod.Name {
+			continue
+		}
+		usage := &PodUsage{}
+		for _, c := range p.Containers {
+			cu := ContainerUsage{Name: c.Name}
+			if c.CPU != nil && c.CPU.UsageNanoCores != nil {
+				cu.CPUMilli = int64(*c.CPU.UsageNanoCores / 1000000)
+			}
+			if c.Memory != nil && c.Memory.WorkingSetBytes != nil {
+				cu.MemoryBytes = int64(*c.Memory.WorkingSetBytes)
+			}
+			usage.Containers = append(usage.Containers, cu)
+		}
+		return usage, nil
+	}
+	return nil, fmt.Errorf("pod %s/%s not found in kubelet summary of node %s", pod.Namespace, pod.Name, pod.Spec.NodeName)
+}
+
+func (s *kubeletSummaryUsageSource) nodeSummary(ctx context.Context, node string) (*kubeletSummary, error) {
+	s.mu.Lock()
+	cached, ok := s.summaries[node]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetched) < kubeletSummaryTTL {
+		return cached.summary, nil
+	}
+	b, err := s.kubeClient.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(node).
+		SubResource("proxy").
+		Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary := &kubeletSummary{}
+	if err := json.Unmarshal(b, summary); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet summary of node %s, %w", node, err)
+	}
+	s.mu.Lock()
+	s.summaries[node] = &cachedSummary{fetched: time.Now(), summary: summary}
+	s.mu.Unlock()
+	return summary, nil
+}
+
+// fallbackUsageSource uses the fallback source for pods the primary source fails for.
+type fallbackUsageSource struct {
+	primary  PodUsageSource
+	fallback PodUsageSource
+}
+
+func (s *fallbackUsageSource) PodUsage(ctx context.Context, pod *v1.Pod) (*PodUsage, error) {
+	usage, err := s.primary.PodUsage(ctx, pod)
+	if err == nil {
+		return usage, nil
+	}
+	usage, fallbackErr := s.fallback.PodUsage(ctx, pod)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%v, fallback failed, %w", err, fallbackErr)
+	}
+	return usage, nil
+}
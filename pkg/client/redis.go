@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RedisStreamStats are the Redis view of the stream and consumer group backing a single buffer.
+type RedisStreamStats struct {
+	Buffer string
+	Stream string
+	Group  string
+	// Err is set when the stream or its group could not be read, the other fields are then partially or not set.
+	Err error
+
+	Length *int64
+	// MemoryBytes is the memory used by the stream key.
+	MemoryBytes *int64
+
+	Consumers       *int64
+	Pending         *int64
+	LastDeliveredID string
+	// Lag is the number of entries not yet delivered to the group, only reported by Redis 7 and later.
+	Lag *int64
+}
+
+// RedisStreamName is the key of the stream Numaflow creates for a buffer, see "pkg/shared/clients/redis/redis_client.go".
+func RedisStreamName(buffer string) string {
+	return fmt.Sprintf("{%s}", buffer)
+}
+
+// RedisGroupName is the consumer group Numaflow creates for a buffer, see "pkg/isbsvc/redis_service.go".
+func RedisGroupName(buffer string) string {
+	return fmt.Sprintf("%s-group", buffer)
+}
+
+// ConnectRedis connects to the Redis isbsvc with the configuration and password secrets referenced in its status,
+// the same way Numaflow's in-cluster client does. The client must be closed by the caller.
+func (c *Client) ConnectRedis(ctx context.Context, isbsvc *dfv1.InterStepBufferService) (_ redis.UniversalClient, err error) {
+	ctx, end := startCall(ctx, "ConnectRedis", attribute.String("namespace", isbsvc.Namespace), attribute.String("isbsvc", isbsvc.Name))
+	defer end(&err)
+	if c.simulator != nil {
+		return c.simulator.connectRedis(isbsvc)
+	}
+	config := isbsvc.Status.Config.Redis
+	if config == nil {
+		return nil, fmt.Errorf("isbsvc %s/%s is not a configured redis isbsvc", isbsvc.Namespace, isbsvc.Name)
+	}
+	opts := &redis.UniversalOptions{
+		Username:     config.User,
+		MasterName:   config.MasterName,
+		MaxRedirects: 3,
+	}
+	if config.Password != nil {
+		if opts.Password, err = c.secretValue(ctx, isbsvc.Namespace, config.Password); err != nil {
+			return nil, fmt.Errorf("failed to read redis password, %w", err)
+		}
+	}
+	if opts.MasterName != "" {
+		if config.SentinelURL != "" {
+			opts.Addrs = strings.Split(config.SentinelURL, ",")
+		}
+		if config.SentinelPassword != nil {
+			if opts.SentinelPassword, err = c.secretValue(ctx, isbsvc.Namespace, config.SentinelPassword); err != nil {
+				return nil, fmt.Errorf("failed to read redis sentinel password, %w", err)
+			}
+		}
+	} else if config.URL != "" {
+		opts.Addrs = strings.Split(config.URL, ",")
+	}
+	if len(opts.Addrs) == 0 {
+		return nil, fmt.Errorf("isbsvc %s/%s has no redis address", isbsvc.Namespace, isbsvc.Name)
+	}
+	return redis.NewUniversalClient(opts), nil
+}
+
+// GetRedisBufferStats reads the streams and consumer groups of the given buffers.
+// It only needs a Redis client, so it can run against any server, e.g. a local stand-in.
+func GetRedisBufferStats(ctx context.Context, rdb redis.UniversalClient, buffers []string) (_ []RedisStreamStats, err error) {
+	ctx, end := startCall(ctx, "GetRedisBufferStats")
+	defer end(&err)
+	stats := make([]RedisStreamStats, len(buffers))
+	for i, buffer := range buffers {
+		s := &stats[i]
+		s.Buffer = buffer
+		s.Stream = RedisStreamName(buffer)
+		s.Group = RedisGroupName(buffer)
+		length, err := rdb.XLen(ctx, s.Stream).Result()
+		if err != nil {
+			s.Err = fmt.Errorf("failed to get length of stream %q, %w", s.Stream, err)
+			continue
+		}
+		s.Length = &length
+		if memory, err := rdb.MemoryUsage(ctx, s.Stream).Result(); err == nil {
+			s.MemoryBytes = &memory
+		}
+		// go-redis only parses the XINFO GROUPS reply of Redis 6 and earlier, which has no lag
+		groups, err := rdb.Do(ctx, "XINFO", "GROUPS", s.Stream).Slice()
+		if err != nil {
+			s.Err = fmt.Errorf("failed to get groups of stream %q, %w", s.Stream, err)
+			continue
+		}
+		found := false
+		for _, g := range groups {
+			group := redisReplyMap(g)
+			if fmt.Sprint(group["name"]) != s.Group {
+				continue
+			}
+			found = true
+			s.Consumers = redisReplyInt(group["consumers"])
+			s.Pending = redisReplyInt(group["pending"])
+			s.Lag = redisReplyInt(group["lag"])
+			if id, ok := group["last-delivered-id"]; ok {
+				s.LastDeliveredID = fmt.Sprint(id)
+			}
+		}
+		if !found {
+			s.Err = fmt.Errorf("group %q of stream %q not found", s.Group, s.Stream)
+		}
+	}
+	return stats, nil
+}
+
+// redisReplyMap converts a flat key-value array reply to a map.
+func redisReplyMap(reply interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	values, ok := reply.([]interface{})
+	if !ok {
+		return m
+	}
+	for i := 0; i+1 < len(values); i += 2 {
+		m[fmt.Sprint(values[i])] = values[i+1]
+	}
+	return m
+}
+
+func redisReplyInt(v interface{}) *int64 {
+	switch x := v.(type) {
+	case int64:
+		return &x
+	case string:
+		if i, err := strconv.ParseInt(x, 10, 64); err == nil {
+			return &i
+		}
+	}
+	return nil
+}
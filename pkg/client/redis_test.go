@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRedisBufferStats(t *testing.T) {
+	ctx := context.Background()
+	s := miniredis.RunT(t)
+	rdb := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{s.Addr()}})
+	defer rdb.Close()
+
+	// the stream and consumer group of a buffer, as Numaflow creates them
+	stream, group := RedisStreamName("pl-in-map"), RedisGroupName("pl-in-map")
+	require.Equal(t, "{pl-in-map}", stream)
+	require.Equal(t, "pl-in-map-group", group)
+	require.NoError(t, rdb.XGroupCreateMkStream(ctx, stream, group, "0").Err())
+	for i := 0; i < 5; i++ {
+		require.NoError(t, rdb.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]interface{}{"msg": i}}).Err())
+	}
+	read, err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{Group: group, Consumer: "pl-map-0", Streams: []string{stream, ">"}, Count: 3}).Result()
+	require.NoError(t, err)
+	require.Len(t, read[0].Messages, 3)
+	require.NoError(t, rdb.XAck(ctx, stream, group, read[0].Messages[0].ID).Err())
+
+	stats, err := GetRedisBufferStats(ctx, rdb, []string{"pl-in-map", "pl-map-out"})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	st := stats[0]
+	require.NoError(t, st.Err)
+	assert.Equal(t, stream, st.Stream)
+	assert.Equal(t, group, st.Group)
+	require.NotNil(t, st.Length)
+	assert.Equal(t, int64(5), *st.Length)
+	require.NotNil(t, st.Consumers)
+	assert.Equal(t, int64(1), *st.Consumers)
+	require.NotNil(t, st.Pending)
+	assert.Equal(t, int64(2), *st.Pending)
+	assert.Equal(t, read[0].Messages[2].ID, st.LastDeliveredID)
+	// miniredis reports the length of the stream as the lag of its groups
+	require.NotNil(t, st.Lag)
+	assert.Equal(t, int64(5), *st.Lag)
+
+	assert.Equal(t, "pl-map-out", stats[1].Buffer)
+	assert.Error(t, stats[1].Err, "the stream of the buffer does not exist")
+}
@@ -0,0 +1,814 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	dfv1fake "github.com/numaproj/numaflow/pkg/client/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/watch"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+	"k8s.io/utils/pointer"
+)
+
+// SimulationOptions configure the synthetic cluster of a simulated client.
+type SimulationOptions struct {
+	// Namespaces is the number of namespaces, ignored when the client is scoped to a namespace.
+	Namespaces int
+	// Pipelines is the number of pipelines per namespace.
+	Pipelines int
+	// Vertices is the number of vertices per pipeline, including a source and a sink.
+	Vertices int
+	// FailureRate is the probability that a daemon service, pod proxy, metrics API or isbsvc call fails.
+	FailureRate float64
+	// FailingPipelines are the names of pipelines that are in a failed phase, and whose calls always fail.
+	FailingPipelines []string
+	// Interval is how often vertices are autoscaled and pods restarted.
+	Interval time.Duration
+}
+
+// simPipelineNames are the names of the first simulated pipelines of a namespace, later ones are numbered.
+var simPipelineNames = []string{"orders", "clickstream", "payments", "sensors", "audit-log", "inventory", "fraud-check", "recommendations"}
+
+const (
+	simJetStreamISBSvc = "default"
+	simRedisISBSvc     = "redis"
+	simISBSvcReplicas  = 3
+	simNodes           = 3
+	simMinReplicas     = 1
+	simMaxReplicas     = 5
+	simReducePartition = 2
+	simReduceWindow    = time.Minute
+	// simEventTTL is how long events are kept, the default of the API server.
+	simEventTTL = time.Hour
+)
+
+// simulator generates the objects of a simulated cluster into fake clientsets, and the metrics of its pipelines.
+type simulator struct {
+	opts     SimulationOptions
+	start    time.Time
+	kube     *kubefake.Clientset
+	metrics  *metricsfake.Clientset
+	numaflow *dfv1fake.Clientset
+
+	pipelines map[string]*simPipeline // by namespace and name
+	buffers   map[string]*simBuffer   // by buffer name, which includes the namespace
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+type simPipeline struct {
+	namespace string
+	name      string
+	isbsvc    string
+	// rate is the peak rate of the source, in messages per second.
+	rate     float64
+	failing  bool
+	vertices []*simVertex
+}
+
+type simVertex struct {
+	pipeline *simPipeline
+	name     string
+	depth    int
+	source   bool
+	reduce   bool
+	// backlogged vertices process slower than their input at times, up to full buffers.
+	backlogged bool
+	// windowDelay is how much the watermark is held back by reduce windows up to and including the vertex.
+	windowDelay time.Duration
+	// replicas is guarded by the simulator's mutex.
+	replicas int
+}
+
+type simBuffer struct {
+	name      string
+	pipeline  *simPipeline
+	from      *simVertex
+	to        *simVertex
+	partition int
+}
+
+func (p *simPipeline) key() string {
+	return p.namespace + "/" + p.name
+}
+
+func (p *simPipeline) vertex(name string) *simVertex {
+	for _, v := range p.vertices {
+		if v.name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+func (v *simVertex) key() string {
+	return v.pipeline.key() + "/" + v.name
+}
+
+// NewSimulatedClient returns a client of a synthetic cluster, which needs no Kubernetes cluster or Numaflow install.
+// Vertices are autoscaled and pods restarted until ctx is done.
+func NewSimulatedClient(ctx context.Context, namespace string, opts SimulationOptions) (*Client, error) {
+	if opts.Namespaces <= 0 {
+		opts.Namespaces = 2
+	}
+	if opts.Pipelines <= 0 {
+		opts.Pipelines = 3
+	}
+	if opts.Vertices < 2 {
+		opts.Vertices = 4
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.FailureRate < 0 || opts.FailureRate > 1 {
+		return nil, fmt.Errorf("simulated failure rate must be between 0 and 1, got %v", opts.FailureRate)
+	}
+	s := &simulator{
+		opts:      opts,
+		start:     time.Now(),
+		kube:      kubefake.NewSimpleClientset(),
+		metrics:   metricsfake.NewSimpleClientset(),
+		numaflow:  dfv1fake.NewSimpleClientset(),
+		pipelines: make(map[string]*simPipeline),
+		buffers:   make(map[string]*simBuffer),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	s.addReactors()
+	namespaces := []string{namespace}
+	if namespace == v1.NamespaceAll {
+		namespaces = nil
+		for i := 1; i <= opts.Namespaces; i++ {
+			namespaces = append(namespaces, fmt.Sprintf("simulated-%d", i))
+		}
+	}
+	for _, ns := range namespaces {
+		if err := s.generateNamespace(ctx, ns); err != nil {
+			return nil, fmt.Errorf("failed to generate simulated namespace %s, %w", ns, err)
+		}
+	}
+	s.kube.ClearActions()
+	s.numaflow.ClearActions()
+	go s.run(ctx)
+	podUsage, err := newPodUsageSource(MetricsServerPodUsageSource, s.kube, s.metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		kubeClient:     s.kube,
+		metricsClient:  s.metrics,
+		numaflowClient: s.numaflow.NumaflowV1alpha1(),
+		podUsage:       podUsage,
+		dialDaemon:     s.dialDaemon,
+		simulator:      s,
+		listOptions:    metav1.ListOptions{Limit: 1000},
+		namespace:      namespace,
+	}, nil
+}
+
+// addReactors makes the fake clientsets answer the calls that are not backed by stored objects.
+func (s *simulator) addReactors() {
+	s.kube.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+		review.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: true, Reason: "simulated cluster"}
+		return true, review, nil
+	})
+	s.kube.PrependProxyReactor("pods", s.reactPodProxy)
+	s.metrics.PrependReactor("get", "pods", s.reactGetPodMetrics)
+	s.metrics.PrependReactor("list", "pods", s.reactListPodMetrics)
+	// the fake watch only sends changes, unlike the API server which first sends the current objects
+	s.numaflow.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		gvr, ns := action.GetResource(), action.GetNamespace()
+		w, err := s.numaflow.Tracker().Watch(gvr, ns)
+		if err != nil {
+			return false, nil, err
+		}
+		kind := ""
+		switch gvr.Resource {
+		case "pipelines":
+			kind = "Pipeline"
+		case "vertices":
+			kind = "Vertex"
+		case "interstepbufferservices":
+			kind = "InterStepBufferService"
+		}
+		l, err := s.numaflow.Tracker().List(gvr, dfv1.SchemeGroupVersion.WithKind(kind), ns)
+		if err != nil {
+			w.Stop()
+			return false, nil, err
+		}
+		objects, err := meta.ExtractList(l)
+		if err != nil {
+			w.Stop()
+			return false, nil, err
+		}
+		return true, newInitialEventsWatch(objects, w), nil
+	})
+}
+
+// initialEventsWatch sends an added event for each of the current objects before the events of a watch.
+type initialEventsWatch struct {
+	w      watch.Interface
+	result chan watch.Event
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func newInitialEventsWatch(objects []runtime.Object, w watch.Interface) *initialEventsWatch {
+	iw := &initialEventsWatch{w: w, result: make(chan watch.Event), stop: make(chan struct{})}
+	go func() {
+		defer close(iw.result)
+		send := func(e watch.Event) bool {
+			select {
+			case iw.result <- e:
+				return true
+			case <-iw.stop:
+				return false
+			}
+		}
+		for _, o := range objects {
+			if !send(watch.Event{Type: watch.Added, Object: o}) {
+				return
+			}
+		}
+		for e := range w.ResultChan() {
+			if !send(e) {
+				return
+			}
+		}
+	}()
+	return iw
+}
+
+func (iw *initialEventsWatch) Stop() {
+	iw.once.Do(func() {
+		close(iw.stop)
+		iw.w.Stop()
+	})
+}
+
+func (iw *initialEventsWatch) ResultChan() <-chan watch.Event {
+	return iw.result
+}
+
+func (s *simulator) generateNamespace(ctx context.Context, ns string) error {
+	if err := s.createInterStepBufferService(ctx, ns, simJetStreamISBSvc, dfv1.ISBSvcTypeJetStream); err != nil {
+		return err
+	}
+	if err := s.createInterStepBufferService(ctx, ns, simRedisISBSvc, dfv1.ISBSvcTypeRedis); err != nil {
+		return err
+	}
+	for i := 0; i < s.opts.Pipelines; i++ {
+		name := fmt.Sprintf("pipeline-%d", i+1)
+		if i < len(simPipelineNames) {
+			name = simPipelineNames[i]
+		}
+		p := &simPipeline{
+			namespace: ns,
+			name:      name,
+			isbsvc:    simJetStreamISBSvc,
+			rate:      float64(100 + 150*(i%4)),
+		}
+		if i%3 == 2 {
+			p.isbsvc = simRedisISBSvc
+		}
+		for _, f := range s.opts.FailingPipelines {
+			if f == name {
+				p.failing = true
+			}
+		}
+		if err := s.createPipeline(ctx, p, i%2 == 1, i); err != nil {
+			return err
+		}
+		s.pipelines[p.key()] = p
+	}
+	return nil
+}
+
+func (s *simulator) createInterStepBufferService(ctx context.Context, ns, name string, t dfv1.ISBSvcType) error {
+	isbsvc := &dfv1.InterStepBufferService{
+		ObjectMeta: s.objectMeta(ns, name, s.start.Add(-72*time.Hour)),
+	}
+	isbsvc.Status.InitConditions()
+	isbsvc.Status.SetType(t)
+	volumeSize := resource.MustParse("3Gi")
+	stsName := ""
+	switch t {
+	case dfv1.ISBSvcTypeJetStream:
+		isbsvc.Spec.JetStream = &dfv1.JetStreamBufferService{
+			Version:     "2.8.1",
+			Replicas:    pointer.Int32(simISBSvcReplicas),
+			Persistence: &dfv1.PersistenceStrategy{VolumeSize: &volumeSize},
+		}
+		isbsvc.Status.Config.JetStream = &dfv1.JetStreamConfig{
+			URL:          fmt.Sprintf("nats://isbsvc-%s-js-svc.%s.svc.cluster.local:4222", name, ns),
+			BufferConfig: "stream:\n  maxMsgs: 100000\n  maxAge: 72h\n  maxBytes: -1\n  replicas: 3\nconsumer:\n  maxAckPending: 20000\n",
+		}
+		stsName = fmt.Sprintf("isbsvc-%s-js", name)
+	case dfv1.ISBSvcTypeRedis:
+		isbsvc.Spec.Redis = &dfv1.RedisBufferService{
+			Native: &dfv1.NativeRedis{
+				Version:     "6.2.6",
+				Replicas:    pointer.Int32(simISBSvcReplicas),
+				Persistence: &dfv1.PersistenceStrategy{VolumeSize: &volumeSize},
+			},
+		}
+		isbsvc.Status.Config.Redis = &dfv1.RedisConfig{
+			URL: fmt.Sprintf("isbsvc-%s-redis-svc.%s.svc.cluster.local:6379", name, ns),
+		}
+		stsName = fmt.Sprintf("isbsvc-%s-redis", name)
+	}
+	isbsvc.Status.MarkConfigured()
+	isbsvc.Status.MarkDeployed()
+	isbsvc.Status.SetPhase(dfv1.ISBSvcPhaseRunning, "")
+	isbsvc, err := s.numaflow.NumaflowV1alpha1().InterStepBufferServices(ns).Create(ctx, isbsvc, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]string{
+		dfv1.KeyPartOf:     dfv1.Project,
+		dfv1.KeyManagedBy:  dfv1.ControllerISBSvc,
+		dfv1.KeyComponent:  dfv1.ComponentISBSvc,
+		dfv1.KeyISBSvcName: name,
+		dfv1.KeyISBSvcType: string(t),
+	}
+	isbsvcRef := ownerReference(isbsvc.ObjectMeta, dfv1.ISBGroupVersionKind)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: s.objectMeta(ns, stsName, isbsvc.CreationTimestamp.Time, isbsvcRef),
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: pointer.Int32(simISBSvcReplicas),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 1,
+			Replicas:           simISBSvcReplicas,
+			ReadyReplicas:      simISBSvcReplicas,
+			CurrentReplicas:    simISBSvcReplicas,
+			UpdatedReplicas:    simISBSvcReplicas,
+			AvailableReplicas:  simISBSvcReplicas,
+		},
+	}
+	sts.Labels = labels
+	if sts, err = s.kube.AppsV1().StatefulSets(ns).Create(ctx, sts, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	svc := &v1.Service{
+		ObjectMeta: s.objectMeta(ns, stsName+"-svc", isbsvc.CreationTimestamp.Time, isbsvcRef),
+		Spec:       v1.ServiceSpec{ClusterIP: v1.ClusterIPNone, Selector: labels},
+	}
+	svc.Labels = labels
+	if _, err := s.kube.CoreV1().Services(ns).Create(ctx, svc, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	containers := []string{"main", "metrics"}
+	if t == dfv1.ISBSvcTypeJetStream {
+		containers = []string{"main", "reloader", "metrics"}
+	}
+	for i := 0; i < simISBSvcReplicas; i++ {
+		pod := s.newPod(ns, fmt.Sprintf("%s-%d", stsName, i), labels, containers, i, ownerReference(sts.ObjectMeta, appsv1.SchemeGroupVersion.WithKind("StatefulSet")))
+		if _, err := s.kube.CoreV1().Pods(ns).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createPipeline creates a pipeline of a generator source, map vertices, a keyed reduce vertex if reduce is set, and a
+// log sink, with its vertices, daemon service and pods.
+func (s *simulator) createPipeline(ctx context.Context, p *simPipeline, reduce bool, index int) error {
+	ns := p.namespace
+	spec := dfv1.PipelineSpec{InterStepBufferServiceName: p.isbsvc}
+	maps := s.opts.Vertices - 2
+	if reduce && maps > 0 {
+		maps--
+	} else {
+		reduce = false
+	}
+	scale := dfv1.Scale{Min: pointer.Int32(simMinReplicas), Max: pointer.Int32(simMaxReplicas)}
+	spec.Vertices = append(spec.Vertices, dfv1.AbstractVertex{
+		Name:   "in",
+		Source: &dfv1.Source{Generator: &dfv1.GeneratorSource{RPU: pointer.Int64(int64(p.rate)), Duration: &metav1.Duration{Duration: time.Second}}},
+	})
+	for i := 1; i <= maps; i++ {
+		spec.Vertices = append(spec.Vertices, dfv1.AbstractVertex{
+			Name:  fmt.Sprintf("map-%d", i),
+			UDF:   &dfv1.UDF{Builtin: &dfv1.Function{Name: "cat"}},
+			Scale: scale,
+		})
+	}
+	if reduce {
+		spec.Vertices = append(spec.Vertices, dfv1.AbstractVertex{
+			Name: "reduce",
+			UDF: &dfv1.UDF{
+				Container: &dfv1.Container{
+					Image: "quay.io/numaio/numaflow-go/reduce-sum:latest",
+					Env:   []v1.EnvVar{{Name: "FAKE_SIMULATED_TOKEN", Value: "not-a-real-token"}},
+				},
+				GroupBy: &dfv1.GroupBy{
+					Window: dfv1.Window{Fixed: &dfv1.FixedWindow{Length: &metav1.Duration{Duration: simReduceWindow}}},
+					Keyed:  true,
+				},
+			},
+		})
+	}
+	spec.Vertices = append(spec.Vertices, dfv1.AbstractVertex{
+		Name: "out",
+		Sink: &dfv1.Sink{Log: &dfv1.Log{}},
+	})
+	for i := 1; i < len(spec.Vertices); i++ {
+		e := dfv1.Edge{From: spec.Vertices[i-1].Name, To: spec.Vertices[i].Name}
+		if spec.Vertices[i].UDF != nil && spec.Vertices[i].UDF.GroupBy != nil {
+			e.Parallelism = pointer.Int32(simReducePartition)
+		}
+		spec.Edges = append(spec.Edges, e)
+	}
+
+	pl := &dfv1.Pipeline{
+		ObjectMeta: s.objectMeta(ns, p.name, s.start.Add(-time.Duration(48-6*index)*time.Hour)),
+		Spec:       spec,
+	}
+	pl.Labels = map[string]string{"app": p.name, "simulated": "true"}
+	pl.Status.InitConditions()
+	pl.Status.SetVertexCounts(spec.Vertices)
+	pl.Status.MarkConfigured()
+	if p.failing {
+		pl.Status.MarkDeployFailed("SimulatedFailure", "simulated failure of the pipeline")
+	} else {
+		pl.Status.MarkDeployed()
+		pl.Status.MarkPhaseRunning()
+	}
+	pl.Status.LastUpdated = pl.CreationTimestamp
+	pl, err := s.numaflow.NumaflowV1alpha1().Pipelines(ns).Create(ctx, pl, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	plRef := ownerReference(pl.ObjectMeta, dfv1.PipelineGroupVersionKind)
+
+	if _, err := s.kube.CoreV1().Services(ns).Create(ctx, pl.GetDaemonServiceObj(), metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	daemonLabels := map[string]string{
+		dfv1.KeyPartOf:       dfv1.Project,
+		dfv1.KeyManagedBy:    dfv1.ControllerPipeline,
+		dfv1.KeyComponent:    dfv1.ComponentDaemon,
+		dfv1.KeyPipelineName: p.name,
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: s.objectMeta(ns, pl.GetDaemonDeploymentName(), pl.CreationTimestamp.Time, plRef),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(1),
+			Selector: &metav1.LabelSelector{MatchLabels: daemonLabels},
+		},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, ReadyReplicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	deployment.Labels = daemonLabels
+	if deployment, err = s.kube.AppsV1().Deployments(ns).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: s.objectMeta(ns, deployment.Name+"-"+utilrand.String(10), pl.CreationTimestamp.Time, ownerReference(deployment.ObjectMeta, appsv1.SchemeGroupVersion.WithKind("Deployment"))),
+		Spec:       appsv1.ReplicaSetSpec{Replicas: pointer.Int32(1), Selector: deployment.Spec.Selector},
+		Status:     appsv1.ReplicaSetStatus{ObservedGeneration: 1, Replicas: 1, ReadyReplicas: 1, AvailableReplicas: 1},
+	}
+	replicaSet.Labels = daemonLabels
+	if replicaSet, err = s.kube.AppsV1().ReplicaSets(ns).Create(ctx, replicaSet, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	daemonPod := s.newPod(ns, replicaSet.Name+"-"+utilrand.String(5), daemonLabels, []string{"main"}, index, ownerReference(replicaSet.ObjectMeta, appsv1.SchemeGroupVersion.WithKind("ReplicaSet")))
+	if _, err := s.kube.CoreV1().Pods(ns).Create(ctx, daemonPod, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	var windowDelay time.Duration
+	for depth, av := range spec.Vertices {
+		v := &simVertex{
+			pipeline: p,
+			name:     av.Name,
+			depth:    depth,
+			source:   av.Source != nil,
+			reduce:   av.UDF != nil && av.UDF.GroupBy != nil,
+			// the last map vertex of every other pipeline falls behind at times
+			backlogged: av.UDF != nil && av.UDF.GroupBy == nil && depth == maps && index%2 == 0,
+			replicas:   1,
+		}
+		if v.reduce {
+			windowDelay += simReduceWindow
+			v.replicas = simReducePartition
+		}
+		v.windowDelay = windowDelay
+		p.vertices = append(p.vertices, v)
+		if err := s.createVertex(ctx, pl, av, v, plRef); err != nil {
+			return err
+		}
+	}
+	for _, e := range spec.Edges {
+		for i, b := range dfv1.GenerateEdgeBufferNames(ns, p.name, e) {
+			s.buffers[b] = &simBuffer{name: b, pipeline: p, from: p.vertex(e.From), to: p.vertex(e.To), partition: i}
+		}
+	}
+	return nil
+}
+
+func (s *simulator) createVertex(ctx context.Context, pl *dfv1.Pipeline, av dfv1.AbstractVertex, v *simVertex, plRef metav1.OwnerReference) error {
+	vtx := &dfv1.Vertex{
+		ObjectMeta: s.objectMeta(pl.Namespace, pl.Name+"-"+av.Name, pl.CreationTimestamp.Time, plRef),
+		Spec: dfv1.VertexSpec{
+			AbstractVertex:             av,
+			PipelineName:               pl.Name,
+			InterStepBufferServiceName: pl.Spec.InterStepBufferServiceName,
+			Replicas:                   pointer.Int32(int32(v.replicas)),
+			FromEdges:                  pl.GetFromEdges(av.Name),
+			ToEdges:                    pl.GetToEdges(av.Name),
+		},
+	}
+	vtx.Labels = map[string]string{
+		dfv1.KeyPartOf:       dfv1.Project,
+		dfv1.KeyManagedBy:    dfv1.ControllerPipeline,
+		dfv1.KeyComponent:    dfv1.ComponentVertex,
+		dfv1.KeyPipelineName: pl.Name,
+		dfv1.KeyVertexName:   av.Name,
+	}
+	vtx.Status.Replicas = uint32(v.replicas)
+	vtx.Status.LastScaledAt = vtx.CreationTimestamp
+	vtx.Status.Selector = fmt.Sprintf("%s=%s,%s=%s", dfv1.KeyPipelineName, pl.Name, dfv1.KeyVertexName, av.Name)
+	if v.pipeline.failing {
+		vtx.Status.MarkPhaseFailed("SimulatedFailure", "simulated failure of the pipeline")
+	} else {
+		vtx.Status.MarkPhaseRunning()
+	}
+	vtx, err := s.numaflow.NumaflowV1alpha1().Vertices(pl.Namespace).Create(ctx, vtx, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	for _, svc := range vtx.GetServiceObjs() {
+		svc.UID = uuid.NewUUID()
+		svc.CreationTimestamp = vtx.CreationTimestamp
+		if _, err := s.kube.CoreV1().Services(pl.Namespace).Create(ctx, svc, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < v.replicas; i++ {
+		if _, err := s.kube.CoreV1().Pods(pl.Namespace).Create(ctx, s.newVertexPod(vtx, i), metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *simulator) newVertexPod(vtx *dfv1.Vertex, replica int) *v1.Pod {
+	labels := map[string]string{
+		dfv1.KeyPartOf:       dfv1.Project,
+		dfv1.KeyManagedBy:    dfv1.ControllerVertex,
+		dfv1.KeyComponent:    dfv1.ComponentVertex,
+		dfv1.KeyPipelineName: vtx.Spec.PipelineName,
+		dfv1.KeyVertexName:   vtx.Spec.Name,
+	}
+	containers := []string{dfv1.CtrMain}
+	if vtx.Spec.UDF != nil {
+		containers = append(containers, dfv1.CtrUdf)
+	}
+	name := fmt.Sprintf("%s-%d-%s", vtx.Name, replica, utilrand.String(5))
+	pod := s.newPod(vtx.Namespace, name, labels, containers, replica, ownerReference(vtx.ObjectMeta, dfv1.VertexGroupVersionKind))
+	pod.Annotations = map[string]string{dfv1.KeyReplica: strconv.Itoa(replica)}
+	pod.Spec.InitContainers = []v1.Container{{Name: dfv1.CtrInit, Image: "quay.io/numaproj/numaflow:v0.6.3"}}
+	return pod
+}
+
+// newPod returns a running pod with the given containers, which each request 100m CPU and 128Mi memory.
+func (s *simulator) newPod(ns, name string, labels map[string]string, containers []string, index int, owner metav1.OwnerReference) *v1.Pod {
+	now := metav1.Now()
+	pod := &v1.Pod{
+		ObjectMeta: s.objectMeta(ns, name, now.Time, owner),
+		Spec:       v1.PodSpec{NodeName: fmt.Sprintf("simulated-node-%d", index%simNodes+1)},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue, LastTransitionTime: now}},
+			StartTime:  &now,
+		},
+	}
+	pod.Labels = labels
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{
+			Name:  c,
+			Image: "quay.io/numaproj/numaflow:v0.6.3",
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("128Mi")},
+				Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+		})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, v1.ContainerStatus{
+			Name:    c,
+			Ready:   true,
+			Started: pointer.Bool(true),
+			State:   v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: now}},
+		})
+	}
+	return pod
+}
+
+func (s *simulator) objectMeta(ns, name string, created time.Time, owners ...metav1.OwnerReference) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace:         ns,
+		Name:              name,
+		UID:               uuid.NewUUID(),
+		Generation:        1,
+		CreationTimestamp: metav1.NewTime(created),
+		OwnerReferences:   owners,
+	}
+}
+
+func ownerReference(m metav1.ObjectMeta, gvk schema.GroupVersionKind) metav1.OwnerReference {
+	return *metav1.NewControllerRef(&m, gvk)
+}
+
+func (s *simulator) run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			s.tick(ctx, t)
+		}
+	}
+}
+
+// tick autoscales map vertices with the rate of their pipeline, restarts a pod at times, and drops the actions the fake
+// clientsets record, which would otherwise grow for as long as the datasource runs.
+func (s *simulator) tick(ctx context.Context, t time.Time) {
+	for _, p := range s.pipelines {
+		if p.failing {
+			continue
+		}
+		for _, v := range p.vertices {
+			if v.source || v.reduce || v.name == "out" {
+				continue
+			}
+			desired := simMinReplicas + int(math.Round(s.load(p, t, 0)*float64(simMaxReplicas-simMinReplicas)))
+			if desired != s.replicas(v) {
+				if err := s.scale(ctx, v, desired, t); err != nil {
+					backend.Logger.Error("failed to scale simulated vertex", "namespace", p.namespace, "pipeline", p.name, "vertex", v.name, "err", err)
+				}
+			}
+		}
+	}
+	if s.random() < 0.2 {
+		if err := s.restartPod(ctx, t); err != nil {
+			backend.Logger.Error("failed to restart simulated pod", "err", err)
+		}
+	}
+	if err := s.expireEvents(ctx, t); err != nil {
+		backend.Logger.Error("failed to expire simulated events", "err", err)
+	}
+	s.kube.ClearActions()
+	s.metrics.ClearActions()
+	s.numaflow.ClearActions()
+}
+
+// scale sets the replicas of a vertex, and creates or deletes its pods.
+func (s *simulator) scale(ctx context.Context, v *simVertex, replicas int, t time.Time) error {
+	ns := v.pipeline.namespace
+	vtx, err := s.numaflow.NumaflowV1alpha1().Vertices(ns).Get(ctx, v.pipeline.name+"-"+v.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	previous := vtx.GetReplicas()
+	vtx.Spec.Replicas = pointer.Int32(int32(replicas))
+	vtx.Generation++
+	vtx.Status.Replicas = uint32(replicas)
+	vtx.Status.LastScaledAt = metav1.NewTime(t)
+	if vtx, err = s.numaflow.NumaflowV1alpha1().Vertices(ns).Update(ctx, vtx, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	involved := v1.ObjectReference{APIVersion: dfv1.SchemeGroupVersion.String(), Kind: dfv1.VertexGroupVersionKind.Kind, Namespace: ns, Name: vtx.Name, UID: vtx.UID}
+	if err := s.recordEvent(ctx, involved, v1.EventTypeNormal, "Scaled", fmt.Sprintf("Scaled from %d to %d replicas", previous, replicas), t); err != nil {
+		return err
+	}
+	pods, err := s.kube.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s,%s=%s", dfv1.KeyPipelineName, v.pipeline.name, dfv1.KeyVertexName, v.name),
+	})
+	if err != nil {
+		return err
+	}
+	existing := make(map[int]bool)
+	for _, pod := range pods.Items {
+		replica, _ := strconv.Atoi(pod.Annotations[dfv1.KeyReplica])
+		existing[replica] = true
+		if replica >= replicas {
+			if err := s.kube.CoreV1().Pods(ns).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+	for i := 0; i < replicas; i++ {
+		if !existing[i] {
+			if _, err := s.kube.CoreV1().Pods(ns).Create(ctx, s.newVertexPod(vtx, i), metav1.CreateOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+	s.mu.Lock()
+	v.replicas = replicas
+	s.mu.Unlock()
+	return nil
+}
+
+// restartPod restarts the main container of a random vertex pod, as if it had been killed for running out of memory.
+func (s *simulator) restartPod(ctx context.Context, t time.Time) error {
+	pods, err := s.kube.CoreV1().Pods(v1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", dfv1.KeyComponent, dfv1.ComponentVertex),
+	})
+	if err != nil || len(pods.Items) == 0 {
+		return err
+	}
+	s.mu.Lock()
+	pod := pods.Items[s.rand.Intn(len(pods.Items))]
+	s.mu.Unlock()
+	now := metav1.Now()
+	cs := &pod.Status.ContainerStatuses[0]
+	cs.LastTerminationState = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+		ExitCode:   137,
+		Reason:     "OOMKilled",
+		StartedAt:  cs.State.Running.StartedAt,
+		FinishedAt: now,
+	}}
+	cs.State = v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: now}}
+	cs.RestartCount++
+	if _, err = s.kube.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, &pod, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	involved := v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID, FieldPath: fmt.Sprintf("spec.containers{%s}", cs.Name)}
+	return s.recordEvent(ctx, involved, v1.EventTypeWarning, "BackOff", fmt.Sprintf("Back-off restarting OOMKilled container %s", cs.Name), t)
+}
+
+// recordEvent records an event of the involved object, as the controllers and the kubelet of a cluster would.
+func (s *simulator) recordEvent(ctx context.Context, involved v1.ObjectReference, eventType, reason, message string, t time.Time) error {
+	ts := metav1.NewTime(t)
+	e := &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Namespace: involved.Namespace, Name: fmt.Sprintf("%s.%x", involved.Name, t.UnixNano())},
+		InvolvedObject: involved,
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         v1.EventSource{Component: "simulator"},
+		FirstTimestamp: ts,
+		LastTimestamp:  ts,
+		Count:          1,
+	}
+	_, err := s.kube.CoreV1().Events(involved.Namespace).Create(ctx, e, metav1.CreateOptions{})
+	return err
+}
+
+// expireEvents deletes the events last seen before the event TTL.
+func (s *simulator) expireEvents(ctx context.Context, t time.Time) error {
+	events, err := s.kube.CoreV1().Events(v1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, e := range events.Items {
+		if e.LastTimestamp.Time.Before(t.Add(-simEventTTL)) {
+			if err := s.kube.CoreV1().Events(e.Namespace).Delete(ctx, e.Name, metav1.DeleteOptions{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *simulator) replicas(v *simVertex) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return v.replicas
+}
+
+func (s *simulator) random() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Float64()
+}
+
+// fail decides whether a call for a pipeline fails.
+func (s *simulator) fail(p *simPipeline) bool {
+	return p.failing || s.random() < s.opts.FailureRate
+}
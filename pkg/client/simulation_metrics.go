@@ -0,0 +1,530 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/numaproj/numaflow/pkg/apis/proto/daemon"
+	"github.com/numaproj/numaflow/pkg/isb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"k8s.io/utils/pointer"
+)
+
+// The metrics of a simulated pipeline follow the load of the pipeline, a wave with a period of simLoadPeriod that each
+// vertex processes with a lag, which grows up to full buffers for backlogged vertices.
+
+const (
+	simLoadPeriod       = 20 * time.Minute
+	simBufferLength     = 30000
+	simBufferUsageLimit = 0.8
+	simMessageBytes     = 256
+)
+
+// simWindows are the windows of the rates and pendings returned by the daemon service.
+var simWindows = map[string]time.Duration{
+	"default": time.Minute,
+	"1m":      time.Minute,
+	"5m":      5 * time.Minute,
+	"15m":     15 * time.Minute,
+}
+
+var (
+	podsResource = v1.SchemeGroupVersion.WithResource("pods")
+	podsKind     = v1.SchemeGroupVersion.WithKind("Pod")
+)
+
+// wave oscillates between 0 and 1 with the given period, with a phase that depends on key.
+func wave(key string, t time.Time, period time.Duration) float64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	phase := float64(h.Sum32()%1000) / 1000 * 2 * math.Pi
+	return 0.5 + 0.5*math.Sin(2*math.Pi*float64(t.UnixNano()%int64(period))/float64(period)+phase)
+}
+
+// load is the load of a pipeline between 0 and 1, averaged over window.
+func (s *simulator) load(p *simPipeline, t time.Time, window time.Duration) float64 {
+	return wave(p.key(), t.Add(-window/2), simLoadPeriod)
+}
+
+// rate is the processing rate of a vertex in messages per second, averaged over window.
+func (s *simulator) rate(v *simVertex, t time.Time, window time.Duration) float64 {
+	r := v.pipeline.rate * (0.4 + 0.6*s.load(v.pipeline, t, window)) * math.Pow(0.97, float64(v.depth))
+	if window <= time.Minute {
+		r *= 0.95 + 0.1*s.random()
+	}
+	return r
+}
+
+// lag is how long messages wait for a vertex in seconds, averaged over window.
+func (s *simulator) lag(v *simVertex, t time.Time, window time.Duration) float64 {
+	w := wave(v.key(), t.Add(-window/2), 30*time.Minute)
+	if v.backlogged {
+		return 2 + 100*math.Pow(w, 4)
+	}
+	return 0.2 + 1.5*w
+}
+
+// pending is the number of messages pending for a vertex, averaged over window.
+func (s *simulator) pending(v *simVertex, t time.Time, window time.Duration) int64 {
+	if v.source {
+		return isb.PendingNotAvailable
+	}
+	return int64(s.rate(v, t, window) * s.lag(v, t, window))
+}
+
+// partitionShare is the share of the messages of a vertex that go to a partition, the first partition of a reduce
+// vertex is hot.
+func partitionShare(v *simVertex, partition int) float64 {
+	if !v.reduce {
+		return 1
+	}
+	if partition == 0 {
+		return 2.0 / (simReducePartition + 1)
+	}
+	return 1.0 / (simReducePartition + 1)
+}
+
+func (s *simulator) pipeline(ns, name string) *simPipeline {
+	return s.pipelines[ns+"/"+name]
+}
+
+func (s *simulator) bufferInfo(b *simBuffer, t time.Time) *daemon.BufferInfo {
+	pending := int64(float64(s.pending(b.to, t, time.Minute)) * partitionShare(b.to, b.partition))
+	ackPending := int64(math.Min(float64(pending), s.rate(b.to, t, time.Minute)*0.5))
+	total := pending + ackPending
+	usage := float64(total) / simBufferLength
+	return &daemon.BufferInfo{
+		Pipeline:         &b.pipeline.name,
+		FromVertex:       &b.from.name,
+		ToVertex:         &b.to.name,
+		BufferName:       &b.name,
+		PendingCount:     &pending,
+		AckPendingCount:  &ackPending,
+		TotalMessages:    &total,
+		BufferLength:     pointer.Int64(simBufferLength),
+		BufferUsageLimit: pointer.Float64(simBufferUsageLimit),
+		BufferUsage:      &usage,
+		IsFull:           pointer.Bool(usage >= simBufferUsageLimit),
+	}
+}
+
+// simDaemon is the daemon service of a simulated pipeline.
+type simDaemon struct {
+	s         *simulator
+	namespace string
+}
+
+func (s *simulator) dialDaemon(ns, _ string) (daemonService, error) {
+	return &simDaemon{s: s, namespace: ns}, nil
+}
+
+// get returns the simulated pipeline, or the error of a failed call, as if the daemon service was unreachable.
+func (d *simDaemon) get(pipeline string) (*simPipeline, error) {
+	p := d.s.pipeline(d.namespace, pipeline)
+	if p == nil {
+		return nil, status.Errorf(codes.Unavailable, "daemon service of pipeline %s/%s does not exist", d.namespace, pipeline)
+	}
+	if d.s.fail(p) {
+		return nil, status.Errorf(codes.Unavailable, "simulated failure: daemon service of pipeline %s/%s is unavailable", d.namespace, pipeline)
+	}
+	return p, nil
+}
+
+func (d *simDaemon) ListPipelineBuffers(_ context.Context, pipeline string) ([]*daemon.BufferInfo, error) {
+	p, err := d.get(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	t := time.Now()
+	buffers := []*daemon.BufferInfo{}
+	for _, v := range p.vertices {
+		for _, b := range d.s.buffers {
+			if b.pipeline == p && b.to == v {
+				buffers = append(buffers, d.s.bufferInfo(b, t))
+			}
+		}
+	}
+	return buffers, nil
+}
+
+func (d *simDaemon) GetPipelineBuffer(_ context.Context, pipeline, buffer string) (*daemon.BufferInfo, error) {
+	p, err := d.get(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := d.s.buffers[buffer]
+	if !ok || b.pipeline != p {
+		return nil, status.Errorf(codes.NotFound, "buffer %q not found in pipeline %s", buffer, pipeline)
+	}
+	return d.s.bufferInfo(b, time.Now()), nil
+}
+
+func (d *simDaemon) GetVertexMetrics(_ context.Context, pipeline, vertex string) (*daemon.VertexMetrics, error) {
+	p, err := d.get(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	v := p.vertex(vertex)
+	if v == nil {
+		return nil, status.Errorf(codes.NotFound, "vertex %q not found in pipeline %s", vertex, pipeline)
+	}
+	t := time.Now()
+	m := &daemon.VertexMetrics{
+		Pipeline:        &p.name,
+		Vertex:          &v.name,
+		ProcessingRates: make(map[string]float64),
+		Pendings:        make(map[string]int64),
+	}
+	for name, window := range simWindows {
+		m.ProcessingRates[name] = d.s.rate(v, t, window)
+		m.Pendings[name] = d.s.pending(v, t, window)
+	}
+	return m, nil
+}
+
+func (d *simDaemon) GetVertexWatermark(_ context.Context, pipeline, vertex string) (*daemon.VertexWatermark, error) {
+	p, err := d.get(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	v := p.vertex(vertex)
+	if v == nil {
+		return nil, status.Errorf(codes.NotFound, "vertex %q not found in pipeline %s", vertex, pipeline)
+	}
+	t := time.Now()
+	delay := time.Duration((float64(v.depth)*0.5+d.s.lag(v, t, time.Minute))*float64(time.Second)) + v.windowDelay
+	wm := t.Add(-delay).UnixMilli()
+	return &daemon.VertexWatermark{
+		Pipeline:           &p.name,
+		Vertex:             &v.name,
+		Watermark:          &wm,
+		IsWatermarkEnabled: pointer.Bool(true),
+	}, nil
+}
+
+func (d *simDaemon) Close() error {
+	return nil
+}
+
+// simResponse is the response of the pod proxy of a simulated pod.
+type simResponse struct {
+	body []byte
+	err  error
+}
+
+func (r *simResponse) DoRaw(context.Context) ([]byte, error) {
+	return r.body, r.err
+}
+
+func (r *simResponse) Stream(context.Context) (io.ReadCloser, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return io.NopCloser(bytes.NewReader(r.body)), nil
+}
+
+// vertexOf returns the simulated vertex of a pod, if it is a vertex pod.
+func (s *simulator) vertexOf(pod *v1.Pod) *simVertex {
+	p := s.pipeline(pod.Namespace, pod.Labels[dfv1.KeyPipelineName])
+	if p == nil {
+		return nil
+	}
+	return p.vertex(pod.Labels[dfv1.KeyVertexName])
+}
+
+func (s *simulator) getPod(ns, name string) (*v1.Pod, error) {
+	o, err := s.kube.Tracker().Get(podsResource, ns, name)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*v1.Pod), nil
+}
+
+// reactPodProxy answers the metrics endpoint of vertex pods through the pod proxy, with the vertex's rates and pendings
+// spread over its replicas.
+func (s *simulator) reactPodProxy(action k8stesting.Action) (bool, rest.ResponseWrapper, error) {
+	proxy := action.(k8stesting.ProxyGetAction)
+	pod, err := s.getPod(proxy.GetNamespace(), proxy.GetName())
+	if err != nil {
+		return true, &simResponse{err: err}, nil
+	}
+	v := s.vertexOf(pod)
+	if v == nil || proxy.GetPath() != "metrics" {
+		return true, &simResponse{err: apierrors.NewNotFound(podsResource.GroupResource(), pod.Name)}, nil
+	}
+	if s.fail(v.pipeline) {
+		return true, &simResponse{err: apierrors.NewServiceUnavailable(fmt.Sprintf("simulated failure: metrics of pod %s/%s are unavailable", pod.Namespace, pod.Name))}, nil
+	}
+	replica, _ := strconv.Atoi(pod.Annotations[dfv1.KeyReplica])
+	replicas := s.replicas(v)
+	t := time.Now()
+	var b strings.Builder
+	labels := func(period string) string {
+		return fmt.Sprintf(`{%s=%q,pipeline=%q,vertex=%q}`, periodLabel, period, v.pipeline.name, v.name)
+	}
+	fmt.Fprintf(&b, "# TYPE %s gauge\n", vertexProcessingRateMetric)
+	for period, window := range simWindows {
+		r := s.rate(v, t, window) * partitionShare(v, replica)
+		if !v.reduce {
+			r = r / float64(replicas) * (0.9 + 0.2*wave(pod.Name, t, 3*time.Minute))
+		}
+		fmt.Fprintf(&b, "%s%s %f\n", vertexProcessingRateMetric, labels(period), r)
+	}
+	if !v.source {
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", vertexPendingMessagesMetric)
+		for period, window := range simWindows {
+			pending := float64(s.pending(v, t, window)) * partitionShare(v, replica)
+			if !v.reduce {
+				pending /= float64(replicas)
+			}
+			fmt.Fprintf(&b, "%s%s %d\n", vertexPendingMessagesMetric, labels(period), int64(pending))
+		}
+	}
+	return true, &simResponse{body: []byte(b.String())}, nil
+}
+
+func (s *simulator) reactGetPodMetrics(action k8stesting.Action) (bool, runtime.Object, error) {
+	get := action.(k8stesting.GetAction)
+	pod, err := s.getPod(get.GetNamespace(), get.GetName())
+	if err != nil {
+		return true, nil, apierrors.NewNotFound(v1beta1.Resource("pods"), get.GetName())
+	}
+	if v := s.vertexOf(pod); v != nil && s.fail(v.pipeline) {
+		return true, nil, apierrors.NewServiceUnavailable(fmt.Sprintf("simulated failure: metrics of pod %s/%s are unavailable", pod.Namespace, pod.Name))
+	}
+	return true, s.podMetrics(pod, time.Now()), nil
+}
+
+func (s *simulator) reactListPodMetrics(action k8stesting.Action) (bool, runtime.Object, error) {
+	o, err := s.kube.Tracker().List(podsResource, podsKind, action.GetNamespace())
+	if err != nil {
+		return true, nil, err
+	}
+	t := time.Now()
+	l := &v1beta1.PodMetricsList{}
+	for i := range o.(*v1.PodList).Items {
+		l.Items = append(l.Items, *s.podMetrics(&o.(*v1.PodList).Items[i], t))
+	}
+	return true, l, nil
+}
+
+// podMetrics returns the usage of a pod, where the main container of a vertex pod uses more CPU with its rate and more
+// memory with its pendings.
+func (s *simulator) podMetrics(pod *v1.Pod, t time.Time) *v1beta1.PodMetrics {
+	m := &v1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name, Labels: pod.Labels},
+		Timestamp:  metav1.NewTime(t),
+		Window:     metav1.Duration{Duration: 30 * time.Second},
+	}
+	v := s.vertexOf(pod)
+	for i, c := range pod.Spec.Containers {
+		w := wave(pod.Name+"/"+c.Name, t, 11*time.Minute)
+		cpu := 5 + 20*w
+		memory := float64(40<<20) + float64(30<<20)*w
+		if v != nil && i == 0 {
+			replicas := float64(s.replicas(v))
+			cpu += s.rate(v, t, time.Minute) / replicas * 0.4
+			if pending := s.pending(v, t, time.Minute); pending > 0 {
+				memory += float64(pending) / replicas * 2048
+			}
+		}
+		m.Containers = append(m.Containers, v1beta1.ContainerMetrics{
+			Name: c.Name,
+			Usage: v1.ResourceList{
+				v1.ResourceCPU:    *resource.NewMilliQuantity(int64(cpu), resource.DecimalSI),
+				v1.ResourceMemory: *resource.NewQuantity(int64(memory), resource.BinarySI),
+			},
+		})
+	}
+	return m
+}
+
+// simBufferOf returns the simulated buffer of a JetStream stream or Redis stream key in a namespace.
+func (s *simulator) simBufferOf(ns string, matches func(b *simBuffer) bool) *simBuffer {
+	for _, b := range s.buffers {
+		if b.pipeline.namespace == ns && matches(b) {
+			return b
+		}
+	}
+	return nil
+}
+
+// sequence is the number of messages written to a buffer since the simulation started.
+func (s *simulator) sequence(b *simBuffer, t time.Time) uint64 {
+	return uint64(b.pipeline.rate * 0.7 * t.Sub(s.start).Seconds() * partitionShare(b.to, b.partition))
+}
+
+type simJetStreamConn struct {
+	s      *simulator
+	isbsvc *dfv1.InterStepBufferService
+}
+
+func (s *simulator) connectJetStream(isbsvc *dfv1.InterStepBufferService) (JetStreamConn, error) {
+	return &simJetStreamConn{s: s, isbsvc: isbsvc}, nil
+}
+
+func (c *simJetStreamConn) JetStream(...nats.JSOpt) (nats.JetStreamContext, error) {
+	return &simJetStream{s: c.s, isbsvc: c.isbsvc}, nil
+}
+
+func (c *simJetStreamConn) Close() {}
+
+// simJetStream answers the stream and consumer information of simulated buffers, its other methods are not
+// implemented and panic.
+type simJetStream struct {
+	nats.JetStreamContext
+	s      *simulator
+	isbsvc *dfv1.InterStepBufferService
+}
+
+func (js *simJetStream) buffer(stream string) (*simBuffer, error) {
+	b := js.s.simBufferOf(js.isbsvc.Namespace, func(b *simBuffer) bool {
+		return b.pipeline.isbsvc == js.isbsvc.Name && JetStreamStreamName(b.pipeline.name, b.name) == stream
+	})
+	if b == nil {
+		return nil, nats.ErrStreamNotFound
+	}
+	if js.s.fail(b.pipeline) {
+		return nil, nats.ErrTimeout
+	}
+	return b, nil
+}
+
+func (js *simJetStream) StreamInfo(stream string, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
+	b, err := js.buffer(stream)
+	if err != nil {
+		return nil, err
+	}
+	t := time.Now()
+	info := js.s.bufferInfo(b, t)
+	last := js.s.sequence(b, t)
+	messages := uint64(*info.TotalMessages)
+	sts := fmt.Sprintf("isbsvc-%s-js", js.isbsvc.Name)
+	cluster := &nats.ClusterInfo{Name: sts, Leader: sts + "-0"}
+	for i := 1; i < simISBSvcReplicas; i++ {
+		peer := fmt.Sprintf("%s-%d", sts, i)
+		cluster.Replicas = append(cluster.Replicas, &nats.PeerInfo{Name: peer, Current: wave(stream+peer, t, 17*time.Minute) > 0.1})
+	}
+	return &nats.StreamInfo{
+		Config:  nats.StreamConfig{Name: stream, Replicas: simISBSvcReplicas},
+		Created: js.isbsvc.CreationTimestamp.Time,
+		State: nats.StreamState{
+			Msgs:     messages,
+			Bytes:    messages * simMessageBytes,
+			FirstSeq: last - messages + 1,
+			LastSeq:  last,
+		},
+		Cluster: cluster,
+	}, nil
+}
+
+func (js *simJetStream) ConsumerInfo(stream, consumer string, _ ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	b, err := js.buffer(stream)
+	if err != nil {
+		return nil, err
+	}
+	t := time.Now()
+	info := js.s.bufferInfo(b, t)
+	return &nats.ConsumerInfo{
+		Stream:         stream,
+		Name:           consumer,
+		NumPending:     uint64(*info.PendingCount),
+		NumAckPending:  int(*info.AckPendingCount),
+		NumRedelivered: int(10 * math.Pow(wave(stream, t, 13*time.Minute), 8)),
+	}, nil
+}
+
+func (s *simulator) connectRedis(isbsvc *dfv1.InterStepBufferService) (redis.UniversalClient, error) {
+	return &simRedis{s: s, isbsvc: isbsvc}, nil
+}
+
+// simRedis answers the stream and consumer group commands of simulated buffers, its other commands are not implemented
+// and panic.
+type simRedis struct {
+	redis.UniversalClient
+	s      *simulator
+	isbsvc *dfv1.InterStepBufferService
+}
+
+func (r *simRedis) buffer(key string) (*simBuffer, error) {
+	b := r.s.simBufferOf(r.isbsvc.Namespace, func(b *simBuffer) bool {
+		return b.pipeline.isbsvc == r.isbsvc.Name && RedisStreamName(b.name) == key
+	})
+	if b == nil {
+		return nil, errors.New("ERR no such key")
+	}
+	if r.s.fail(b.pipeline) {
+		return nil, errors.New("simulated failure: i/o timeout")
+	}
+	return b, nil
+}
+
+func (r *simRedis) XLen(ctx context.Context, stream string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "xlen", stream)
+	if b, err := r.buffer(stream); err != nil {
+		cmd.SetErr(err)
+	} else {
+		cmd.SetVal(*r.s.bufferInfo(b, time.Now()).TotalMessages)
+	}
+	return cmd
+}
+
+func (r *simRedis) MemoryUsage(ctx context.Context, key string, _ ...int) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "memory", "usage", key)
+	if b, err := r.buffer(key); err != nil {
+		cmd.SetErr(err)
+	} else {
+		cmd.SetVal(*r.s.bufferInfo(b, time.Now()).TotalMessages*simMessageBytes + 4096)
+	}
+	return cmd
+}
+
+// Do only answers XINFO GROUPS, with the reply of Redis 7, which includes the lag.
+func (r *simRedis) Do(ctx context.Context, args ...interface{}) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, args...)
+	if len(args) != 3 || fmt.Sprint(args[0]) != "XINFO" || fmt.Sprint(args[1]) != "GROUPS" {
+		cmd.SetErr(fmt.Errorf("simulated redis does not support %v", args))
+		return cmd
+	}
+	key := fmt.Sprint(args[2])
+	b, err := r.buffer(key)
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	t := time.Now()
+	info := r.s.bufferInfo(b, t)
+	cmd.SetVal([]interface{}{[]interface{}{
+		"name", RedisGroupName(b.name),
+		"consumers", int64(r.s.replicas(b.to)),
+		"pending", *info.AckPendingCount,
+		"last-delivered-id", fmt.Sprintf("%d-0", t.Add(-time.Duration(*info.PendingCount)*time.Millisecond).UnixMilli()),
+		"entries-read", int64(r.s.sequence(b, t)) - *info.PendingCount,
+		"lag", *info.PendingCount,
+	}})
+	return cmd
+}
+
+func (r *simRedis) Close() error {
+	return nil
+}
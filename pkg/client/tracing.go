@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// startCall starts a span for a client call, the returned func ends it and records the call's metrics.
+func startCall(ctx context.Context, call string, attrs ...attribute.KeyValue) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, span := tracing.DefaultTracer().Start(ctx, "client."+call, trace.WithAttributes(attrs...))
+	return ctx, func(err *error) {
+		observe(call, start, err)
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
+
+// injectTraceContext propagates the trace context of ctx to the daemon service through gRPC metadata.
+func injectTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Workload is a Kubernetes object that may be owned by a Numaflow resource, with its readiness and rollout state.
+type Workload struct {
+	Kind         string
+	Namespace    string
+	Name         string
+	UID          types.UID
+	Owners       []types.UID
+	CreationTime time.Time
+
+	Generation int64
+	// ObservedGeneration is nil for kinds without one, e.g. services and pods.
+	ObservedGeneration *int64
+	// Ready is e.g. "2/3" ready replicas or containers, empty for kinds without readiness.
+	Ready string
+	// Rollout summarizes the rollout or completion state, e.g. "complete" or "2 of 3 updated replicas".
+	Rollout string
+}
+
+// ListWorkloads lists the vertices, deployments, replica sets, stateful sets, services, jobs and pods in a namespace,
+// i.e. every kind Numaflow creates for a pipeline or an isbsvc.
+func (c *Client) ListWorkloads(ctx context.Context, ns string) (_ []Workload, err error) {
+	ctx, end := startCall(ctx, "ListWorkloads", attribute.String("namespace", ns))
+	defer end(&err)
+	workloads := []Workload{}
+	newWorkload := func(kind string, m metav1.ObjectMeta) Workload {
+		w := Workload{Kind: kind, Namespace: m.Namespace, Name: m.Name, UID: m.UID, CreationTime: m.CreationTimestamp.Time, Generation: m.Generation}
+		for _, o := range m.OwnerReferences {
+			w.Owners = append(w.Owners, o.UID)
+		}
+		return w
+	}
+
+	vertices, err := c.numaflowClient.Vertices(ns).List(ctx, c.listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vertices, %w", err)
+	}
+	for _, v := range vertices.Items {
+		w := newWorkload("Vertex", v.ObjectMeta)
+		w.Ready = fmt.Sprintf("%d/%d", v.Status.Replicas, v.GetReplicas())
+		w.Rollout = string(v.Status.Phase)
+		workloads = append(workloads, w)
+	}
+	deployments, err := c.kubeClient.AppsV1().Deployments(ns).List(ctx, c.listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments, %w", err)
+	}
+	for _, d := range deployments.Items {
+		w := newWorkload("Deployment", d.ObjectMeta)
+		w.ObservedGeneration = &d.Status.ObservedGeneration
+		w.Ready = fmt.Sprintf("%d/%d", d.Status.ReadyReplicas, desiredReplicas(d.Spec.Replicas))
+		w.Rollout = deploymentRollout(&d)
+		workloads = append(workloads, w)
+	}
+	replicaSets, err := c.kubeClient.AppsV1().ReplicaSets(ns).List(ctx, c.listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replica sets, %w", err)
+	}
+	for _, rs := range replicaSets.Items {
+		w := newWorkload("ReplicaSet", rs.ObjectMeta)
+		w.ObservedGeneration = &rs.Status.ObservedGeneration
+		w.Ready = fmt.Sprintf("%d/%d", rs.Status.ReadyReplicas, desiredReplicas(rs.Spec.Replicas))
+		workloads = append(workloads, w)
+	}
+	statefulSets, err := c.kubeClient.AppsV1().StatefulSets(ns).List(ctx, c.listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stateful sets, %w", err)
+	}
+	for _, s := range statefulSets.Items {
+		w := newWorkload("StatefulSet", s.ObjectMeta)
+		w.ObservedGeneration = &s.Status.ObservedGeneration
+		w.Ready = fmt.Sprintf("%d/%d", s.Status.ReadyReplicas, desiredReplicas(s.Spec.Replicas))
+		w.Rollout = statefulSetRollout(&s)
+		workloads = append(workloads, w)
+	}
+	services, err := c.kubeClient.CoreV1().Services(ns).List(ctx, c.listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services, %w", err)
+	}
+	for _, s := range services.Items {
+		workloads = append(workloads, newWorkload("Service", s.ObjectMeta))
+	}
+	jobs, err := c.kubeClient.BatchV1().Jobs(ns).List(ctx, c.listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs, %w", err)
+	}
+	for _, j := range jobs.Items {
+		w := newWorkload("Job", j.ObjectMeta)
+		w.Ready = fmt.Sprintf("%d/%d", j.Status.Succeeded, desiredReplicas(j.Spec.Completions))
+		w.Rollout = jobRollout(&j)
+		workloads = append(workloads, w)
+	}
+	pods, err := c.kubeClient.CoreV1().Pods(ns).List(ctx, c.listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods, %w", err)
+	}
+	for _, p := range pods.Items {
+		w := newWorkload("Pod", p.ObjectMeta)
+		ready := 0
+		for _, s := range p.Status.ContainerStatuses {
+			if s.Ready {
+				ready++
+			}
+		}
+		w.Ready = fmt.Sprintf("%d/%d", ready, len(p.Spec.Containers))
+		w.Rollout = string(p.Status.Phase)
+		workloads = append(workloads, w)
+	}
+	return workloads, nil
+}
+
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// deploymentRollout follows "kubectl rollout status" for deployments.
+func deploymentRollout(d *appsv1.Deployment) string {
+	if d.Generation > d.Status.ObservedGeneration {
+		return "waiting for rollout to be observed"
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return "progress deadline exceeded"
+		}
+	}
+	desired := desiredReplicas(d.Spec.Replicas)
+	switch {
+	case d.Status.UpdatedReplicas < desired:
+		return fmt.Sprintf("%d of %d updated replicas", d.Status.UpdatedReplicas, desired)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		return fmt.Sprintf("%d old replicas pending termination", d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		return fmt.Sprintf("%d of %d updated replicas available", d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	}
+	return "complete"
+}
+
+// statefulSetRollout follows "kubectl rollout status" for stateful sets with the rolling update strategy.
+func statefulSetRollout(s *appsv1.StatefulSet) string {
+	if s.Generation > s.Status.ObservedGeneration {
+		return "waiting for rollout to be observed"
+	}
+	desired := desiredReplicas(s.Spec.Replicas)
+	switch {
+	case s.Status.ReadyReplicas < desired:
+		return fmt.Sprintf("%d of %d replicas ready", s.Status.ReadyReplicas, desired)
+	case s.Status.UpdateRevision != s.Status.CurrentRevision:
+		return fmt.Sprintf("%d of %d updated replicas", s.Status.UpdatedReplicas, desired)
+	}
+	return "complete"
+}
+
+func jobRollout(j *batchv1.Job) string {
+	for _, c := range j.Status.Conditions {
+		if c.Status != v1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return "complete"
+		case batchv1.JobFailed:
+			return "failed: " + c.Message
+		}
+	}
+	if j.Status.Active > 0 {
+		return "running"
+	}
+	return "pending"
+}
@@ -0,0 +1,214 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type Kind string
+
+const (
+	PipelineKind Kind = "Pipeline"
+	VertexKind   Kind = "Vertex"
+)
+
+// Revision is a snapshot of a resource spec at a given metadata.generation. The UID tells apart a resource from one
+// deleted before with the same name, whose generations started over.
+type Revision struct {
+	Kind       Kind
+	Namespace  string
+	Name       string
+	UID        string
+	Pipeline   string
+	Vertex     string
+	Generation int64
+	// Time is when the spec changed to this revision. It is zero for baseline revisions, which were first observed
+	// when listing resources, e.g. when the plugin started, and changed at an unknown time before.
+	Time time.Time
+	Spec json.RawMessage
+}
+
+// IsBaseline returns whether the revision was observed at an unknown time after its change.
+func (r Revision) IsBaseline() bool {
+	return r.Time.IsZero()
+}
+
+type key struct {
+	kind      Kind
+	namespace string
+	name      string
+}
+
+var revisionsBucket = []byte("revisions")
+
+const keySeparator = "\x00"
+
+// bytes encodes the key of a revision, the generation is big endian so that revisions are ordered by generation.
+func (k key) bytes(generation int64) []byte {
+	b := []byte(strings.Join([]string{string(k.kind), k.namespace, k.name, ""}, keySeparator))
+	return binary.BigEndian.AppendUint64(b, uint64(generation))
+}
+
+// Store keeps a bounded history of spec revisions per resource, in memory and, when opened from a file, on disk.
+type Store struct {
+	mu           sync.RWMutex
+	maxRevisions int
+	revisions    map[key][]Revision
+	db           *bolt.DB
+}
+
+// NewStore returns an in-memory store.
+func NewStore(maxRevisions int) *Store {
+	return &Store{
+		maxRevisions: maxRevisions,
+		revisions:    make(map[key][]Revision),
+	}
+}
+
+// Open opens, or creates, the store at path and loads the revisions recorded before. Revisions beyond maxRevisions per
+// resource, recorded with a higher maxRevisions, are dropped.
+func Open(path string, maxRevisions int) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spec history directory, %w", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spec history, %w", err)
+	}
+	s := NewStore(maxRevisions)
+	s.db = db
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(revisionsBucket)
+		if err != nil {
+			return err
+		}
+		err = b.ForEach(func(_, v []byte) error {
+			var rev Revision
+			if err := json.Unmarshal(v, &rev); err != nil {
+				return err
+			}
+			k := key{kind: rev.Kind, namespace: rev.Namespace, name: rev.Name}
+			s.revisions[k] = append(s.revisions[k], rev)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for k, revs := range s.revisions {
+			if len(revs) <= maxRevisions {
+				continue
+			}
+			if err := deleteRevisions(b, k, revs[:len(revs)-maxRevisions]); err != nil {
+				return err
+			}
+			s.revisions[k] = revs[len(revs)-maxRevisions:]
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to load spec history, %w", err)
+	}
+	return s, nil
+}
+
+// Close closes the file of a store opened with Open.
+func (s *Store) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Record stores rev unless the latest revision of the resource already has the same generation.
+// The oldest revisions are dropped once the store holds more than maxRevisions for the resource, and all of them when
+// rev is of a new resource with the same name, i.e. its UID changed.
+func (s *Store) Record(rev Revision) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := key{kind: rev.Kind, namespace: rev.Namespace, name: rev.Name}
+	revs := s.revisions[k]
+	var dropped []Revision
+	if len(revs) > 0 && revs[len(revs)-1].UID != rev.UID {
+		dropped, revs = revs, nil
+	}
+	if len(revs) > 0 && revs[len(revs)-1].Generation == rev.Generation {
+		return false, nil
+	}
+	revs = append(revs, rev)
+	if len(revs) > s.maxRevisions {
+		dropped = append(dropped, revs[:len(revs)-s.maxRevisions]...)
+		revs = revs[len(revs)-s.maxRevisions:]
+	}
+	if s.db != nil {
+		err := s.db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(revisionsBucket)
+			if err := deleteRevisions(b, k, dropped); err != nil {
+				return err
+			}
+			v, err := json.Marshal(rev)
+			if err != nil {
+				return err
+			}
+			return b.Put(k.bytes(rev.Generation), v)
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	s.revisions[k] = revs
+	return true, nil
+}
+
+// deleteRevisions deletes revs of the resource k from b.
+func deleteRevisions(b *bolt.Bucket, k key, revs []Revision) error {
+	for _, rev := range revs {
+		if err := b.Delete(k.bytes(rev.Generation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the revisions of the given kind matching filter, oldest first, with baseline revisions first.
+func (s *Store) List(kind Kind, filter func(Revision) bool) []Revision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := []Revision{}
+	for k, revs := range s.revisions {
+		if k.kind != kind {
+			continue
+		}
+		for _, rev := range revs {
+			if filter == nil || filter(rev) {
+				result = append(result, rev)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+// Previous returns the revision recorded for the same resource just before rev.
+func (s *Store) Previous(rev Revision) (Revision, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	revs := s.revisions[key{kind: rev.Kind, namespace: rev.Namespace, name: rev.Name}]
+	for i := len(revs) - 1; i > 0; i-- {
+		if revs[i].Generation == rev.Generation {
+			return revs[i-1], true
+		}
+	}
+	return Revision{}, false
+}
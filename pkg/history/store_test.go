@@ -0,0 +1,152 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func pipeline(generation int64, created time.Time) *dfv1.Pipeline {
+	return &dfv1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "ns",
+			Name:              "pl",
+			Generation:        generation,
+			ResourceVersion:   "1",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+}
+
+// consumeEvents records the events of a fake watch into store.
+func consumeEvents(store *Store, events ...watch.Event) {
+	w := watch.NewFakeWithChanSize(len(events), false)
+	for _, e := range events {
+		w.Action(e.Type, e.Object)
+	}
+	w.Stop()
+	consume(context.Background(), PipelineKind, w, store, "")
+}
+
+func TestConsumeRecordsListedResourcesAsBaseline(t *testing.T) {
+	created := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	store := NewStore(10)
+
+	consumeEvents(store,
+		watch.Event{Type: watch.Added, Object: pipeline(3, created)},
+		// a re-list after a watch error finds a generation changed while the plugin was not watching
+		watch.Event{Type: watch.Added, Object: pipeline(4, created)},
+	)
+	revs := store.List(PipelineKind, nil)
+	require.Len(t, revs, 2)
+	assert.True(t, revs[0].IsBaseline())
+	assert.True(t, revs[1].IsBaseline())
+
+	before := time.Now()
+	consumeEvents(store,
+		watch.Event{Type: watch.Modified, Object: pipeline(4, created)},
+		watch.Event{Type: watch.Modified, Object: pipeline(5, created)},
+	)
+	revs = store.List(PipelineKind, nil)
+	require.Len(t, revs, 3)
+	assert.Equal(t, int64(5), revs[2].Generation)
+	assert.False(t, revs[2].Time.Before(before))
+}
+
+func TestConsumeRecordsCreationTime(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	store := NewStore(10)
+	consumeEvents(store, watch.Event{Type: watch.Added, Object: pipeline(1, created)})
+	revs := store.List(PipelineKind, nil)
+	require.Len(t, revs, 1)
+	assert.True(t, revs[0].Time.Equal(created))
+}
+
+func TestStorePersistsRevisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := Open(path, 2)
+	require.NoError(t, err)
+	for g := int64(1); g <= 3; g++ {
+		recorded, err := store.Record(Revision{Kind: PipelineKind, Namespace: "ns", Name: "pl", Generation: g, Time: time.Unix(g, 0), Spec: []byte(`{}`)})
+		require.NoError(t, err)
+		assert.True(t, recorded)
+	}
+	recorded, err := store.Record(Revision{Kind: PipelineKind, Namespace: "ns", Name: "pl", Generation: 3})
+	require.NoError(t, err)
+	assert.False(t, recorded)
+	require.NoError(t, store.Close())
+
+	store, err = Open(path, 2)
+	require.NoError(t, err)
+	defer store.Close()
+	revs := store.List(PipelineKind, nil)
+	require.Len(t, revs, 2)
+	assert.Equal(t, int64(2), revs[0].Generation)
+	assert.Equal(t, int64(3), revs[1].Generation)
+	assert.True(t, revs[1].Time.Equal(time.Unix(3, 0)))
+	prev, ok := store.Previous(revs[1])
+	require.True(t, ok)
+	assert.Equal(t, int64(2), prev.Generation)
+}
+
+func TestStoreResetsHistoryOfRecreatedResource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := Open(path, 2)
+	require.NoError(t, err)
+	for _, rev := range []Revision{
+		{UID: "old", Generation: 1},
+		{UID: "old", Generation: 2},
+		// the pipeline is deleted and created again, its generations start over
+		{UID: "new", Generation: 1},
+		{UID: "new", Generation: 2},
+		{UID: "new", Generation: 3},
+	} {
+		rev.Kind, rev.Namespace, rev.Name, rev.Time, rev.Spec = PipelineKind, "ns", "pl", time.Unix(rev.Generation, 0), []byte(`{}`)
+		recorded, err := store.Record(rev)
+		require.NoError(t, err)
+		assert.True(t, recorded, "%s generation %d", rev.UID, rev.Generation)
+	}
+	require.NoError(t, store.Close())
+
+	store, err = Open(path, 2)
+	require.NoError(t, err)
+	defer store.Close()
+	revs := store.List(PipelineKind, nil)
+	require.Len(t, revs, 2)
+	for i, g := range []int64{2, 3} {
+		assert.Equal(t, "new", revs[i].UID)
+		assert.Equal(t, g, revs[i].Generation)
+	}
+}
+
+func TestOpenTrimsToMaxRevisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := Open(path, 5)
+	require.NoError(t, err)
+	for g := int64(1); g <= 5; g++ {
+		_, err := store.Record(Revision{Kind: PipelineKind, Namespace: "ns", Name: "pl", Generation: g, Time: time.Unix(g, 0), Spec: []byte(`{}`)})
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.Close())
+
+	// the max revisions setting was lowered
+	store, err = Open(path, 2)
+	require.NoError(t, err)
+	require.Len(t, store.List(PipelineKind, nil), 2)
+	require.NoError(t, store.Close())
+
+	store, err = Open(path, 5)
+	require.NoError(t, err)
+	defer store.Close()
+	revs := store.List(PipelineKind, nil)
+	require.Len(t, revs, 2)
+	assert.Equal(t, int64(4), revs[0].Generation)
+	assert.Equal(t, int64(5), revs[1].Generation)
+}
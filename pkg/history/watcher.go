@@ -0,0 +1,147 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// watchRetryInterval is the delay before retrying a failed watch, doubled on every consecutive failure up to
+	// maxWatchRetryInterval.
+	watchRetryInterval    = 5 * time.Second
+	maxWatchRetryInterval = 5 * time.Minute
+)
+
+// Watcher records pipeline and vertex spec revisions into a Store.
+type Watcher struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Watch starts recording pipeline and vertex spec revisions into store until Stop is called. Resources are not
+// watched when the datasource is not allowed to, as watching them is optional.
+func Watch(nfClient *client.Client, store *Store) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{cancel: cancel}
+	w.wg.Add(2)
+	go w.watchLoop(ctx, PipelineKind, nfClient.WatchPipelines, store)
+	go w.watchLoop(ctx, VertexKind, nfClient.WatchVertices, store)
+	return w
+}
+
+// Stop stops watching, and waits until no more revisions are recorded, so that the store can be closed.
+func (w *Watcher) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+type watchFunc func(ctx context.Context, resourceVersion string) (watch.Interface, error)
+
+func (w *Watcher) watchLoop(ctx context.Context, kind Kind, watchFn watchFunc, store *Store) {
+	defer w.wg.Done()
+	resourceVersion := ""
+	retryInterval := watchRetryInterval
+	for {
+		watcher, err := watchFn(ctx, resourceVersion)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err):
+			backend.Logger.Warn("not allowed to watch resources, spec history is not recorded", "kind", kind, "err", err)
+			return
+		case err != nil:
+			backend.Logger.Error("failed to watch resources for spec history", "kind", kind, "retry", retryInterval, "err", err)
+		default:
+			resourceVersion = consume(ctx, kind, watcher, store, resourceVersion)
+			retryInterval = watchRetryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+		if err != nil {
+			retryInterval *= 2
+			if retryInterval > maxWatchRetryInterval {
+				retryInterval = maxWatchRetryInterval
+			}
+		}
+	}
+}
+
+// consume records revisions from w until the watch closes, and returns the resource version to resume from.
+func consume(ctx context.Context, kind Kind, w watch.Interface, store *Store, resourceVersion string) string {
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				rev, rv, err := revisionFromObject(kind, event.Object)
+				if err != nil {
+					backend.Logger.Error("failed to record spec revision", "kind", kind, "err", err)
+					continue
+				}
+				resourceVersion = rv
+				// resources are added when they are created, but also when they are listed at the start of every
+				// watch, only changes observed while watching are known to have happened now
+				if event.Type == watch.Modified && rev.Generation > 1 {
+					rev.Time = time.Now()
+				}
+				if _, err := store.Record(rev); err != nil {
+					backend.Logger.Error("failed to record spec revision", "kind", kind, "namespace", rev.Namespace, "name", rev.Name, "err", err)
+				}
+			case watch.Error:
+				// most likely the resource version is too old, start over from the current state
+				backend.Logger.Debug("spec history watch error", "kind", kind, "status", event.Object)
+				return ""
+			}
+		}
+	}
+}
+
+// revisionFromObject returns the revision of a pipeline or vertex, a baseline revision unless it is the first generation.
+func revisionFromObject(kind Kind, obj interface{}) (Revision, string, error) {
+	var meta metav1.ObjectMeta
+	var spec interface{}
+	rev := Revision{Kind: kind}
+	switch o := obj.(type) {
+	case *dfv1.Pipeline:
+		meta = o.ObjectMeta
+		spec = o.Spec
+		rev.Pipeline = o.Name
+	case *dfv1.Vertex:
+		meta = o.ObjectMeta
+		spec = o.Spec
+		rev.Pipeline = o.Spec.PipelineName
+		rev.Vertex = o.Spec.Name
+	}
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return rev, "", err
+	}
+	rev.Namespace = meta.Namespace
+	rev.Name = meta.Name
+	rev.UID = string(meta.UID)
+	rev.Generation = meta.Generation
+	rev.Spec = b
+	// the first generation is the spec the resource was created with, the change time of later ones is unknown here
+	if meta.Generation <= 1 {
+		rev.Time = meta.CreationTimestamp.Time
+	}
+	return rev, meta.ResourceVersion, nil
+}
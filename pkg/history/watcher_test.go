@@ -0,0 +1,57 @@
+package history
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// startWatchLoop runs a watch loop of pipelines with watchFn, like Watch.
+func startWatchLoop(watchFn watchFunc, store *Store) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{cancel: cancel}
+	w.wg.Add(1)
+	go w.watchLoop(ctx, PipelineKind, watchFn, store)
+	return w
+}
+
+func TestWatchLoopStopsWhenForbidden(t *testing.T) {
+	var calls int32
+	w := startWatchLoop(func(ctx context.Context, _ string) (watch.Interface, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, apierrors.NewForbidden(dfv1.Resource("pipelines"), "", nil)
+	}, NewStore(10))
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watch loop retried a forbidden watch")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	w.Stop()
+}
+
+func TestWatcherStopWaitsForRecords(t *testing.T) {
+	store := NewStore(10)
+	fake := watch.NewFake()
+	w := startWatchLoop(func(ctx context.Context, _ string) (watch.Interface, error) {
+		return fake, nil
+	}, store)
+	fake.Add(pipeline(1, time.Now()))
+
+	w.Stop()
+	// Stop returns although the watch is still open, after the event sent before it was recorded
+	require.Len(t, store.List(PipelineKind, nil), 1)
+}
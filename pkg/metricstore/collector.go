@@ -0,0 +1,222 @@
+package metricstore
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/numaproj/numaflow/pkg/apis/proto/daemon"
+	"github.com/numaproj/numaflow/pkg/isb"
+)
+
+type CollectorOptions struct {
+	Interval           time.Duration
+	Retention          time.Duration
+	DownsampleAfter    time.Duration
+	DownsampleInterval time.Duration
+}
+
+// Collector periodically records vertex metrics into a Store.
+type Collector struct {
+	nfClient  *client.Client
+	namespace string
+	store     *Store
+	opts      CollectorOptions
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// collectRedis records the state of the streams backing the buffers of a pipeline, when its isbsvc is a Redis isbsvc.
+func (c *Collector) collectRedis(ctx context.Context, v *dfv1.Vertex, edges []*daemon.BufferInfo, samples map[SeriesKey]float64) {
+	isbsvcName := v.Spec.InterStepBufferServiceName
+	if isbsvcName == "" {
+		isbsvcName = dfv1.DefaultISBSvcName
+	}
+	isbsvc, err := c.nfClient.GetInterStepBufferService(ctx, v.Namespace, isbsvcName)
+	if err != nil {
+		backend.Logger.Error("failed to retrieve isbsvc for metric store", "namespace", v.Namespace, "isbsvc", isbsvcName, "err", err)
+		return
+	}
+	if isbsvc.Spec.Redis == nil {
+		return
+	}
+	buffers := []string{}
+	toVertices := make(map[string]string)
+	for _, e := range edges {
+		if e.BufferName != nil && e.ToVertex != nil {
+			buffers = append(buffers, *e.BufferName)
+			toVertices[*e.BufferName] = *e.ToVertex
+		}
+	}
+	rdb, err := c.nfClient.ConnectRedis(ctx, isbsvc)
+	if err != nil {
+		backend.Logger.Error("failed to connect to redis isbsvc for metric store", "namespace", v.Namespace, "isbsvc", isbsvcName, "err", err)
+		return
+	}
+	defer rdb.Close()
+	stats, err := client.GetRedisBufferStats(ctx, rdb, buffers)
+	if err != nil {
+		backend.Logger.Error("failed to read redis buffers for metric store", "namespace", v.Namespace, "pipeline", v.Spec.PipelineName, "err", err)
+		return
+	}
+	for _, s := range stats {
+		key := func(m Metric) SeriesKey {
+			return SeriesKey{Namespace: v.Namespace, Pipeline: v.Spec.PipelineName, Vertex: toVertices[s.Buffer], Buffer: s.Buffer, Metric: m}
+		}
+		for m, value := range map[Metric]*int64{
+			RedisStreamLengthMetric: s.Length,
+			RedisPendingMetric:      s.Pending,
+			RedisLagMetric:          s.Lag,
+		} {
+			if value != nil {
+				samples[key(m)] = float64(*value)
+			}
+		}
+		if s.MemoryBytes != nil {
+			samples[key(RedisMemoryMetric)] = float64(*s.MemoryBytes) / 1000000
+		}
+	}
+}
+
+// StartCollector starts recording metrics of all vertices in namespace until Stop is called.
+func StartCollector(nfClient *client.Client, namespace string, store *Store, opts CollectorOptions) *Collector {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Collector{
+		nfClient:  nfClient,
+		namespace: namespace,
+		store:     store,
+		opts:      opts,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	go c.run(ctx)
+	return c
+}
+
+// Stop stops the collector, cancelling an in-progress collection, and waits for it to finish.
+func (c *Collector) Stop() {
+	c.cancel()
+	<-c.done
+}
+
+func (c *Collector) run(ctx context.Context) {
+	defer close(c.done)
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+	// compaction rewrites every series, and downsampled samples only change once per downsample interval
+	compactInterval := c.opts.DownsampleInterval
+	if compactInterval < c.opts.Interval {
+		compactInterval = c.opts.Interval
+	}
+	var compacted time.Time
+	for {
+		now := time.Now()
+		// a collection must not outlast its interval, e.g. when a daemon service hangs, nor delay Stop
+		collectCtx, cancel := context.WithTimeout(ctx, c.opts.Interval)
+		samples := c.collect(collectCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err := c.store.Append(now, samples); err != nil {
+			backend.Logger.Error("failed to append samples to metric store", "err", err)
+		}
+		if now.Sub(compacted) >= compactInterval {
+			if err := c.store.Compact(now, c.opts.Retention, c.opts.DownsampleAfter, c.opts.DownsampleInterval); err != nil {
+				backend.Logger.Error("failed to compact metric store", "err", err)
+			}
+			compacted = now
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Collector) collect(ctx context.Context) map[SeriesKey]float64 {
+	samples := make(map[SeriesKey]float64)
+	vertices, err := c.nfClient.ListVertices(ctx, c.namespace)
+	if err != nil {
+		backend.Logger.Error("failed to list vertices for metric store", "namespace", c.namespace, "err", err)
+		return samples
+	}
+
+	// input buffer usage per vertex, the highest usage is kept when a vertex has multiple input buffers
+	type pipelineKey struct{ namespace, pipeline string }
+	bufferUsages := make(map[pipelineKey]map[string]float64)
+	for _, v := range vertices {
+		pk := pipelineKey{namespace: v.Namespace, pipeline: v.Spec.PipelineName}
+		if _, ok := bufferUsages[pk]; ok {
+			continue
+		}
+		usages := make(map[string]float64)
+		bufferUsages[pk] = usages
+		edges, err := c.nfClient.ListPipelineEdges(ctx, pk.namespace, pk.pipeline)
+		if err != nil {
+			backend.Logger.Error("failed to retrieve edges for metric store", "namespace", pk.namespace, "pipeline", pk.pipeline)
+			continue
+		}
+		for _, e := range edges {
+			if e.ToVertex == nil || e.BufferUsage == nil {
+				continue
+			}
+			if u, ok := usages[*e.ToVertex]; !ok || *e.BufferUsage > u {
+				usages[*e.ToVertex] = *e.BufferUsage
+			}
+		}
+		c.collectRedis(ctx, &v, edges, samples)
+	}
+
+	for _, v := range vertices {
+		key := func(m Metric) SeriesKey {
+			return SeriesKey{Namespace: v.Namespace, Pipeline: v.Spec.PipelineName, Vertex: v.Spec.Name, Metric: m}
+		}
+		if usage, ok := bufferUsages[pipelineKey{namespace: v.Namespace, pipeline: v.Spec.PipelineName}][v.Spec.Name]; ok {
+			samples[key(BufferUsageMetric)] = usage
+		}
+		if vMetrics, err := c.nfClient.GetVertexMetrics(ctx, v.Namespace, v.Spec.PipelineName, v.Spec.Name); err == nil {
+			// Avg rate and pending for autoscaling are both in the map with key "default", see "pkg/metrics/metrics.go".
+			if rate, ok := vMetrics.ProcessingRates["default"]; ok && rate >= 0 && rate != isb.RateNotAvailable {
+				samples[key(ProcessingRateMetric)] = rate
+			}
+			if pending, ok := vMetrics.Pendings["default"]; ok && pending >= 0 && pending != isb.PendingNotAvailable {
+				samples[key(PendingMetric)] = float64(pending)
+			}
+		}
+		if vWatermark, err := c.nfClient.GetVertexWatermark(ctx, v.Namespace, v.Spec.PipelineName, v.Spec.Name); err == nil && vWatermark.Watermark != nil {
+			samples[key(WatermarkMetric)] = float64(*vWatermark.Watermark)
+		}
+		pods, err := c.nfClient.ListVertexPods(ctx, v.Namespace, v.Spec.PipelineName, v.Spec.Name)
+		if err != nil {
+			continue
+		}
+		cpu, memory, usages := int64(0), int64(0), 0
+		for pi := range pods {
+			pUsage, err := c.nfClient.GetPodUsage(ctx, &pods[pi])
+			if err != nil {
+				continue
+			}
+			cpu += pUsage.CPUMilli()
+			memory += pUsage.MemoryBytes()
+			usages++
+			for _, container := range pUsage.Containers {
+				cpuKey := key(ContainerCPUMetric)
+				cpuKey.Container = container.Name
+				samples[cpuKey] = math.Max(samples[cpuKey], float64(container.CPUMilli))
+				memoryKey := key(ContainerMemoryMetric)
+				memoryKey.Container = container.Name
+				samples[memoryKey] = math.Max(samples[memoryKey], float64(container.MemoryBytes)/1000000)
+			}
+		}
+		if usages > 0 {
+			samples[key(CPUMetric)] = float64(cpu)
+			samples[key(MemoryMetric)] = float64(memory) / 1000000
+		}
+	}
+	return samples
+}
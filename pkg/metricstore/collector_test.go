@@ -0,0 +1,48 @@
+package metricstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectorRecordsSimulatedCluster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nfClient, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Namespaces: 1, Pipelines: 2, Vertices: 3})
+	require.NoError(t, err)
+	store, err := Open(filepath.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	c := StartCollector(nfClient, "ns", store, CollectorOptions{
+		Interval:           20 * time.Millisecond,
+		Retention:          time.Hour,
+		DownsampleAfter:    time.Minute,
+		DownsampleInterval: time.Minute,
+	})
+	require.Eventually(t, func() bool {
+		series, err := store.Series(func(k SeriesKey) bool { return k.Metric == ProcessingRateMetric })
+		return err == nil && len(series) == 6
+	}, 5*time.Second, 20*time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("collector did not stop")
+	}
+
+	samples, err := store.Query(SeriesKey{Namespace: "ns", Pipeline: "orders", Vertex: "in", Metric: ProcessingRateMetric}, time.Now().Add(-time.Minute), time.Now())
+	require.NoError(t, err)
+	assert.NotEmpty(t, samples)
+}
@@ -0,0 +1,308 @@
+package metricstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type Metric string
+
+const (
+	ProcessingRateMetric Metric = "processing_rate"
+	PendingMetric        Metric = "pending"
+	WatermarkMetric      Metric = "watermark"
+	BufferUsageMetric    Metric = "buffer_usage"
+	CPUMetric            Metric = "cpu"
+	MemoryMetric         Metric = "memory"
+	// Container metrics are the usage of the busiest pod's container, keyed by container name.
+	ContainerCPUMetric    Metric = "container_cpu"
+	ContainerMemoryMetric Metric = "container_memory"
+	// Redis metrics are the state of the stream backing a buffer of a Redis isbsvc, keyed by buffer name.
+	RedisStreamLengthMetric Metric = "redis_stream_length"
+	RedisPendingMetric      Metric = "redis_pending"
+	RedisLagMetric          Metric = "redis_lag"
+	RedisMemoryMetric       Metric = "redis_memory"
+)
+
+func Metrics() []Metric {
+	return []Metric{
+		ProcessingRateMetric,
+		PendingMetric,
+		WatermarkMetric,
+		BufferUsageMetric,
+		CPUMetric,
+		MemoryMetric,
+		ContainerCPUMetric,
+		ContainerMemoryMetric,
+		RedisStreamLengthMetric,
+		RedisPendingMetric,
+		RedisLagMetric,
+		RedisMemoryMetric,
+	}
+}
+
+var (
+	rawBucket         = []byte("raw")
+	downsampledBucket = []byte("downsampled")
+)
+
+const keySeparator = "\x00"
+
+// SeriesKey identifies a single time series of a vertex metric.
+// Container is only set for container metrics, Buffer only for buffer metrics, whose vertex is the one reading the buffer.
+type SeriesKey struct {
+	Namespace string
+	Pipeline  string
+	Vertex    string
+	Container string
+	Buffer    string
+	Metric    Metric
+}
+
+// bytes encodes the key, buffer keys have an extra part so that the keys of existing stores stay valid.
+func (k SeriesKey) bytes() []byte {
+	parts := []string{k.Namespace, k.Pipeline, k.Vertex, k.Container, string(k.Metric)}
+	if k.Buffer != "" {
+		parts = append(parts, k.Buffer)
+	}
+	return []byte(strings.Join(parts, keySeparator))
+}
+
+func parseSeriesKey(b []byte) (SeriesKey, bool) {
+	parts := strings.Split(string(b), keySeparator)
+	if len(parts) != 5 && len(parts) != 6 {
+		return SeriesKey{}, false
+	}
+	k := SeriesKey{Namespace: parts[0], Pipeline: parts[1], Vertex: parts[2], Container: parts[3], Metric: Metric(parts[4])}
+	if len(parts) == 6 {
+		k.Buffer = parts[5]
+	}
+	return k, true
+}
+
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// Store is an embedded, on-disk store of vertex metric samples.
+// Recent samples are kept at full resolution, older samples are downsampled to averages.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens, or creates, the store at path.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create metric store directory, %w", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metric store, %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{rawBucket, downsampledBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Append writes one sample per series at time t.
+func (s *Store) Append(t time.Time, samples map[SeriesKey]float64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(rawBucket)
+		for k, v := range samples {
+			series, err := raw.CreateBucketIfNotExists(k.bytes())
+			if err != nil {
+				return err
+			}
+			if err := series.Put(encodeTime(t), encodeValue(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Series returns the keys of all stored series matching filter.
+func (s *Store) Series(filter func(SeriesKey) bool) ([]SeriesKey, error) {
+	seen := make(map[SeriesKey]bool)
+	keys := []SeriesKey{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{downsampledBucket, rawBucket} {
+			err := tx.Bucket(b).ForEach(func(name, _ []byte) error {
+				k, ok := parseSeriesKey(name)
+				if ok && !seen[k] && (filter == nil || filter(k)) {
+					seen[k] = true
+					keys = append(keys, k)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Query returns the samples of a series between from and to, oldest first.
+func (s *Store) Query(k SeriesKey, from, to time.Time) ([]Sample, error) {
+	samples := []Sample{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// downsampled samples are always older than raw samples
+		for _, b := range [][]byte{downsampledBucket, rawBucket} {
+			series := tx.Bucket(b).Bucket(k.bytes())
+			if series == nil {
+				continue
+			}
+			c := series.Cursor()
+			max := encodeTime(to)
+			for tk, v := c.Seek(encodeTime(from)); tk != nil && string(tk) <= string(max); tk, v = c.Next() {
+				samples = append(samples, Sample{Time: decodeTime(tk), Value: decodeValue(v)})
+			}
+		}
+		return nil
+	})
+	return samples, err
+}
+
+// Max returns the highest value of a series between from and to, false when there are no samples.
+func (s *Store) Max(k SeriesKey, from, to time.Time) (float64, bool, error) {
+	samples, err := s.Query(k, from, to)
+	if err != nil || len(samples) == 0 {
+		return 0, false, err
+	}
+	max := samples[0].Value
+	for _, sample := range samples[1:] {
+		max = math.Max(max, sample.Value)
+	}
+	return max, true, nil
+}
+
+// Compact downsamples raw samples older than downsampleAfter into averages over downsampleInterval,
+// and deletes all samples older than retention.
+func (s *Store) Compact(now time.Time, retention, downsampleAfter, downsampleInterval time.Duration) error {
+	expired := encodeTime(now.Add(-retention))
+	downsampleBefore := now.Add(-downsampleAfter).Truncate(downsampleInterval)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(rawBucket)
+		downsampled := tx.Bucket(downsampledBucket)
+		var names [][]byte
+		if err := raw.ForEach(func(name, _ []byte) error {
+			names = append(names, append([]byte{}, name...))
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := downsampleSeries(raw.Bucket(name), downsampled, name, downsampleBefore, downsampleInterval); err != nil {
+				return err
+			}
+		}
+		for _, b := range []*bolt.Bucket{raw, downsampled} {
+			if err := deleteBefore(b, expired); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func downsampleSeries(series, downsampled *bolt.Bucket, name []byte, before time.Time, interval time.Duration) error {
+	type bucketAvg struct {
+		sum   float64
+		count int
+	}
+	avgs := make(map[time.Time]*bucketAvg)
+	var old [][]byte
+	c := series.Cursor()
+	for tk, v := c.First(); tk != nil && decodeTime(tk).Before(before); tk, v = c.Next() {
+		start := decodeTime(tk).Truncate(interval)
+		if avgs[start] == nil {
+			avgs[start] = &bucketAvg{}
+		}
+		avgs[start].sum += decodeValue(v)
+		avgs[start].count++
+		old = append(old, append([]byte{}, tk...))
+	}
+	if len(old) == 0 {
+		return nil
+	}
+	target, err := downsampled.CreateBucketIfNotExists(name)
+	if err != nil {
+		return err
+	}
+	for start, avg := range avgs {
+		if err := target.Put(encodeTime(start), encodeValue(avg.sum/float64(avg.count))); err != nil {
+			return err
+		}
+	}
+	for _, tk := range old {
+		if err := series.Delete(tk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteBefore(b *bolt.Bucket, expired []byte) error {
+	return b.ForEach(func(name, _ []byte) error {
+		series := b.Bucket(name)
+		if series == nil {
+			return nil
+		}
+		var old [][]byte
+		c := series.Cursor()
+		for tk, _ := c.First(); tk != nil && string(tk) < string(expired); tk, _ = c.Next() {
+			old = append(old, append([]byte{}, tk...))
+		}
+		for _, tk := range old {
+			if err := series.Delete(tk); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Times are encoded big-endian so that byte order matches time order.
+func encodeTime(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	return b
+}
+
+func decodeTime(b []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b)))
+}
+
+func encodeValue(v float64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(v))
+	return b
+}
+
+func decodeValue(b []byte) float64 {
+	return math.Float64frombits(binary.BigEndian.Uint64(b))
+}
@@ -0,0 +1,45 @@
+package metricstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreCompactDownsamplesAndExpires(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "store.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	k := SeriesKey{Namespace: "ns", Pipeline: "pl", Vertex: "map", Metric: ProcessingRateMetric}
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		// one sample per minute for the last 30 minutes, valued by minute
+		require.NoError(t, store.Append(now.Add(-time.Duration(30-i)*time.Minute), map[SeriesKey]float64{k: float64(i)}))
+	}
+	require.NoError(t, store.Compact(now, 25*time.Minute, 10*time.Minute, 10*time.Minute))
+
+	samples, err := store.Query(k, now.Add(-time.Hour), now)
+	require.NoError(t, err)
+	// samples of 11:30-11:49 are downsampled into 11:30 and 11:40, of which 11:30 is expired, later samples stay raw
+	require.Len(t, samples, 11)
+	assert.Equal(t, now.Add(-20*time.Minute), samples[0].Time.UTC())
+	assert.Equal(t, 14.5, samples[0].Value)
+	assert.Equal(t, now.Add(-10*time.Minute), samples[1].Time.UTC())
+	assert.Equal(t, 20.0, samples[1].Value)
+	last := samples[len(samples)-1]
+	assert.Equal(t, now.Add(-time.Minute), last.Time.UTC())
+	assert.Equal(t, 29.0, last.Value)
+
+	max, ok, err := store.Max(k, now.Add(-time.Hour), now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 29.0, max)
+
+	series, err := store.Series(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []SeriesKey{k}, series)
+}
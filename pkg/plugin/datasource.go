@@ -2,18 +2,26 @@ package plugin
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"github.com/dseapy/numaflow-datasource/pkg/cache"
 	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/history"
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
 	"github.com/dseapy/numaflow-datasource/pkg/query"
-	"github.com/dseapy/numaflow-datasource/pkg/resource"
 	"github.com/dseapy/numaflow-datasource/pkg/scenario"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/utils/pointer"
-	"net/http"
+	"path/filepath"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Make sure Datasource implements required interfaces. This is important to do
@@ -38,21 +46,108 @@ func NewDatasource(dis backend.DataSourceInstanceSettings) (instancemgmt.Instanc
 	if !settings.Namespaced {
 		ns = v1.NamespaceAll
 	}
-	c, err := client.NewClient(ns)
+	redaction, err := settings.redactionRules()
 	if err != nil {
 		return nil, err
 	}
-	return &Datasource{
-		settings: settings,
-		client:   c,
-	}, nil
+	cacheTTL, err := settings.cacheTTL()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := newClient(ctx, ns, settings)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	var metricStore *metricstore.Store
+	var collector *metricstore.Collector
+	if settings.MetricStoreEnabled {
+		collectorOpts, err := settings.metricStoreOptions()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		path, err := settings.metricStorePath(dis.ID)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		metricStore, err = metricstore.Open(path)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		collector = metricstore.StartCollector(c, ns, metricStore, collectorOpts)
+	}
+	var historyStore *history.Store
+	var historyWatcher *history.Watcher
+	if settings.HistoryMaxRevisions > 0 {
+		historyStore = newHistoryStore(settings, dis.ID)
+		historyWatcher = history.Watch(c, historyStore)
+	}
+	d := &Datasource{
+		settings:    settings,
+		client:      c,
+		cancel:      cancel,
+		metricStore: metricStore,
+		collector:   collector,
+		watcher:     historyWatcher,
+		cache:       cache.New(cacheTTL),
+		scenarioOptions: scenario.Options{
+			Redaction: redaction,
+			History:   historyStore,
+			Metrics:   metricStore,
+			Links: scenario.LinkOptions{
+				UIURL:          settings.NumaflowUIURL,
+				DatasourceUID:  dis.UID,
+				DatasourceName: dis.Name,
+			},
+		},
+	}
+	d.resourceHandler = httpadapter.New(d.newRouter())
+	return d, nil
+}
+
+// newClient returns the client of the cluster, or of a simulated cluster which runs until ctx is done.
+func newClient(ctx context.Context, ns string, settings *Settings) (*client.Client, error) {
+	if settings.Simulated {
+		opts, err := settings.simulationOptions()
+		if err != nil {
+			return nil, err
+		}
+		backend.Logger.Info("using a simulated cluster", "namespaces", opts.Namespaces, "pipelines", opts.Pipelines, "vertices", opts.Vertices)
+		return client.NewSimulatedClient(ctx, ns, opts)
+	}
+	return client.NewClient(ns, client.PodUsageSourceType(settings.PodUsageSource))
+}
+
+// newHistoryStore returns the spec history, kept on disk when historyDirectory is set. A history that cannot be opened,
+// e.g. in a read-only directory or while another process holds it, is kept in memory instead of failing the datasource.
+func newHistoryStore(settings *Settings, id int64) *history.Store {
+	if settings.HistoryDirectory == "" {
+		return history.NewStore(settings.HistoryMaxRevisions)
+	}
+	store, err := history.Open(filepath.Join(settings.HistoryDirectory, fmt.Sprintf("history-%d.db", id)), settings.HistoryMaxRevisions)
+	if err != nil {
+		backend.Logger.Warn("failed to open spec history, keeping it in memory", "directory", settings.HistoryDirectory, "err", err)
+		return history.NewStore(settings.HistoryMaxRevisions)
+	}
+	return store
 }
 
 // Datasource is an example datasource which can respond to data queries, reports
 // its health and has streaming skills.
 type Datasource struct {
-	settings *Settings
-	client   *client.Client
+	settings        *Settings
+	client          *client.Client
+	cancel          context.CancelFunc
+	metricStore     *metricstore.Store
+	collector       *metricstore.Collector
+	watcher         *history.Watcher
+	cache           *cache.Cache
+	scenarioOptions scenario.Options
+	resourceHandler backend.CallResourceHandler
 }
 
 // Dispose here tells plugin SDK that plugin wants to clean up resources when a new instance
@@ -60,6 +155,23 @@ type Datasource struct {
 // be disposed and a new one will be created using NewSampleDatasource factory function.
 func (d *Datasource) Dispose() {
 	// Clean up datasource instance resources.
+	d.cancel()
+	if d.collector != nil {
+		d.collector.Stop()
+	}
+	if d.metricStore != nil {
+		if err := d.metricStore.Close(); err != nil {
+			log.DefaultLogger.Error("failed to close metric store", "err", err)
+		}
+	}
+	if d.watcher != nil {
+		d.watcher.Stop()
+	}
+	if d.scenarioOptions.History != nil {
+		if err := d.scenarioOptions.History.Close(); err != nil {
+			log.DefaultLogger.Error("failed to close spec history", "err", err)
+		}
+	}
 }
 
 // QueryData handles multiple queries and returns multiple responses.
@@ -68,13 +180,15 @@ func (d *Datasource) Dispose() {
 // contains Frames ([]*Frame).
 func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	log.DefaultLogger.Debug("QueryData called", "request", req)
+	ctx, span := tracing.DefaultTracer().Start(ctx, "Datasource.QueryData", trace.WithAttributes(attribute.Int("queries", len(req.Queries))))
+	defer span.End()
 
 	// create response struct
 	response := backend.NewQueryDataResponse()
 
 	// loop over queries and execute them individually.
 	for _, q := range req.Queries {
-		res := runQuery(ctx, *d.settings, d.client, q)
+		res := runQuery(ctx, *d.settings, d.client, d.cache, d.scenarioOptions, q)
 
 		// save the response in a hashmap
 		// based on with RefID as identifier
@@ -84,78 +198,32 @@ func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataReques
 	return response, nil
 }
 
-func (d *Datasource) CallResource(_ context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
-	if req.Path == resource.QueryTypesAPIPath && req.Method == resource.QueryTypesAPIMethod {
-		j, err := resource.QueryTypesJson()
-		if err != nil {
-			return sender.Send(&backend.CallResourceResponse{
-				Status: http.StatusInternalServerError,
-			})
-		}
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusOK,
-			Body:   j,
-		})
-	} else if req.Path == resource.MetricNamesAPIPath && req.Method == resource.MetricNamesAPIMethod {
-		var q query.Query
-		if d.settings == nil {
-			return sender.Send(&backend.CallResourceResponse{
-				Status: http.StatusBadRequest,
-				Body:   []byte("datasource settings nil when trying to get metric names"),
-			})
-		}
-		if err := q.Unmarshall(req.Body); err != nil {
-			return sender.Send(&backend.CallResourceResponse{
-				Status: http.StatusBadRequest,
-				Body:   []byte(err.Error()),
-			})
-		}
-		j, err := resource.MetricNamesJson(&q, d.client)
-		if err != nil {
-			return sender.Send(&backend.CallResourceResponse{
-				// might be bad request still... this is good enough for now
-				Status: http.StatusInternalServerError,
-				// consider not passing back, but logging instead
-				Body: []byte(err.Error()),
-			})
-		}
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusOK,
-			Body:   j,
-		})
-	} else {
-		return sender.Send(&backend.CallResourceResponse{
-			Status: http.StatusNotFound,
-		})
-	}
+// CallResource serves the resources of the router, see newRouter.
+func (d *Datasource) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return d.resourceHandler.CallResource(ctx, req, sender)
 }
 
 // CheckHealth handles health checks sent from Grafana to the plugin.
 // The main use case for these health checks is the test button on the
 // datasource configuration page which allows users to verify that
 // a datasource is working as expected.
-func (d *Datasource) CheckHealth(_ context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+func (d *Datasource) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
 	log.DefaultLogger.Debug("CheckHealth called", "request", req)
 
-	if _, err := d.client.ListNamespacesWithInterStepBufferServices(); err != nil {
-		return &backend.CheckHealthResult{
-			Status:  backend.HealthStatusError,
-			Message: err.Error(),
-		}, nil
-	}
-
-	return &backend.CheckHealthResult{
-		Status:  backend.HealthStatusOk,
-		Message: "Data source is working",
-	}, nil
+	return newCheckHealthResult(runHealthChecks(ctx, d.client))
 }
 
-func runQuery(_ context.Context, settings Settings, client *client.Client, dq backend.DataQuery) backend.DataResponse {
+func runQuery(ctx context.Context, settings Settings, client *client.Client, c *cache.Cache, opts scenario.Options, dq backend.DataQuery) backend.DataResponse {
+	ctx, span := tracing.DefaultTracer().Start(ctx, "runQuery", trace.WithAttributes(
+		attribute.String("refId", dq.RefID),
+		attribute.String("queryType", dq.QueryType),
+	))
+	defer span.End()
 	response := backend.DataResponse{}
 	var q query.Query
 	backend.Logger.Debug("query json %v", string(dq.JSON))
-	if response.Error = q.Unmarshall(dq.JSON); response.Error != nil {
-		return response
+	if err := q.Unmarshall(dq.JSON); err != nil {
+		return errorResponse(span, query.ClassifyError(err, ""))
 	}
 
 	// validate
@@ -166,23 +234,81 @@ func runQuery(_ context.Context, settings Settings, client *client.Client, dq ba
 		q.RunnableQuery.Namespace = pointer.String(v1.NamespaceAll)
 	}
 	if *q.RunnableQuery.Namespace == v1.NamespaceAll && q.RunnableQuery.ResourceName != "*" {
-		response.Error = errors.New(`"namespace" must be provided when requesting a single pipeline, vertex, or isbsvc by name`)
-		return response
+		return errorResponse(span, query.Errorf(query.ValidationError, `"namespace" must be provided when requesting a single pipeline, vertex, or isbsvc by name`))
 	}
 
-	// create frames
-	frames, err := scenario.NewDataFrames(client, dq, q.RunnableQuery)
+	span.SetAttributes(queryAttributes(q.RunnableQuery)...)
+
+	// create frames, or reuse the frames of an identical query
+	key := dq.QueryType + " " + q.RunnableQuery.CacheKey()
+	if scenario.UsesTimeRange(dq.QueryType) {
+		// viewers of the same relative time range share results within a TTL
+		key += fmt.Sprintf(" %d %d", dq.TimeRange.From.Truncate(c.TTL()).Unix(), dq.TimeRange.To.Truncate(c.TTL()).Unix())
+	}
+	v, err := c.Get(ctx, dq.QueryType, key, q.RunnableQuery.GetNoCache(), func(ctx context.Context) (interface{}, error) {
+		return scenario.NewDataFrames(ctx, client, dq, q.RunnableQuery, opts)
+	})
 	if err != nil {
-		backend.Logger.Error("error retrieving frames", "err", err)
-		response.Error = errors.New(`error retrieving frames`)
-		return response
+		return errorResponse(span, query.ClassifyError(err, q.RunnableQuery.Object()))
 	}
+	frames := v.(data.Frames)
 	if len(frames) == 0 {
 		return response
 	}
+	// cached frames are shared, so only copies get the ref id of this query
 	for _, frame := range frames {
-		frame.RefID = dq.RefID
+		response.Frames = append(response.Frames, copyFrame(frame, dq.RefID))
 	}
-	response.Frames = append(response.Frames, frames...)
 	return response
 }
+
+// copyFrame returns a copy of a cached frame with the given ref id. The frame's fields and metadata are copied too, so
+// that changes to the response, e.g. by the SDK or a later notice, do not reach the cache. The values of the fields
+// are shared, and must not be modified.
+func copyFrame(frame *data.Frame, refID string) *data.Frame {
+	f := *frame
+	f.RefID = refID
+	f.Fields = make([]*data.Field, len(frame.Fields))
+	for i, field := range frame.Fields {
+		fc := *field
+		if field.Config != nil {
+			config := *field.Config
+			fc.Config = &config
+		}
+		if field.Labels != nil {
+			fc.Labels = make(data.Labels, len(field.Labels))
+			for k, v := range field.Labels {
+				fc.Labels[k] = v
+			}
+		}
+		f.Fields[i] = &fc
+	}
+	if frame.Meta != nil {
+		meta := *frame.Meta
+		meta.Stats = append([]data.QueryStat(nil), frame.Meta.Stats...)
+		meta.Notices = append([]data.Notice(nil), frame.Meta.Notices...)
+		f.Meta = &meta
+	}
+	return &f
+}
+
+// errorResponse returns the data response of a failed query, whose status and source tell Grafana whether the query,
+// Kubernetes and Numaflow, or the plugin are at fault.
+func errorResponse(span trace.Span, err *query.Error) backend.DataResponse {
+	backend.Logger.Error("query failed", "kind", err.Kind, "source", err.Source(), "object", err.Object, "err", err.Err)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(attribute.String("error.kind", string(err.Kind)), attribute.String("error.source", string(err.Source())))
+	return backend.DataResponse{Error: err, Status: err.Status()}
+}
+
+// queryAttributes returns the span attributes describing the resources a query selects.
+func queryAttributes(rq query.RunnableQuery) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("namespace", *rq.Namespace)}
+	for k, v := range map[string]*string{"pipeline": rq.Pipeline, "vertex": rq.Vertex, "isbsvc": rq.InterStepBufferService, "pod": rq.Pod} {
+		if v != nil {
+			attrs = append(attrs, attribute.String(k, *v))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,30 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dseapy/numaflow-datasource/pkg/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHistoryStoreFallsBackToMemory(t *testing.T) {
+	// a file where the directory should be cannot hold the history
+	notADirectory := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(notADirectory, nil, 0o600))
+	store := newHistoryStore(&Settings{HistoryMaxRevisions: 2, HistoryDirectory: notADirectory}, 1)
+	require.NotNil(t, store)
+	recorded, err := store.Record(history.Revision{Kind: history.PipelineKind, Namespace: "ns", Name: "pl", Generation: 1})
+	require.NoError(t, err)
+	assert.True(t, recorded)
+	assert.NoError(t, store.Close())
+}
+
+func TestNewHistoryStoreKeepsHistoryInDirectory(t *testing.T) {
+	dir := t.TempDir()
+	store := newHistoryStore(&Settings{HistoryMaxRevisions: 2, HistoryDirectory: dir}, 7)
+	defer store.Close()
+	assert.FileExists(t, filepath.Join(dir, "history-7.db"))
+}
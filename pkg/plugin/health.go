@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+type checkStatus string
+
+const (
+	checkPass checkStatus = "pass"
+	checkWarn checkStatus = "warn"
+	checkFail checkStatus = "fail"
+)
+
+type checkResult struct {
+	Name    string      `json:"name"`
+	Status  checkStatus `json:"status"`
+	Message string      `json:"message"`
+}
+
+type healthDetails struct {
+	Checks []checkResult `json:"checks"`
+}
+
+// runHealthChecks checks access to every resource the datasource uses, the metrics API and a daemon service.
+func runHealthChecks(ctx context.Context, c *client.Client) []checkResult {
+	results := []checkResult{}
+	for _, a := range client.RequiredAccess {
+		results = append(results, checkAccess(ctx, c, a))
+	}
+	results = append(results, checkMetricsAPI(ctx, c))
+	results = append(results, checkDaemonService(ctx, c))
+	return results
+}
+
+func checkAccess(ctx context.Context, c *client.Client, a client.Access) checkResult {
+	resource := a.Resource
+	if a.Subresource != "" {
+		resource += "/" + a.Subresource
+	}
+	if a.Group != "" {
+		resource += "." + a.Group
+	}
+	r := checkResult{Name: fmt.Sprintf("%s %s", a.Verb, resource)}
+	failed := checkFail
+	if a.Optional {
+		failed = checkWarn
+	}
+	allowed, reason, err := c.CheckAccess(ctx, a)
+	switch {
+	case err != nil:
+		r.Status = failed
+		r.Message = fmt.Sprintf("access review failed, %v", err)
+	case !allowed:
+		r.Status = failed
+		r.Message = "not allowed"
+		if reason != "" {
+			r.Message += ", " + reason
+		}
+	default:
+		r.Status = checkPass
+		r.Message = "allowed"
+	}
+	return r
+}
+
+func checkMetricsAPI(ctx context.Context, c *client.Client) checkResult {
+	r := checkResult{Name: "metrics API"}
+	if err := c.ProbeMetricsAPI(ctx); err != nil {
+		r.Status = checkWarn
+		r.Message = fmt.Sprintf("pod cpu and memory usage unavailable, %v", err)
+		return r
+	}
+	r.Status = checkPass
+	r.Message = "metrics.k8s.io is available"
+	return r
+}
+
+func checkDaemonService(ctx context.Context, c *client.Client) checkResult {
+	r := checkResult{Name: "daemon service"}
+	pipelines, err := c.ListPipelines(ctx, c.Namespace())
+	if err != nil {
+		r.Status = checkFail
+		r.Message = fmt.Sprintf("failed to list pipelines, %v", err)
+		return r
+	}
+	if len(pipelines) == 0 {
+		r.Status = checkWarn
+		r.Message = "no pipeline found to probe a daemon service"
+		return r
+	}
+	pl := pipelines[0]
+	if _, err := c.ListPipelineEdges(ctx, pl.Namespace, pl.Name); err != nil {
+		r.Status = checkFail
+		r.Message = fmt.Sprintf("daemon service of pipeline %s/%s unreachable, %v", pl.Namespace, pl.Name, err)
+		return r
+	}
+	r.Status = checkPass
+	r.Message = fmt.Sprintf("daemon service of pipeline %s/%s is reachable", pl.Namespace, pl.Name)
+	return r
+}
+
+// newCheckHealthResult rolls the check results up into a single status with the results as JSON details.
+func newCheckHealthResult(results []checkResult) (*backend.CheckHealthResult, error) {
+	counts := make(map[checkStatus]int)
+	problems := []string{}
+	for _, r := range results {
+		counts[r.Status]++
+		if r.Status != checkPass {
+			problems = append(problems, fmt.Sprintf("%s: %s", r.Name, r.Message))
+		}
+	}
+	details, err := json.Marshal(healthDetails{Checks: results})
+	if err != nil {
+		return nil, err
+	}
+	status := backend.HealthStatusOk
+	message := "Data source is working"
+	if counts[checkFail] > 0 {
+		status = backend.HealthStatusError
+		message = fmt.Sprintf("%d checks failed", counts[checkFail])
+	} else if counts[checkWarn] > 0 {
+		message = fmt.Sprintf("Data source is working with %d warnings", counts[checkWarn])
+	}
+	if len(problems) > 0 {
+		message += ": " + strings.Join(problems, "; ")
+	}
+	return &backend.CheckHealthResult{
+		Status:      status,
+		Message:     message,
+		JSONDetails: details,
+	}, nil
+}
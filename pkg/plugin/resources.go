@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/dseapy/numaflow-datasource/pkg/api"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/dseapy/numaflow-datasource/pkg/resource"
+	"github.com/gorilla/mux"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// newRouter returns the handler of the datasource's resources: the resources of the query editor, and the REST API.
+func (d *Datasource) newRouter() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc(resource.QueryTypesAPIPath, d.handleQueryTypes).Methods(resource.QueryTypesAPIMethod)
+	r.HandleFunc(resource.MetricNamesAPIPath, d.handleMetricNames).Methods(resource.MetricNamesAPIMethod)
+	r.HandleFunc(resource.ValidateAPIPath, d.handleValidate).Methods(resource.ValidateAPIMethod)
+	r.HandleFunc(resource.CompleteAPIPath, d.handleComplete).Methods(resource.CompleteAPIMethod)
+	api.RegisterV1(r.PathPrefix(api.V1Prefix).Subrouter(), d.client, d.cache, d.settings.restrictedNamespace())
+	return r
+}
+
+func (d *Datasource) handleQueryTypes(w http.ResponseWriter, _ *http.Request) {
+	j, err := resource.QueryTypesJson()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, http.StatusOK, j)
+}
+
+func (d *Datasource) handleMetricNames(w http.ResponseWriter, req *http.Request) {
+	var q query.Query
+	body, err := io.ReadAll(req.Body)
+	if err == nil {
+		err = q.Unmarshall(body)
+	}
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	key := "metric-names " + q.RunnableQuery.CacheKey()
+	v, err := d.cache.Get(req.Context(), "metric-names", key, q.RunnableQuery.GetNoCache(), func(ctx context.Context) (interface{}, error) {
+		return resource.MetricNamesJson(ctx, &q, d.client)
+	})
+	if err != nil {
+		writeError(w, query.ClassifyError(err, q.RunnableQuery.Object()))
+		return
+	}
+	writeBody(w, http.StatusOK, v.([]byte))
+}
+
+func (d *Datasource) handleValidate(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err == nil {
+		body, err = resource.ValidateJson(body, d.settings.restrictedNamespace())
+	}
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	writeBody(w, http.StatusOK, body)
+}
+
+func (d *Datasource) handleComplete(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err == nil {
+		body, err = resource.CompleteJson(req.Context(), body, d.client, d.cache, d.settings.restrictedNamespace())
+	}
+	if err != nil {
+		writeError(w, query.ClassifyError(err, ""))
+		return
+	}
+	writeBody(w, http.StatusOK, body)
+}
+
+// writeError writes the message of a classified error, with its status.
+func writeError(w http.ResponseWriter, err *query.Error) {
+	if err.Kind == query.InternalError {
+		backend.Logger.Error("resource request failed", "kind", err.Kind, "object", err.Object, "err", err.Err)
+	}
+	writeBody(w, int(err.Status()), []byte(err.Error()))
+}
+
+func writeBody(w http.ResponseWriter, status int, body []byte) {
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		backend.Logger.Error("failed to write resource response", "err", err)
+	}
+}
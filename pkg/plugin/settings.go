@@ -2,20 +2,71 @@ package plugin
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
+	"github.com/dseapy/numaflow-datasource/pkg/scenario"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 type Settings struct {
-	Namespaced bool   `json:"namespaced"`
-	Namespace  string `json:"namespace"`
+	Namespaced           bool   `json:"namespaced"`
+	Namespace            string `json:"namespace"`
+	PodUsageSource       string `json:"podUsageSource"`
+	RedactEnvValues      bool   `json:"redactEnvValues"`
+	RedactEnvNamePattern string `json:"redactEnvNamePattern"`
+	RedactSecretRefs     bool   `json:"redactSecretRefs"`
+	HistoryMaxRevisions  int    `json:"historyMaxRevisions"`
+	HistoryDirectory     string `json:"historyDirectory"`
+	NumaflowUIURL        string `json:"numaflowUiUrl"`
+	CacheTTL             string `json:"cacheTtl"`
+
+	MetricStoreEnabled            bool   `json:"metricStoreEnabled"`
+	MetricStoreDirectory          string `json:"metricStoreDirectory"`
+	MetricStoreInterval           string `json:"metricStoreInterval"`
+	MetricStoreRetention          string `json:"metricStoreRetention"`
+	MetricStoreDownsampleAfter    string `json:"metricStoreDownsampleAfter"`
+	MetricStoreDownsampleInterval string `json:"metricStoreDownsampleInterval"`
+
+	Simulated                  bool    `json:"simulated"`
+	SimulationNamespaces       int     `json:"simulationNamespaces"`
+	SimulationPipelines        int     `json:"simulationPipelines"`
+	SimulationVertices         int     `json:"simulationVertices"`
+	SimulationFailureRate      float64 `json:"simulationFailureRate"`
+	SimulationFailingPipelines string  `json:"simulationFailingPipelines"`
+	SimulationInterval         string  `json:"simulationInterval"`
 }
 
 func loadSettings(source backend.DataSourceInstanceSettings) (*Settings, error) {
 	settings := Settings{
-		Namespaced: false,
-		Namespace:  "default",
+		Namespaced:           false,
+		Namespace:            "default",
+		PodUsageSource:       string(client.AutoPodUsageSource),
+		RedactEnvValues:      false,
+		RedactEnvNamePattern: "(?i)(password|secret|token|credential|key)",
+		RedactSecretRefs:     true,
+		HistoryMaxRevisions:  20,
+		CacheTTL:             "10s",
+
+		MetricStoreEnabled:            false,
+		MetricStoreInterval:           "30s",
+		MetricStoreRetention:          "168h",
+		MetricStoreDownsampleAfter:    "6h",
+		MetricStoreDownsampleInterval: "5m",
+
+		Simulated:            false,
+		SimulationNamespaces: 2,
+		SimulationPipelines:  3,
+		SimulationVertices:   4,
+		SimulationInterval:   "1m",
 	}
 
 	if source.JSONData == nil || len(source.JSONData) < 1 {
@@ -31,3 +82,125 @@ func loadSettings(source backend.DataSourceInstanceSettings) (*Settings, error)
 
 	return &settings, nil
 }
+
+func (s *Settings) redactionRules() (scenario.RedactionRules, error) {
+	rules := scenario.RedactionRules{
+		EnvValues:  s.RedactEnvValues,
+		SecretRefs: s.RedactSecretRefs,
+	}
+	if s.RedactEnvNamePattern != "" {
+		re, err := regexp.Compile(s.RedactEnvNamePattern)
+		if err != nil {
+			return rules, fmt.Errorf("could not compile redactEnvNamePattern: %w", err)
+		}
+		rules.EnvNamePattern = re
+	}
+	return rules, nil
+}
+
+// restrictedNamespace is the namespace that all queries are restricted to, or empty when the datasource is not namespaced.
+func (s *Settings) restrictedNamespace() string {
+	if !s.Namespaced {
+		return ""
+	}
+	return s.Namespace
+}
+
+// cacheTTL is how long query results are cached, 0 disables the cache.
+func (s *Settings) cacheTTL() (time.Duration, error) {
+	if s.CacheTTL == "" || s.CacheTTL == "0" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(s.CacheTTL)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse cacheTtl: %w", err)
+	}
+	if ttl < 0 {
+		return 0, errors.New("cacheTtl must not be negative")
+	}
+	return ttl, nil
+}
+
+// metricStorePath returns the file of the metric store of a datasource, in metricStoreDirectory or else in a directory
+// under Grafana's data path. The directory must be writable, and outside of the plugin's installation directory, whose
+// files Grafana verifies against the plugin's signature.
+func (s *Settings) metricStorePath(id int64) (string, error) {
+	dir := s.MetricStoreDirectory
+	if dir == "" {
+		dataPath := os.Getenv("GF_PATHS_DATA")
+		if dataPath == "" {
+			return "", errors.New("metricStoreDirectory must be set when the metric store is enabled, as Grafana's data path (GF_PATHS_DATA) is not known")
+		}
+		dir = filepath.Join(dataPath, "numaflow-datasource")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid metricStoreDirectory %q: %w", s.MetricStoreDirectory, err)
+	}
+	if exe, err := os.Executable(); err == nil {
+		if rel, err := filepath.Rel(filepath.Dir(exe), dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("metricStoreDirectory %q must be outside of the plugin's installation directory", dir)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("metricStoreDirectory %q could not be created: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".write-check-")
+	if err != nil {
+		return "", fmt.Errorf("metricStoreDirectory %q is not writable: %w", dir, err)
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return filepath.Join(dir, fmt.Sprintf("datasource-%d.db", id)), nil
+}
+
+func (s *Settings) metricStoreOptions() (metricstore.CollectorOptions, error) {
+	opts := metricstore.CollectorOptions{}
+	durations := []struct {
+		name  string
+		value string
+		into  *time.Duration
+	}{
+		{"metricStoreInterval", s.MetricStoreInterval, &opts.Interval},
+		{"metricStoreRetention", s.MetricStoreRetention, &opts.Retention},
+		{"metricStoreDownsampleAfter", s.MetricStoreDownsampleAfter, &opts.DownsampleAfter},
+		{"metricStoreDownsampleInterval", s.MetricStoreDownsampleInterval, &opts.DownsampleInterval},
+	}
+	for _, d := range durations {
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return opts, fmt.Errorf("could not parse %s: %w", d.name, err)
+		}
+		if v <= 0 {
+			return opts, fmt.Errorf("%s must be positive", d.name)
+		}
+		*d.into = v
+	}
+	return opts, nil
+}
+
+func (s *Settings) simulationOptions() (client.SimulationOptions, error) {
+	opts := client.SimulationOptions{
+		Namespaces:  s.SimulationNamespaces,
+		Pipelines:   s.SimulationPipelines,
+		Vertices:    s.SimulationVertices,
+		FailureRate: s.SimulationFailureRate,
+	}
+	if s.SimulationFailureRate < 0 || s.SimulationFailureRate > 1 {
+		return opts, errors.New("simulationFailureRate must be between 0 and 1")
+	}
+	for _, p := range strings.Split(s.SimulationFailingPipelines, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			opts.FailingPipelines = append(opts.FailingPipelines, p)
+		}
+	}
+	interval, err := time.ParseDuration(s.SimulationInterval)
+	if err != nil {
+		return opts, fmt.Errorf("could not parse simulationInterval: %w", err)
+	}
+	if interval <= 0 {
+		return opts, errors.New("simulationInterval must be positive")
+	}
+	opts.Interval = interval
+	return opts, nil
+}
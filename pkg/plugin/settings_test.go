@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricStorePath(t *testing.T) {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	dataPath := t.TempDir()
+	notADirectory := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(notADirectory, nil, 0o600))
+	dir := t.TempDir()
+
+	for _, tc := range []struct {
+		name      string
+		directory string
+		dataPath  string
+		want      string
+		wantErr   string
+	}{
+		{name: "directory", directory: dir, dataPath: dataPath, want: filepath.Join(dir, "datasource-3.db")},
+		{name: "grafana data path", dataPath: dataPath, want: filepath.Join(dataPath, "numaflow-datasource", "datasource-3.db")},
+		{name: "no directory", wantErr: "metricStoreDirectory must be set"},
+		{name: "plugin directory", directory: filepath.Join(filepath.Dir(exe), "data"), wantErr: "outside of the plugin's installation directory"},
+		{name: "not a directory", directory: notADirectory, wantErr: "could not be created"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GF_PATHS_DATA", tc.dataPath)
+			s := Settings{MetricStoreEnabled: true, MetricStoreDirectory: tc.directory}
+			path, err := s.metricStorePath(3)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, path)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ErrorKind classifies why a query failed.
+type ErrorKind string
+
+const (
+	// ParseError is a query that is not valid JSON or selects no resource.
+	ParseError ErrorKind = "parse"
+	// ValidationError is a well-formed query that the query type does not support.
+	ValidationError  ErrorKind = "validation"
+	NotFoundError    ErrorKind = "not_found"
+	ForbiddenError   ErrorKind = "forbidden"
+	UnavailableError ErrorKind = "unavailable"
+	TimeoutError     ErrorKind = "timeout"
+	// InternalError is any other error, including unexpected Kubernetes and daemon service errors.
+	InternalError ErrorKind = "internal"
+)
+
+// ErrorSource tells whether an error is caused by the plugin, or downstream of it, i.e. by the query, Kubernetes,
+// or Numaflow's daemon service.
+type ErrorSource string
+
+const (
+	PluginErrorSource     ErrorSource = "plugin"
+	DownstreamErrorSource ErrorSource = "downstream"
+)
+
+// Error is a classified query error, with the object it is about, e.g. `pipeline "default/simple"`.
+type Error struct {
+	Kind   ErrorKind
+	Object string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Object == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Object, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Source() ErrorSource {
+	if e.Kind == InternalError {
+		return PluginErrorSource
+	}
+	return DownstreamErrorSource
+}
+
+// Status is the status of the data response of a query that failed with the error.
+func (e *Error) Status() backend.Status {
+	switch e.Kind {
+	case ParseError, ValidationError:
+		return backend.StatusBadRequest
+	case NotFoundError:
+		return backend.StatusNotFound
+	case ForbiddenError:
+		return backend.StatusForbidden
+	case UnavailableError:
+		return backend.StatusBadGateway
+	case TimeoutError:
+		return backend.StatusTimeout
+	}
+	return backend.StatusInternal
+}
+
+// Errorf returns an error of the given kind, without an object.
+func Errorf(kind ErrorKind, format string, a ...interface{}) *Error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, a...)}
+}
+
+// ClassifyError returns err as a classified error about object. An error that is already classified keeps its kind,
+// and its object when it has one.
+func ClassifyError(err error, object string) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Object == "" {
+			return &Error{Kind: e.Kind, Object: object, Err: e.Err}
+		}
+		return e
+	}
+	kind := InternalError
+	switch client.Outcome(err) {
+	case "timeout":
+		kind = TimeoutError
+	case "not_found":
+		kind = NotFoundError
+	case "forbidden":
+		kind = ForbiddenError
+	case "unavailable":
+		kind = UnavailableError
+	}
+	return &Error{Kind: kind, Object: object, Err: err}
+}
+
+// Object describes the resources a query selects, for errors.
+func (q *RunnableQuery) Object() string {
+	kind := string(q.ResourceType)
+	if q.ResourceName == "*" {
+		kind += "s"
+	}
+	namespaced := func(name string) string {
+		if q.Namespace == nil || *q.Namespace == "" {
+			return name
+		}
+		return *q.Namespace + "/" + name
+	}
+	if q.ResourceType == VertexResourceType && q.Pipeline != nil {
+		return fmt.Sprintf("%s %q of pipeline %q", kind, q.ResourceName, namespaced(*q.Pipeline))
+	}
+	name := namespaced(q.ResourceName)
+	return fmt.Sprintf("%s %q", kind, name)
+}
@@ -0,0 +1,30 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	dfv1 "github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestClassifyError(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		kind   ErrorKind
+		source ErrorSource
+	}{
+		{apierrors.NewNotFound(dfv1.Resource("vertices"), "in"), NotFoundError, DownstreamErrorSource},
+		{fmt.Errorf("failed to get vertex, %w", apierrors.NewNotFound(dfv1.Resource("vertices"), "in")), NotFoundError, DownstreamErrorSource},
+		{apierrors.NewForbidden(dfv1.Resource("pipelines"), "pl", errors.New("denied")), ForbiddenError, DownstreamErrorSource},
+		{Errorf(ValidationError, "invalid"), ValidationError, DownstreamErrorSource},
+		{errors.New("unexpected"), InternalError, PluginErrorSource},
+	} {
+		e := ClassifyError(tc.err, `vertex "ns/pl/in"`)
+		assert.Equal(t, tc.kind, e.Kind, "kind of %v", tc.err)
+		assert.Equal(t, tc.source, e.Source(), "source of %v", tc.err)
+		assert.Equal(t, `vertex "ns/pl/in"`, e.Object)
+	}
+}
@@ -2,7 +2,6 @@ package query
 
 import (
 	"encoding/json"
-	"errors"
 )
 
 type Query struct {
@@ -19,12 +18,36 @@ const (
 	IsbsvcResourceType   ResourceType = "isbsvc"
 )
 
+type Format string
+
+const (
+	YAMLFormat Format = "yaml"
+	JSONFormat Format = "json"
+)
+
+// Window is the lookback window of vertex processing rates and pendings, as keyed in Numaflow's daemon service.
+type Window string
+
+const (
+	// DefaultWindow is the window used by Numaflow's autoscaler, i.e. the vertex's scale.lookbackSeconds.
+	DefaultWindow       Window = "default"
+	OneMinuteWindow     Window = "1m"
+	FiveMinuteWindow    Window = "5m"
+	FifteenMinuteWindow Window = "15m"
+	// AllWindows selects every window above.
+	AllWindows Window = "all"
+)
+
+func Windows() []Window {
+	return []Window{DefaultWindow, OneMinuteWindow, FiveMinuteWindow, FifteenMinuteWindow}
+}
+
 func (q *Query) Unmarshall(b []byte) error {
 	if err := json.Unmarshal(b, &q); err != nil {
-		return err
+		return Errorf(ParseError, "invalid query, %w", err)
 	}
 	if err := json.Unmarshal([]byte(q.RawQuery), &q.RunnableQuery); err != nil {
-		return err
+		return Errorf(ParseError, "invalid raw query, %w", err)
 	}
 
 	if q.RunnableQuery.Pipeline != nil {
@@ -49,8 +72,7 @@ func (q *Query) Unmarshall(b []byte) error {
 			q.RunnableQuery.ResourceType = IsbsvcResourceType
 		}
 	} else {
-		// TODO: better error reporting
-		return errors.New("cannot unmarshal json")
+		return Errorf(ParseError, `query must have a "pipeline" or an "isbsvc"`)
 	}
 
 	return nil
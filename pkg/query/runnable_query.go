@@ -1,6 +1,8 @@
 package query
 
 import (
+	"encoding/json"
+	"sort"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
@@ -8,13 +10,20 @@ import (
 
 // RunnableQuery describes what data should be returned by the backend.
 type RunnableQuery struct {
-	Namespace              *string      `json:"namespace,omitempty"`
-	Pipeline               *string      `json:"pipeline,omitempty"`
-	Vertex                 *string      `json:"vertex,omitempty"`
-	Pod                    *string      `json:"pod,omitempty"`
-	InterStepBufferService *string      `json:"isbsvc,omitempty"`
-	ResourceType           ResourceType `json:"-"`
-	ResourceName           string       `json:"-"`
+	Namespace              *string `json:"namespace,omitempty"`
+	Pipeline               *string `json:"pipeline,omitempty"`
+	Vertex                 *string `json:"vertex,omitempty"`
+	Pod                    *string `json:"pod,omitempty"`
+	InterStepBufferService *string `json:"isbsvc,omitempty"`
+	Format                 *string `json:"format,omitempty"`
+	FromGeneration         *int64  `json:"fromGeneration,omitempty"`
+	ToGeneration           *int64  `json:"toGeneration,omitempty"`
+	Metric                 *string `json:"metric,omitempty"`
+	Window                 *string `json:"window,omitempty"`
+	// NoCache bypasses the query cache, the results then replace any cached results.
+	NoCache      *bool        `json:"noCache,omitempty"`
+	ResourceType ResourceType `json:"-"`
+	ResourceName string       `json:"-"`
 }
 
 func (q *RunnableQuery) GetNamespace() string {
@@ -51,3 +60,53 @@ func (q *RunnableQuery) GetFilterPipelines() []string {
 	pl = strings.ReplaceAll(pl, "}", "")
 	return strings.Split(pl, ",")
 }
+
+func (q *RunnableQuery) GetNoCache() bool {
+	return q.NoCache != nil && *q.NoCache
+}
+
+// CacheKey identifies the results of the query, regardless of the order of multi-value filters and of NoCache.
+func (q *RunnableQuery) CacheKey() string {
+	k := *q
+	k.NoCache = nil
+	for _, v := range []**string{&k.Namespace, &k.Pipeline} {
+		if *v == nil || !strings.Contains(**v, ",") {
+			continue
+		}
+		values := strings.Split(strings.NewReplacer("{", "", "}", "").Replace(**v), ",")
+		sort.Strings(values)
+		sorted := "{" + strings.Join(values, ",") + "}"
+		*v = &sorted
+	}
+	// cannot fail, the query only has strings and numbers
+	b, _ := json.Marshal(k)
+	return string(b)
+}
+
+func (q *RunnableQuery) GetFormat() Format {
+	if q.Format == nil || *q.Format == "" {
+		return YAMLFormat
+	}
+	return Format(*q.Format)
+}
+
+func (q *RunnableQuery) GetWindow() Window {
+	if q.Window == nil || *q.Window == "" {
+		return DefaultWindow
+	}
+	return Window(*q.Window)
+}
+
+// GetWindows returns the windows selected by the query, in the order of Windows.
+func (q *RunnableQuery) GetWindows() ([]Window, error) {
+	w := q.GetWindow()
+	if w == AllWindows {
+		return Windows(), nil
+	}
+	for _, known := range Windows() {
+		if w == known {
+			return []Window{w}, nil
+		}
+	}
+	return nil, Errorf(ValidationError, "unsupported window %q, must be one of %v or %q", w, Windows(), AllWindows)
+}
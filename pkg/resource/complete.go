@@ -0,0 +1,321 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dseapy/numaflow-datasource/pkg/cache"
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"k8s.io/utils/strings/slices"
+)
+
+const (
+	CompleteAPIPath   = "/complete"
+	CompleteAPIMethod = http.MethodPost
+)
+
+type completeRequest struct {
+	QueryType string `json:"queryType"`
+	RawQuery  string `json:"rawQuery"`
+	// Cursor is the offset of the cursor in the raw query, in characters.
+	Cursor int `json:"cursor"`
+}
+
+// Suggestion completes a key or a value of a raw query. InsertText replaces the raw query from From to To of the
+// completions, and is quoted unless the cursor is within a string.
+type Suggestion struct {
+	Label      string `json:"label"`
+	InsertText string `json:"insertText"`
+	Detail     string `json:"detail,omitempty"`
+	Kind       string `json:"kind"`
+}
+
+type completions struct {
+	From        int          `json:"from"`
+	To          int          `json:"to"`
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+// cursorContext is what is being typed at the cursor of a raw query.
+type cursorContext struct {
+	role tokenRole
+	// key is the key of a value.
+	key    string
+	prefix string
+	// token is the start of the string or literal being typed, -1 when the cursor is not in one.
+	token    int
+	inString bool
+	from     int
+	to       int
+}
+
+// CompleteJson returns the keys, or the values of the key, that complete a partial query at its cursor. Namespaces,
+// pipelines, vertices, isbsvcs and pods are listed as for metric names, and cached in lookups.
+// namespace is the namespace the datasource is restricted to, if any.
+func CompleteJson(ctx context.Context, b []byte, c *client.Client, lookups *cache.Cache, namespace string) ([]byte, error) {
+	var req completeRequest
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, query.Errorf(query.ParseError, "invalid complete request, %w", err)
+	}
+	raw := []rune(req.RawQuery)
+	if req.Cursor < 0 || req.Cursor > len(raw) {
+		return nil, query.Errorf(query.ValidationError, "cursor %d is outside of the raw query", req.Cursor)
+	}
+	scan := scanRawQuery(raw)
+	cc := cursorContextAt(raw, req.Cursor)
+	comp := &completions{From: cc.from, To: cc.to, Suggestions: []Suggestion{}}
+	switch cc.role {
+	case keyRole:
+		comp.Suggestions = completeKeys(req.QueryType, scan, cc)
+	case valueRole:
+		values, err := completeValues(ctx, c, lookups, namespace, scan, cc.key)
+		if err != nil {
+			return nil, err
+		}
+		comp.Suggestions = filterValues(values, cc)
+	}
+	j, err := json.Marshal(comp)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func cursorContextAt(raw []rune, cursor int) cursorContext {
+	// the raw query up to the cursor tells whether a key or a value is being typed
+	before := scanRawQuery(raw[:cursor])
+	cc := cursorContext{token: -1, from: cursor, to: cursor}
+	if n := len(before.tokens); n > 0 && before.tokens[n-1].end == cursor {
+		t := before.tokens[n-1]
+		switch {
+		case t.kind == stringToken && !t.closed:
+			cc.role, cc.key, cc.prefix, cc.inString = t.role, t.key, t.text, true
+			cc.token, cc.from, cc.to = t.start, t.start+1, tokenEnd(raw, cursor, true)
+		case t.kind == literalToken:
+			cc.role, cc.key, cc.prefix = t.role, t.key, t.text
+			cc.token, cc.from, cc.to = t.start, t.start, tokenEnd(raw, cursor, false)
+		case t.kind == stringToken:
+			// right after a closing quote
+			return cursorContext{}
+		}
+		if cc.role != noRole {
+			return cc
+		}
+	}
+	if before.depth != 1 {
+		return cursorContext{}
+	}
+	switch before.state {
+	case expectKey:
+		cc.role = keyRole
+	case expectValue:
+		cc.role, cc.key = valueRole, before.key
+	}
+	return cc
+}
+
+// tokenEnd returns the end of the string or literal being typed at the cursor, so that completions replace all of it.
+// The end of a string is before its closing quote on the same line, or the cursor when it is not closed.
+func tokenEnd(raw []rune, cursor int, inString bool) int {
+	for i := cursor; i < len(raw); i++ {
+		switch {
+		case inString && raw[i] == '\\':
+			i++
+		case inString && raw[i] == '"':
+			return i
+		case inString && raw[i] == '\n':
+			return cursor
+		case !inString && (unicode.IsSpace(raw[i]) || strings.ContainsRune("{}[]:,\"", raw[i])):
+			return i
+		}
+	}
+	if inString {
+		return cursor
+	}
+	return len(raw)
+}
+
+// completeKeys returns the keys that are not in the query yet, and are used by its query type.
+func completeKeys(queryType string, scan rawScan, cc cursorContext) []Suggestion {
+	suggestions := []Suggestion{}
+	for _, qk := range queryKeys() {
+		if t, ok := scan.keyToken(qk.name); ok && t.start != cc.token {
+			continue
+		}
+		if qk.queryTypes != nil && queryType != "" && !slices.Contains(qk.queryTypes, queryType) {
+			continue
+		}
+		if !strings.HasPrefix(qk.name, cc.prefix) {
+			continue
+		}
+		insertText := qk.name
+		if !cc.inString {
+			insertText = quote(qk.name) + ": "
+		}
+		suggestions = append(suggestions, Suggestion{Label: qk.name, InsertText: insertText, Detail: qk.detail, Kind: "key"})
+	}
+	return suggestions
+}
+
+// completeValues returns the values of key, given the other values of the query.
+func completeValues(ctx context.Context, c *client.Client, lookups *cache.Cache, namespace string, scan rawScan, key string) ([]Suggestion, error) {
+	values := scan.values()
+	concrete := func(key string) (string, bool) {
+		t, ok := values[key]
+		if !ok || t.kind != stringToken || t.text == "" || strings.ContainsAny(t.text, "*,{}$") {
+			return "", false
+		}
+		return t.text, true
+	}
+	ns, nsOK := concrete("namespace")
+	if namespace != "" {
+		ns, nsOK = namespace, true
+	}
+	pipeline, pipelineOK := concrete("pipeline")
+	vertex, vertexOK := concrete("vertex")
+	isbsvc, isbsvcOK := concrete("isbsvc")
+	_, hasPipeline := values["pipeline"]
+
+	switch key {
+	case "namespace":
+		if namespace != "" {
+			return []Suggestion{{Label: namespace, Detail: "namespace of the datasource"}}, nil
+		}
+		resourceType := query.PipelineResourceType
+		if _, ok := values["isbsvc"]; ok && !hasPipeline {
+			resourceType = query.IsbsvcResourceType
+		}
+		names, err := lookupNames(ctx, lookups, "namespaces "+string(resourceType), func(ctx context.Context) ([]string, error) {
+			return getNamespacesContainingResource(ctx, resourceType, c)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append(nameSuggestions(names, "namespace with "+string(resourceType)+"s"),
+			Suggestion{Label: "", Detail: "all namespaces"}), nil
+	case "pipeline":
+		all := Suggestion{Label: "*", Detail: "all pipelines"}
+		if !nsOK {
+			return []Suggestion{all}, nil
+		}
+		names, err := lookupResourceNames(ctx, c, lookups, query.RunnableQuery{Namespace: &ns, ResourceType: query.PipelineResourceType})
+		if err != nil {
+			return nil, err
+		}
+		return append(nameSuggestions(names, "pipeline"), all), nil
+	case "vertex":
+		all := Suggestion{Label: "*", Detail: "all vertices"}
+		if !nsOK || !pipelineOK {
+			return []Suggestion{all}, nil
+		}
+		names, err := lookupResourceNames(ctx, c, lookups, query.RunnableQuery{Namespace: &ns, Pipeline: &pipeline, ResourceType: query.VertexResourceType})
+		if err != nil {
+			return nil, err
+		}
+		return append(nameSuggestions(names, "vertex of pipeline "+pipeline), all), nil
+	case "isbsvc":
+		all := Suggestion{Label: "*", Detail: "all isbsvcs"}
+		if !nsOK {
+			return []Suggestion{all}, nil
+		}
+		names, err := lookupResourceNames(ctx, c, lookups, query.RunnableQuery{Namespace: &ns, ResourceType: query.IsbsvcResourceType})
+		if err != nil {
+			return nil, err
+		}
+		return append(nameSuggestions(names, "isbsvc"), all), nil
+	case "pod":
+		all := Suggestion{Label: "*", Detail: "all pods"}
+		rq := query.RunnableQuery{Namespace: &ns, ResourceType: query.PodResourceType}
+		detail := ""
+		switch {
+		case nsOK && pipelineOK && vertexOK:
+			rq.Pipeline, rq.Vertex = &pipeline, &vertex
+			detail = "pod of vertex " + vertex
+		case nsOK && isbsvcOK && !hasPipeline:
+			rq.InterStepBufferService = &isbsvc
+			detail = "pod of isbsvc " + isbsvc
+		default:
+			return []Suggestion{all}, nil
+		}
+		names, err := lookupResourceNames(ctx, c, lookups, rq)
+		if err != nil {
+			return nil, err
+		}
+		return append(nameSuggestions(names, detail), all), nil
+	case "format":
+		return []Suggestion{{Label: string(query.YAMLFormat)}, {Label: string(query.JSONFormat)}}, nil
+	case "window":
+		suggestions := []Suggestion{}
+		for _, w := range query.Windows() {
+			suggestions = append(suggestions, Suggestion{Label: string(w)})
+		}
+		return append(suggestions, Suggestion{Label: string(query.AllWindows), Detail: "all windows"}), nil
+	case "metric":
+		return nameSuggestions(storeMetricNames(), "metric"), nil
+	case "noCache":
+		return []Suggestion{{Label: "true", InsertText: "true"}, {Label: "false", InsertText: "false"}}, nil
+	}
+	return []Suggestion{}, nil
+}
+
+// filterValues keeps the values starting with the prefix at the cursor, and sets how they are inserted.
+// Values that already have an insert text are literals rather than strings.
+func filterValues(values []Suggestion, cc cursorContext) []Suggestion {
+	suggestions := []Suggestion{}
+	for _, v := range values {
+		if !strings.HasPrefix(v.Label, cc.prefix) {
+			continue
+		}
+		literal := v.InsertText != ""
+		if literal && cc.inString {
+			continue
+		}
+		if !literal {
+			v.InsertText = v.Label
+			if !cc.inString {
+				v.InsertText = quote(v.Label)
+			}
+		}
+		v.Kind = "value"
+		suggestions = append(suggestions, v)
+	}
+	return suggestions
+}
+
+func nameSuggestions(names []string, detail string) []Suggestion {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	suggestions := make([]Suggestion, len(sorted))
+	for i, name := range sorted {
+		suggestions[i] = Suggestion{Label: name, Detail: detail}
+	}
+	return suggestions
+}
+
+func lookupResourceNames(ctx context.Context, c *client.Client, lookups *cache.Cache, rq query.RunnableQuery) ([]string, error) {
+	return lookupNames(ctx, lookups, string(rq.ResourceType)+" "+rq.CacheKey(), func(ctx context.Context) ([]string, error) {
+		return getResourcesInNamespace(ctx, &rq, c)
+	})
+}
+
+// lookupNames returns names listed by list, cached as the values of complete requests are looked up as they are typed.
+func lookupNames(ctx context.Context, lookups *cache.Cache, key string, list func(ctx context.Context) ([]string, error)) ([]string, error) {
+	v, err := lookups.Get(ctx, "complete", "complete "+key, false, func(ctx context.Context) (interface{}, error) {
+		return list(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]string), nil
+}
+
+func quote(s string) string {
+	// cannot fail for a string
+	b, _ := json.Marshal(s)
+	return string(b)
+}
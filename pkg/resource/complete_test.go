@@ -0,0 +1,150 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// complete returns the completions of raw at the cursor, marked by | in raw.
+func complete(t *testing.T, c *client.Client, namespace, queryType, raw string) (*completions, error) {
+	cursor := -1
+	for i, r := range []rune(raw) {
+		if r == '|' {
+			cursor = i
+			break
+		}
+	}
+	require.GreaterOrEqual(t, cursor, 0, "no cursor in %s", raw)
+	runes := []rune(raw)
+	raw = string(runes[:cursor]) + string(runes[cursor+1:])
+	b, err := json.Marshal(&completeRequest{QueryType: queryType, RawQuery: raw, Cursor: cursor})
+	require.NoError(t, err)
+	j, err := CompleteJson(context.Background(), b, c, nil, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var comp completions
+	require.NoError(t, json.Unmarshal(j, &comp))
+	return &comp, nil
+}
+
+func labels(suggestions []Suggestion) []string {
+	l := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		l[i] = s.Label
+	}
+	return l
+}
+
+func TestCompleteKeys(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		queryType string
+		raw       string
+		from, to  int
+		labels    []string
+		insert    string
+	}{
+		{name: "empty object", queryType: SpecQueryType, raw: `{|}`, from: 1, to: 1,
+			labels: []string{"namespace", "pipeline", "vertex", "isbsvc", "pod", "format", "noCache"}, insert: `"namespace": `},
+		{name: "after a value", queryType: TableQueryType, raw: `{"namespace":"ns", |}`, from: 19, to: 19,
+			labels: []string{"pipeline", "vertex", "isbsvc", "pod", "window", "noCache"}, insert: `"pipeline": `},
+		{name: "key prefix", queryType: TableQueryType, raw: `{"namespace":"ñs","pi|`, from: 19, to: 21,
+			labels: []string{"pipeline"}, insert: "pipeline"},
+		{name: "key prefix before closing quote", queryType: TableQueryType, raw: `{"namespace":"日本","p|ip": 1}`, from: 19, to: 22,
+			labels: []string{"pipeline", "pod"}, insert: "pipeline"},
+		{name: "all query types", raw: `{"f|`, from: 2, to: 3,
+			labels: []string{"format", "fromGeneration"}, insert: "format"},
+		{name: "inside a nested object", queryType: TableQueryType, raw: `{"pipeline":{|}}`, from: 13, to: 13},
+		{name: "after a closing quote", queryType: TableQueryType, raw: `{"pipeline"|`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			comp, err := complete(t, nil, "", tc.queryType, tc.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tc.labels, nonNil(labels(comp.Suggestions)))
+			if len(tc.labels) == 0 {
+				return
+			}
+			assert.Equal(t, tc.from, comp.From, "from")
+			assert.Equal(t, tc.to, comp.To, "to")
+			assert.Equal(t, tc.insert, comp.Suggestions[0].InsertText)
+			assert.Equal(t, "key", comp.Suggestions[0].Kind)
+		})
+	}
+}
+
+func nonNil(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+func TestCompleteValues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Pipelines: 2, Vertices: 3})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name      string
+		namespace string
+		raw       string
+		from, to  int
+		labels    []string
+		insert    string
+	}{
+		{name: "pipelines of the namespace", raw: `{"namespace":"ns","pipeline":|}`, from: 29, to: 29,
+			labels: []string{"clickstream", "orders", "*"}, insert: `"clickstream"`},
+		{name: "pipeline prefix in a string", raw: `{"namespace":"ns","pipeline":"o|rd"}`, from: 30, to: 33,
+			labels: []string{"orders"}, insert: "orders"},
+		{name: "vertices of the pipeline", raw: `{"namespace":"ns","pipeline":"orders","vertex":"|`, from: 48, to: 48,
+			labels: []string{"in", "map-1", "out", "*"}, insert: "in"},
+		{name: "pipelines without a namespace", raw: `{"pipeline":"|"}`, from: 13, to: 13,
+			labels: []string{"*"}, insert: "*"},
+		{name: "namespace of a restricted datasource", namespace: "ns", raw: `{"namespace":"|`, from: 14, to: 14,
+			labels: []string{"ns"}, insert: "ns"},
+		{name: "after multibyte values", raw: `{"vertex":"日本","format":"|"}`, from: 25, to: 25,
+			labels: []string{"yaml", "json"}, insert: "yaml"},
+		{name: "literal prefix", raw: `{"noCache":t|}`, from: 11, to: 12,
+			labels: []string{"true"}, insert: "true"},
+		{name: "literal in a string", raw: `{"noCache":"|"}`},
+		{name: "value of an unknown key", raw: `{"pipelines":"|"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			comp, err := complete(t, c, tc.namespace, TableQueryType, tc.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tc.labels, nonNil(labels(comp.Suggestions)))
+			if len(tc.labels) == 0 {
+				return
+			}
+			assert.Equal(t, tc.from, comp.From, "from")
+			assert.Equal(t, tc.to, comp.To, "to")
+			assert.Equal(t, tc.insert, comp.Suggestions[0].InsertText)
+			assert.Equal(t, "value", comp.Suggestions[0].Kind)
+		})
+	}
+}
+
+func TestCompleteCursorOutsideOfQuery(t *testing.T) {
+	raw := `{"pipeline":"日本"}`
+	for _, cursor := range []int{-1, utf8.RuneCountInString(raw) + 1} {
+		b, err := json.Marshal(&completeRequest{RawQuery: raw, Cursor: cursor})
+		require.NoError(t, err)
+		_, err = CompleteJson(context.Background(), b, nil, nil, "")
+		require.Error(t, err)
+		assert.Equal(t, query.ValidationError, query.ClassifyError(err, "").Kind)
+	}
+	// the end of a query with multibyte characters is inside of it
+	b, err := json.Marshal(&completeRequest{RawQuery: raw, Cursor: utf8.RuneCountInString(raw)})
+	require.NoError(t, err)
+	_, err = CompleteJson(context.Background(), b, nil, nil, "")
+	require.NoError(t, err)
+}
@@ -1,6 +1,7 @@
 package resource
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,22 +23,22 @@ type metricNames struct {
 	MetricNames []string `json:"metricNames"`
 }
 
-func MetricNamesJson(q *query.Query, c *client.Client) ([]byte, error) {
+func MetricNamesJson(ctx context.Context, q *query.Query, c *client.Client) ([]byte, error) {
 	// validate
 	if q.RunnableQuery.Namespace == nil || *q.RunnableQuery.Namespace == v1.NamespaceAll {
 		return nil, errors.New("namespace cannot be empty")
 	}
 
 	// create metric names
-	var mn *metricNames
+	mn := &metricNames{}
 	var err error
 	if q.RunnableQuery.ResourceName == "" && *q.RunnableQuery.Namespace == "*" {
-		mn, err = getNamespacesContainingResource(q, c)
+		mn.MetricNames, err = getNamespacesContainingResource(ctx, q.RunnableQuery.ResourceType, c)
 		if err != nil {
 			return nil, err
 		}
 	} else if q.RunnableQuery.ResourceName == "*" && *q.RunnableQuery.Namespace != "*" && *q.RunnableQuery.Namespace != "" {
-		mn, err = getResourcesInNamespace(q, c)
+		mn.MetricNames, err = getResourcesInNamespace(ctx, &q.RunnableQuery, c)
 		if err != nil {
 			return nil, err
 		}
@@ -53,38 +54,25 @@ func MetricNamesJson(q *query.Query, c *client.Client) ([]byte, error) {
 	return j, nil
 }
 
-func getNamespacesContainingResource(q *query.Query, c *client.Client) (*metricNames, error) {
-	mn := &metricNames{}
-	switch q.RunnableQuery.ResourceType {
+// getNamespacesContainingResource returns the namespaces with resources of the given type.
+func getNamespacesContainingResource(ctx context.Context, resourceType query.ResourceType, c *client.Client) ([]string, error) {
+	switch resourceType {
 	case query.PipelineResourceType:
-		namespacesWithPipelines, err := c.ListNamespacesWithPipelines()
-		if err != nil {
-			return nil, err
-		}
-		mn.MetricNames = namespacesWithPipelines
+		return c.ListNamespacesWithPipelines(ctx)
 	case query.VertexResourceType:
-		namespacesWithVertices, err := c.ListNamespacesWithVertices()
-		if err != nil {
-			return nil, err
-		}
-		mn.MetricNames = namespacesWithVertices
+		return c.ListNamespacesWithVertices(ctx)
 	case query.IsbsvcResourceType:
-		namespacesWithInterStepBufferServices, err := c.ListNamespacesWithInterStepBufferServices()
-		if err != nil {
-			return nil, err
-		}
-		mn.MetricNames = namespacesWithInterStepBufferServices
-	default:
-		return nil, errors.New(fmt.Sprintf("error listing namespaces, resource type unknown, %v", q.RunnableQuery.ResourceType))
+		return c.ListNamespacesWithInterStepBufferServices(ctx)
 	}
-	return mn, nil
+	return nil, errors.New(fmt.Sprintf("error listing namespaces, resource type unknown, %v", resourceType))
 }
 
-func getResourcesInNamespace(q *query.Query, c *client.Client) (*metricNames, error) {
-	mn := &metricNames{}
-	switch q.RunnableQuery.ResourceType {
+// getResourcesInNamespace returns the names of the resources of the query's type in its namespace, i.e. the pipelines
+// or isbsvcs of the namespace, the vertices of a pipeline, or the pods of a vertex or isbsvc.
+func getResourcesInNamespace(ctx context.Context, rq *query.RunnableQuery, c *client.Client) ([]string, error) {
+	switch rq.ResourceType {
 	case query.PipelineResourceType:
-		pipelinesInNamespace, err := c.ListPipelines(*q.RunnableQuery.Namespace)
+		pipelinesInNamespace, err := c.ListPipelines(ctx, *rq.Namespace)
 		if err != nil {
 			return nil, err
 		}
@@ -92,9 +80,9 @@ func getResourcesInNamespace(q *query.Query, c *client.Client) (*metricNames, er
 		for i := range pipelinesInNamespace {
 			pipelineNamesInNamespace[i] = pipelinesInNamespace[i].Name
 		}
-		mn.MetricNames = pipelineNamesInNamespace
+		return pipelineNamesInNamespace, nil
 	case query.VertexResourceType:
-		verticesInPipeline, err := c.ListPipelineVertices(*q.RunnableQuery.Namespace, *q.RunnableQuery.Pipeline)
+		verticesInPipeline, err := c.ListPipelineVertices(ctx, *rq.Namespace, *rq.Pipeline)
 		if err != nil {
 			return nil, err
 		}
@@ -102,9 +90,9 @@ func getResourcesInNamespace(q *query.Query, c *client.Client) (*metricNames, er
 		for i := range verticesInPipeline {
 			vertexNamesInPipeline[i] = verticesInPipeline[i].Labels[dfv1.KeyVertexName]
 		}
-		mn.MetricNames = vertexNamesInPipeline
+		return vertexNamesInPipeline, nil
 	case query.IsbsvcResourceType:
-		isbsvcsInNamespace, err := c.ListInterStepBufferServices(*q.RunnableQuery.Namespace)
+		isbsvcsInNamespace, err := c.ListInterStepBufferServices(ctx, *rq.Namespace)
 		if err != nil {
 			return nil, err
 		}
@@ -112,25 +100,25 @@ func getResourcesInNamespace(q *query.Query, c *client.Client) (*metricNames, er
 		for i := range isbsvcsInNamespace {
 			isbsvcNamesInNamespace[i] = isbsvcsInNamespace[i].Name
 		}
-		mn.MetricNames = isbsvcNamesInNamespace
+		return isbsvcNamesInNamespace, nil
 	case query.PodResourceType:
-		if q.RunnableQuery.Vertex != nil {
-			podsInIsbsvc, err := c.ListVertexPods(*q.RunnableQuery.Namespace, *q.RunnableQuery.Pipeline, *q.RunnableQuery.Vertex)
-			if err != nil {
-				return nil, err
-			}
-			podNamesInIsbsvc := make([]string, len(podsInIsbsvc))
-			for i := range podsInIsbsvc {
-				podNamesInIsbsvc[i] = podsInIsbsvc[i].Name
-			}
-			mn.MetricNames = podNamesInIsbsvc
-		} else if q.RunnableQuery.InterStepBufferService != nil {
-
+		var pods []v1.Pod
+		var err error
+		if rq.Vertex != nil {
+			pods, err = c.ListVertexPods(ctx, *rq.Namespace, *rq.Pipeline, *rq.Vertex)
+		} else if rq.InterStepBufferService != nil {
+			pods, err = c.ListInterStepBufferServicePods(ctx, *rq.Namespace, *rq.InterStepBufferService)
 		} else {
 			return nil, errors.New(fmt.Sprintf("vertex or isbsvc must be provided when requesting pods"))
 		}
-	default:
-		return nil, errors.New(fmt.Sprintf("error listing namespaces, resource type unknown, %v", q.RunnableQuery.ResourceType))
+		if err != nil {
+			return nil, err
+		}
+		podNames := make([]string, len(pods))
+		for i := range pods {
+			podNames[i] = pods[i].Name
+		}
+		return podNames, nil
 	}
-	return mn, nil
+	return nil, errors.New(fmt.Sprintf("error listing namespaces, resource type unknown, %v", rq.ResourceType))
 }
@@ -3,16 +3,97 @@ package resource
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"k8s.io/utils/strings/slices"
 )
 
 const (
-	TableQueryType     string = "Table"
-	NodeGraphQueryType string = "NodeGraph"
+	TableQueryType       string = "Table"
+	NodeGraphQueryType   string = "NodeGraph"
+	SpecQueryType        string = "Spec"
+	HistoryQueryType     string = "History"
+	TimeSeriesQueryType  string = "TimeSeries"
+	UtilizationQueryType string = "Utilization"
+	AutoscalingQueryType string = "Autoscaling"
+	DrainTimeQueryType   string = "DrainTime"
+	PartitionsQueryType  string = "Partitions"
+	HealthQueryType      string = "Health"
+	JetStreamQueryType   string = "JetStream"
+	RedisQueryType       string = "Redis"
+	ConditionsQueryType  string = "Conditions"
+	WorkloadsQueryType   string = "Workloads"
+	PodsQueryType        string = "Pods"
+	LogsQueryType        string = "Logs"
+	EventsQueryType      string = "Events"
 
 	QueryTypesAPIPath   = "/query-types"
 	QueryTypesAPIMethod = http.MethodGet
 )
 
+// queryTypeResources are the resource types each query type supports, checked by CheckResourceType.
+var queryTypeResources = map[string][]query.ResourceType{
+	TableQueryType:       {query.PipelineResourceType, query.VertexResourceType, query.IsbsvcResourceType},
+	NodeGraphQueryType:   {query.PipelineResourceType},
+	SpecQueryType:        {query.PipelineResourceType, query.VertexResourceType, query.IsbsvcResourceType},
+	HistoryQueryType:     {query.PipelineResourceType, query.VertexResourceType},
+	TimeSeriesQueryType:  {query.PipelineResourceType, query.VertexResourceType},
+	UtilizationQueryType: {query.VertexResourceType},
+	AutoscalingQueryType: {query.VertexResourceType},
+	DrainTimeQueryType:   {query.PipelineResourceType, query.VertexResourceType},
+	PartitionsQueryType:  {query.VertexResourceType},
+	HealthQueryType:      {query.PipelineResourceType},
+	JetStreamQueryType:   {query.PipelineResourceType},
+	RedisQueryType:       {query.PipelineResourceType},
+	ConditionsQueryType:  {query.PipelineResourceType, query.VertexResourceType, query.IsbsvcResourceType},
+	WorkloadsQueryType:   {query.PipelineResourceType, query.IsbsvcResourceType},
+	PodsQueryType:        {query.PipelineResourceType, query.VertexResourceType, query.IsbsvcResourceType, query.PodResourceType},
+	LogsQueryType:        {query.PipelineResourceType, query.VertexResourceType, query.IsbsvcResourceType, query.PodResourceType},
+	EventsQueryType:      {query.PipelineResourceType, query.VertexResourceType, query.IsbsvcResourceType, query.PodResourceType},
+}
+
+// singleResourceQueryTypes are the query types of a single resource, rather than of all resources matching a filter.
+var singleResourceQueryTypes = []string{NodeGraphQueryType, SpecQueryType}
+
+// CheckResourceType returns a validation error when a query type does not support the resource type of a query, or
+// is of a single resource and the query selects several. Both raw query validation and the scenarios running queries
+// check it, so that they agree. Unknown query types are left to the caller.
+func CheckResourceType(queryType string, rq query.RunnableQuery) *query.Error {
+	supported, ok := queryTypeResources[queryType]
+	if !ok {
+		return nil
+	}
+	if !containsResourceType(supported, rq.ResourceType) {
+		return query.Errorf(query.ValidationError, "%s queries only support %s", queryType, strings.Join(resourceTypeNames(supported), ", "))
+	}
+	if slices.Contains(singleResourceQueryTypes, queryType) && (rq.ResourceName == "*" || strings.Contains(rq.ResourceName, ",")) {
+		return query.Errorf(query.ValidationError, "%s queries only support a single %s", queryType, rq.ResourceType)
+	}
+	return nil
+}
+
+func containsResourceType(resourceTypes []query.ResourceType, resourceType query.ResourceType) bool {
+	for _, t := range resourceTypes {
+		if t == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceTypeNames(resourceTypes []query.ResourceType) []string {
+	names := make([]string, len(resourceTypes))
+	for i, t := range resourceTypes {
+		if t == query.VertexResourceType {
+			names[i] = "vertices"
+		} else {
+			names[i] = string(t) + "s"
+		}
+	}
+	return names
+}
+
 type queryTypes struct {
 	QueryTypes []string `json:"queryTypes"`
 }
@@ -21,6 +102,21 @@ func QueryTypes() []string {
 	return []string{
 		TableQueryType,
 		NodeGraphQueryType,
+		SpecQueryType,
+		HistoryQueryType,
+		TimeSeriesQueryType,
+		UtilizationQueryType,
+		AutoscalingQueryType,
+		DrainTimeQueryType,
+		PartitionsQueryType,
+		HealthQueryType,
+		JetStreamQueryType,
+		RedisQueryType,
+		ConditionsQueryType,
+		WorkloadsQueryType,
+		PodsQueryType,
+		LogsQueryType,
+		EventsQueryType,
 	}
 }
 
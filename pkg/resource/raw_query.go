@@ -0,0 +1,214 @@
+package resource
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// queryKey is a key of raw queries, i.e. a field of query.RunnableQuery.
+type queryKey struct {
+	name   string
+	kind   string
+	detail string
+	// queryTypes are the query types that use the key, nil when all do.
+	queryTypes []string
+}
+
+const (
+	stringKey  = "string"
+	integerKey = "integer"
+	booleanKey = "boolean"
+)
+
+func queryKeys() []queryKey {
+	return []queryKey{
+		{name: "namespace", kind: stringKey, detail: "namespace, {a,b} for several, or empty for all namespaces"},
+		{name: "pipeline", kind: stringKey, detail: "pipeline name, {a,b} for several, or * for all"},
+		{name: "vertex", kind: stringKey, detail: "vertex name of the pipeline, or * for all"},
+		{name: "isbsvc", kind: stringKey, detail: "isbsvc name, or * for all"},
+		{name: "pod", kind: stringKey, detail: "pod name of the vertex or isbsvc, or * for all"},
+		{name: "format", kind: stringKey, detail: "format of the spec, yaml or json", queryTypes: []string{SpecQueryType}},
+		{name: "fromGeneration", kind: integerKey, detail: "generation to diff the spec from", queryTypes: []string{HistoryQueryType}},
+		{name: "toGeneration", kind: integerKey, detail: "generation to diff the spec to", queryTypes: []string{HistoryQueryType}},
+		{name: "metric", kind: stringKey, detail: "metric of the metric store", queryTypes: []string{TimeSeriesQueryType}},
+		{name: "window", kind: stringKey, detail: "lookback window of processing rates and pendings",
+			queryTypes: []string{TableQueryType, NodeGraphQueryType, DrainTimeQueryType, PartitionsQueryType}},
+		{name: "noCache", kind: booleanKey, detail: "bypass the query cache"},
+	}
+}
+
+func lookupQueryKey(name string) (queryKey, bool) {
+	for _, k := range queryKeys() {
+		if k.name == name {
+			return k, true
+		}
+	}
+	return queryKey{}, false
+}
+
+type tokenKind int
+
+const (
+	stringToken tokenKind = iota
+	literalToken
+	punctuationToken
+)
+
+type tokenRole int
+
+const (
+	noRole tokenRole = iota
+	keyRole
+	valueRole
+)
+
+// rawToken is a token of a raw query. Offsets are in characters, i.e. Unicode code points, end is exclusive.
+type rawToken struct {
+	kind  tokenKind
+	text  string
+	start int
+	end   int
+	// closed is false for a string missing its closing quote.
+	closed bool
+	role   tokenRole
+	// key is the key of a value.
+	key string
+}
+
+// rawScan is the result of scanning a raw query, which may be partial or invalid JSON as typed in the query editor.
+type rawScan struct {
+	tokens []rawToken
+	// state is the state after the last token, when in the top level object.
+	state scanState
+	depth int
+	key   string
+}
+
+type scanState int
+
+const (
+	expectKey scanState = iota
+	expectColon
+	expectValue
+	expectComma
+)
+
+// scanRawQuery tokenizes a raw query and tells the keys and values of its top level object apart, tolerating
+// anything that is not valid JSON.
+func scanRawQuery(raw []rune) rawScan {
+	s := rawScan{}
+	for i := 0; i < len(raw); {
+		r := raw[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '"':
+			t := rawToken{kind: stringToken, start: i}
+			j := i + 1
+			for ; j < len(raw); j++ {
+				if raw[j] == '\\' {
+					j++
+					continue
+				}
+				if raw[j] == '"' {
+					t.closed = true
+					break
+				}
+			}
+			if t.closed {
+				t.end = j + 1
+			} else {
+				t.end = len(raw)
+			}
+			t.text = unquote(raw[t.start:t.end], t.closed)
+			s.add(t)
+			i = t.end
+		case strings.ContainsRune("{}[]:,", r):
+			s.add(rawToken{kind: punctuationToken, text: string(r), start: i, end: i + 1, closed: true})
+			i++
+		default:
+			j := i
+			for j < len(raw) && !unicode.IsSpace(raw[j]) && !strings.ContainsRune("{}[]:,\"", raw[j]) {
+				j++
+			}
+			s.add(rawToken{kind: literalToken, text: string(raw[i:j]), start: i, end: j, closed: true})
+			i = j
+		}
+	}
+	return s
+}
+
+func (s *rawScan) add(t rawToken) {
+	if t.kind == punctuationToken {
+		switch t.text {
+		case "{", "[":
+			if s.depth == 1 && s.state == expectValue {
+				s.state = expectComma
+			}
+			s.depth++
+			if s.depth == 1 {
+				s.state = expectKey
+			}
+		case "}", "]":
+			s.depth--
+		case ":":
+			if s.depth == 1 && s.state == expectColon {
+				s.state = expectValue
+			}
+		case ",":
+			if s.depth == 1 {
+				s.state = expectKey
+			}
+		}
+	} else if s.depth == 1 {
+		switch s.state {
+		case expectKey:
+			t.role = keyRole
+			s.key = t.text
+			s.state = expectColon
+		case expectValue:
+			t.role = valueRole
+			t.key = s.key
+			s.state = expectComma
+		}
+	}
+	s.tokens = append(s.tokens, t)
+}
+
+// values returns the value token of each key, the last one when a key is repeated.
+func (s *rawScan) values() map[string]rawToken {
+	values := make(map[string]rawToken)
+	for _, t := range s.tokens {
+		if t.role == valueRole {
+			values[t.key] = t
+		}
+	}
+	return values
+}
+
+// keyToken returns the token of a key, the last one when a key is repeated.
+func (s *rawScan) keyToken(key string) (rawToken, bool) {
+	var found rawToken
+	ok := false
+	for _, t := range s.tokens {
+		if t.role == keyRole && t.text == key {
+			found, ok = t, true
+		}
+	}
+	return found, ok
+}
+
+func unquote(quoted []rune, closed bool) string {
+	q := string(quoted)
+	if !closed {
+		q += `"`
+	}
+	var s string
+	if err := json.Unmarshal([]byte(q), &s); err != nil {
+		// an invalid or partial escape sequence, keep it as is
+		return strings.TrimSuffix(strings.TrimPrefix(q, `"`), `"`)
+	}
+	return s
+}
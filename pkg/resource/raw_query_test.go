@@ -0,0 +1,64 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanRawQueryOffsetsAreInCharacters(t *testing.T) {
+	raw := []rune(`{"namespace": "ñs", "pipeline":"日本語", "window":5m}`)
+	scan := scanRawQuery(raw)
+	values := scan.values()
+
+	for key, want := range map[string]struct {
+		text       string
+		start, end int
+	}{
+		"namespace": {"ñs", 14, 18},
+		"pipeline":  {"日本語", 31, 36},
+		"window":    {"5m", 47, 49},
+	} {
+		v, ok := values[key]
+		require.True(t, ok, key)
+		assert.Equal(t, want.text, v.text, key)
+		assert.Equal(t, want.start, v.start, "start of %s", key)
+		assert.Equal(t, want.end, v.end, "end of %s", key)
+	}
+	k, ok := scan.keyToken("pipeline")
+	require.True(t, ok)
+	assert.Equal(t, 20, k.start)
+	assert.Equal(t, 30, k.end)
+	assert.Equal(t, 0, scan.depth)
+}
+
+func TestScanRawQueryToleratesPartialQueries(t *testing.T) {
+	for _, tc := range []struct {
+		raw   string
+		state scanState
+		depth int
+		key   string
+	}{
+		{raw: `{`, state: expectKey, depth: 1},
+		{raw: `{"pipeline"`, state: expectColon, depth: 1, key: "pipeline"},
+		{raw: `{"pipeline":`, state: expectValue, depth: 1, key: "pipeline"},
+		{raw: `{"pipeline":"a`, state: expectComma, depth: 1, key: "pipeline"},
+		{raw: `{"pipeline":"a",`, state: expectKey, depth: 1, key: "pipeline"},
+		// keys of nested objects are not keys of the query
+		{raw: `{"pipeline":{"vertex":`, state: expectComma, depth: 2, key: "pipeline"},
+		{raw: `{"pipeline":{"vertex":1},`, state: expectKey, depth: 1, key: "pipeline"},
+	} {
+		scan := scanRawQuery([]rune(tc.raw))
+		assert.Equal(t, tc.state, scan.state, "state of %s", tc.raw)
+		assert.Equal(t, tc.depth, scan.depth, "depth of %s", tc.raw)
+		assert.Equal(t, tc.key, scan.key, "key of %s", tc.raw)
+	}
+
+	// a string missing its closing quote ends the query
+	scan := scanRawQuery([]rune(`{"pipeline":"a\"b`))
+	v := scan.values()["pipeline"]
+	assert.False(t, v.closed)
+	assert.Equal(t, `a"b`, v.text)
+	assert.Equal(t, 17, v.end)
+}
@@ -0,0 +1,227 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"k8s.io/utils/strings/slices"
+)
+
+const (
+	ValidateAPIPath   = "/validate"
+	ValidateAPIMethod = http.MethodPost
+)
+
+type Severity string
+
+const (
+	ErrorSeverity   Severity = "error"
+	WarningSeverity Severity = "warning"
+)
+
+type validateRequest struct {
+	QueryType string `json:"queryType"`
+	RawQuery  string `json:"rawQuery"`
+}
+
+// Diagnostic is a problem of a raw query, tied to the key that caused it, or to no key when it is about the whole query.
+// From and To are the offsets of the key's value in the raw query, or of the key when it has no value or is unknown, or
+// of the invalid character of invalid JSON, in characters.
+type Diagnostic struct {
+	Key      string          `json:"key"`
+	Severity Severity        `json:"severity"`
+	Kind     query.ErrorKind `json:"kind"`
+	Message  string          `json:"message"`
+	From     *int            `json:"from,omitempty"`
+	To       *int            `json:"to,omitempty"`
+}
+
+type diagnostics struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// ValidateJson returns the diagnostics of a query, without running it. namespace is the namespace the datasource is
+// restricted to, if any.
+func ValidateJson(b []byte, namespace string) ([]byte, error) {
+	var req validateRequest
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, query.Errorf(query.ParseError, "invalid validate request, %w", err)
+	}
+	j, err := json.Marshal(&diagnostics{Diagnostics: validate(req, namespace)})
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func validate(req validateRequest, namespace string) []Diagnostic {
+	raw := []rune(req.RawQuery)
+	scan := scanRawQuery(raw)
+	d := []Diagnostic{}
+	diagnose := func(key string, t *rawToken, severity Severity, kind query.ErrorKind, format string, a ...interface{}) {
+		diagnostic := Diagnostic{Key: key, Severity: severity, Kind: kind, Message: fmt.Sprintf(format, a...)}
+		if t != nil {
+			diagnostic.From, diagnostic.To = &t.start, &t.end
+		}
+		d = append(d, diagnostic)
+	}
+	// report reports a problem with the value of key, at the value, or at the key when it has none
+	report := func(key string, severity Severity, kind query.ErrorKind, format string, a ...interface{}) {
+		if t, ok := scan.values()[key]; ok {
+			diagnose(key, &t, severity, kind, format, a...)
+		} else if t, ok := scan.keyToken(key); ok {
+			diagnose(key, &t, severity, kind, format, a...)
+		} else {
+			diagnose(key, nil, severity, kind, format, a...)
+		}
+	}
+
+	queryTypeKnown := slices.Contains(QueryTypes(), req.QueryType)
+	if req.QueryType == "" {
+		report("queryType", ErrorSeverity, query.ValidationError, "query type must be selected")
+	} else if !queryTypeKnown {
+		report("queryType", ErrorSeverity, query.ValidationError, "unsupported query type %q, must be one of %v", req.QueryType, QueryTypes())
+	}
+
+	// syntax, in the top level object
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(req.RawQuery), &values); err != nil {
+		diagnostic := Diagnostic{Severity: ErrorSeverity, Kind: query.ParseError, Message: fmt.Sprintf("invalid raw query, %v", err)}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// the offset is after the byte the decoder failed at, i.e. the first byte of an invalid character, or the
+			// end of the raw query when it ends early
+			to := utf8.RuneCountInString(req.RawQuery[:syntaxErr.Offset])
+			from := to
+			if strings.HasPrefix(syntaxErr.Error(), "invalid character") {
+				from--
+			}
+			diagnostic.From, diagnostic.To = &from, &to
+		}
+		return append(d, diagnostic)
+	}
+
+	// keys, by the order of query keys and then unknown keys by name
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	typeErrors := false
+	for _, qk := range queryKeys() {
+		v, ok := values[qk.name]
+		if !ok {
+			continue
+		}
+		if err := checkKind(qk, v); err != nil {
+			report(qk.name, ErrorSeverity, query.ParseError, "%v", err)
+			typeErrors = true
+			continue
+		}
+		if queryTypeKnown && qk.queryTypes != nil && !slices.Contains(qk.queryTypes, req.QueryType) {
+			report(qk.name, WarningSeverity, query.ValidationError, "%q is only used by %s queries", qk.name, strings.Join(qk.queryTypes, ", "))
+		}
+	}
+	for _, k := range keys {
+		if _, ok := lookupQueryKey(k); !ok {
+			var at *rawToken
+			if t, ok := scan.keyToken(k); ok {
+				at = &t
+			}
+			diagnose(k, at, WarningSeverity, query.ValidationError, "unknown key %q is ignored", k)
+		}
+	}
+	if typeErrors {
+		return d
+	}
+
+	// the query, as run
+	q := query.Query{RawQuery: req.RawQuery}
+	b, _ := json.Marshal(&q)
+	if err := q.Unmarshall(b); err != nil {
+		report("", ErrorSeverity, query.ClassifyError(err, "").Kind, "%v", err)
+		return d
+	}
+	rq := q.RunnableQuery
+	if rq.Pipeline != nil && rq.InterStepBufferService != nil {
+		report("isbsvc", WarningSeverity, query.ValidationError, `"isbsvc" is ignored when a "pipeline" is given`)
+	}
+	if rq.Vertex != nil && rq.Pipeline == nil {
+		report("vertex", WarningSeverity, query.ValidationError, `"vertex" is ignored without a "pipeline"`)
+	}
+	if rq.Pod != nil && rq.Vertex == nil && (rq.Pipeline != nil || rq.InterStepBufferService == nil) {
+		report("pod", WarningSeverity, query.ValidationError, `"pod" is ignored without a "vertex" or an "isbsvc"`)
+	}
+	if namespace != "" {
+		if rq.Namespace != nil {
+			report("namespace", WarningSeverity, query.ValidationError, "%q is ignored, the datasource is restricted to namespace %q", "namespace", namespace)
+		}
+	} else if (rq.Namespace == nil || *rq.Namespace == "") && rq.ResourceName != "*" {
+		report("namespace", ErrorSeverity, query.ValidationError, `"namespace" must be provided when requesting a single pipeline, vertex, or isbsvc by name`)
+	}
+	if rq.Format != nil && rq.GetFormat() != query.YAMLFormat && rq.GetFormat() != query.JSONFormat {
+		report("format", ErrorSeverity, query.ValidationError, "unsupported format %q, must be %q or %q", *rq.Format, query.YAMLFormat, query.JSONFormat)
+	}
+	if _, err := rq.GetWindows(); err != nil {
+		report("window", ErrorSeverity, query.ValidationError, "%v", err)
+	}
+	if rq.Metric != nil && !slices.Contains(storeMetricNames(), *rq.Metric) {
+		report("metric", ErrorSeverity, query.ValidationError, "unknown metric %q, must be one of %v", *rq.Metric, storeMetricNames())
+	}
+	if rq.FromGeneration != nil && rq.ToGeneration != nil && *rq.FromGeneration > *rq.ToGeneration {
+		report("fromGeneration", WarningSeverity, query.ValidationError, `"fromGeneration" is after "toGeneration"`)
+	}
+	if !queryTypeKnown {
+		return d
+	}
+	if err := CheckResourceType(req.QueryType, rq); err != nil {
+		report(resourceTypeKey(rq.ResourceType), ErrorSeverity, query.ValidationError, "%v", err)
+	} else if slices.Contains(singleResourceQueryTypes, req.QueryType) && rq.Namespace != nil && strings.Contains(*rq.Namespace, ",") && namespace == "" {
+		report("namespace", ErrorSeverity, query.ValidationError, "%s queries only support a single namespace", req.QueryType)
+	}
+	return d
+}
+
+// checkKind returns an error when the value of a key is not of the key's kind.
+func checkKind(qk queryKey, v json.RawMessage) error {
+	var err error
+	switch qk.kind {
+	case stringKey:
+		var s string
+		err = json.Unmarshal(v, &s)
+	case integerKey:
+		var i int64
+		err = json.Unmarshal(v, &i)
+	case booleanKey:
+		var b bool
+		err = json.Unmarshal(v, &b)
+	}
+	if err != nil {
+		return fmt.Errorf("%q must be a %s", qk.name, qk.kind)
+	}
+	return nil
+}
+
+// resourceTypeKey is the key selecting resources of a type.
+func resourceTypeKey(resourceType query.ResourceType) string {
+	if resourceType == query.IsbsvcResourceType {
+		return "isbsvc"
+	}
+	return string(resourceType)
+}
+
+func storeMetricNames() []string {
+	metrics := metricstore.Metrics()
+	names := make([]string, len(metrics))
+	for i, m := range metrics {
+		names[i] = string(m)
+	}
+	return names
+}
@@ -0,0 +1,120 @@
+package resource
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// span returns the offsets, in characters, of the first occurrence of s in raw.
+func span(t *testing.T, raw, s string) (int, int) {
+	i := []rune(raw)
+	for start := 0; start+utf8.RuneCountInString(s) <= len(i); start++ {
+		if string(i[start:start+utf8.RuneCountInString(s)]) == s {
+			return start, start + utf8.RuneCountInString(s)
+		}
+	}
+	t.Fatalf("%q not found in %q", s, raw)
+	return 0, 0
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		queryType string
+		raw       string
+		namespace string
+		key       string
+		severity  Severity
+		kind      query.ErrorKind
+		// span is the text the diagnostic points at
+		span string
+	}{
+		{name: "unknown key after multibyte values", queryType: TableQueryType, raw: `{"namespace":"ñs","pipeline":"日本","pipelines":"*"}`,
+			key: "pipelines", severity: WarningSeverity, kind: query.ValidationError, span: `"pipelines"`},
+		{name: "wrong value kind", queryType: HistoryQueryType, raw: `{"namespace":"ñs","pipeline":"日本","fromGeneration":"3"}`,
+			key: "fromGeneration", severity: ErrorSeverity, kind: query.ParseError, span: `"3"`},
+		{name: "wrong boolean kind", queryType: TableQueryType, raw: `{"namespace":"ns","pipeline":"*","noCache":1}`,
+			key: "noCache", severity: ErrorSeverity, kind: query.ParseError, span: `1`},
+		{name: "key of another query type", queryType: TableQueryType, raw: `{"namespace":"ns","pipeline":"*","format":"yaml"}`,
+			key: "format", severity: WarningSeverity, kind: query.ValidationError, span: `"yaml"`},
+		{name: "unsupported resource type", queryType: UtilizationQueryType, raw: `{"namespace":"ns","pipeline":"日本"}`,
+			key: "pipeline", severity: ErrorSeverity, kind: query.ValidationError, span: `"日本"`},
+		{name: "several resources of a single resource query type", queryType: NodeGraphQueryType, raw: `{"namespace":"ns","pipeline":"*"}`,
+			key: "pipeline", severity: ErrorSeverity, kind: query.ValidationError, span: `"*"`},
+		{name: "several namespaces of a single resource query type", queryType: SpecQueryType, raw: `{"namespace":"{a,b}","pipeline":"pl"}`,
+			key: "namespace", severity: ErrorSeverity, kind: query.ValidationError, span: `"{a,b}"`},
+		{name: "missing namespace", queryType: TableQueryType, raw: `{"pipeline":"pl"}`,
+			key: "namespace", severity: ErrorSeverity, kind: query.ValidationError},
+		{name: "namespace of a restricted datasource", queryType: TableQueryType, raw: `{"namespace":"ñs","pipeline":"pl"}`, namespace: "ns",
+			key: "namespace", severity: WarningSeverity, kind: query.ValidationError, span: `"ñs"`},
+		{name: "unknown query type", queryType: "Tables", raw: `{"namespace":"ns","pipeline":"pl"}`,
+			key: "queryType", severity: ErrorSeverity, kind: query.ValidationError},
+		{name: "unknown metric", queryType: TimeSeriesQueryType, raw: `{"namespace":"ns","pipeline":"pl","vertex":"*","metric":"rate"}`,
+			key: "metric", severity: ErrorSeverity, kind: query.ValidationError, span: `"rate"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := validate(validateRequest{QueryType: tc.queryType, RawQuery: tc.raw}, tc.namespace)
+			require.Len(t, d, 1, "%+v", d)
+			assert.Equal(t, tc.key, d[0].Key)
+			assert.Equal(t, tc.severity, d[0].Severity)
+			assert.Equal(t, tc.kind, d[0].Kind)
+			if tc.span == "" {
+				assert.Nil(t, d[0].From)
+				return
+			}
+			from, to := span(t, tc.raw, tc.span)
+			require.NotNil(t, d[0].From)
+			require.NotNil(t, d[0].To)
+			assert.Equal(t, from, *d[0].From, "from")
+			assert.Equal(t, to, *d[0].To, "to")
+		})
+	}
+}
+
+func TestValidateValidQueries(t *testing.T) {
+	for queryType, raw := range map[string]string{
+		TableQueryType:       `{"namespace":"ñs","pipeline":"日本","window":"5m"}`,
+		NodeGraphQueryType:   `{"namespace":"ns","pipeline":"pl"}`,
+		SpecQueryType:        `{"namespace":"ns","isbsvc":"default","format":"json"}`,
+		HistoryQueryType:     `{"namespace":"ns","pipeline":"pl","fromGeneration":1,"toGeneration":2}`,
+		UtilizationQueryType: `{"namespace":"ns","pipeline":"pl","vertex":"*","noCache":true}`,
+		LogsQueryType:        `{"namespace":"ns","pipeline":"pl","vertex":"in","pod":"*"}`,
+	} {
+		assert.Empty(t, validate(validateRequest{QueryType: queryType, RawQuery: raw}, ""), "%s %s", queryType, raw)
+	}
+}
+
+func TestValidateSyntaxErrorOffsetIsInCharacters(t *testing.T) {
+	for _, tc := range []struct {
+		raw      string
+		from, to int
+	}{
+		// the invalid character
+		{raw: `{"pipeline":"日本",}`, from: 17, to: 18},
+		{raw: `{"pipeline":"ñ" "vertex":"*"}`, from: 16, to: 17},
+		{raw: `{"pipeline":日本}`, from: 12, to: 13},
+		// the end of a query that ends early
+		{raw: `{"pipeline":"日本"`, from: 16, to: 16},
+	} {
+		d := validate(validateRequest{QueryType: TableQueryType, RawQuery: tc.raw}, "")
+		require.Len(t, d, 1, "%s: %+v", tc.raw, d)
+		assert.Equal(t, query.ParseError, d[0].Kind, tc.raw)
+		require.NotNil(t, d[0].From, tc.raw)
+		assert.Equal(t, tc.from, *d[0].From, tc.raw)
+		assert.Equal(t, tc.to, *d[0].To, tc.raw)
+	}
+}
+
+func TestCheckResourceTypeCoversQueryTypes(t *testing.T) {
+	for _, queryType := range QueryTypes() {
+		assert.NotEmpty(t, queryTypeResources[queryType], "resource types of %s", queryType)
+	}
+	assert.NotNil(t, CheckResourceType(PartitionsQueryType, query.RunnableQuery{ResourceType: query.PipelineResourceType, ResourceName: "pl"}))
+	assert.NotNil(t, CheckResourceType(SpecQueryType, query.RunnableQuery{ResourceType: query.PipelineResourceType, ResourceName: "a,b"}))
+	assert.Nil(t, CheckResourceType(SpecQueryType, query.RunnableQuery{ResourceType: query.IsbsvcResourceType, ResourceName: "default"}))
+	assert.Nil(t, CheckResourceType("Unknown", query.RunnableQuery{ResourceType: query.PipelineResourceType, ResourceName: "pl"}))
+}
@@ -0,0 +1,306 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/numaproj/numaflow/pkg/apis/proto/daemon"
+	"k8s.io/utils/pointer"
+)
+
+// backPressureThreshold is the default pending to buffer length ratio above which Numaflow's autoscaler sees back pressure.
+const backPressureThreshold = 0.9
+
+// scalingInsight is what Numaflow's autoscaler would do with a vertex, see "pkg/reconciler/vertex/scaling/scaling.go".
+type scalingInsight struct {
+	rate        *float64
+	pending     *int64
+	recommended *int32 // desired replicas clamped to min and max
+	next        *int32 // replicas the autoscaler would patch in the next round
+	status      string
+}
+
+func newAutoscalingFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	vertices, err := listQueryVertices(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	type pipelineKey struct{ namespace, name string }
+	pipelines := make(map[pipelineKey]*v1alpha1.Pipeline)
+	buffers := make(map[pipelineKey]map[string]*daemon.BufferInfo)
+
+	namespaces := make([]string, len(vertices))
+	pipelineNames := make([]string, len(vertices))
+	names := make([]string, len(vertices))
+	scalable := make([]bool, len(vertices))
+	disabled := make([]bool, len(vertices))
+	minReplicas := make([]int32, len(vertices))
+	maxReplicas := make([]int32, len(vertices))
+	targetProcessingSeconds := make([]int64, len(vertices))
+	targetBufferUsage := make([]int64, len(vertices))
+	cooldownSeconds := make([]int64, len(vertices))
+	replicasPerScale := make([]int64, len(vertices))
+	lastScaledAt := make([]*time.Time, len(vertices))
+	replicas := make([]uint32, len(vertices))
+	desiredReplicas := make([]int64, len(vertices))
+	processingRate := make([]*float64, len(vertices))
+	pendingMessages := make([]*int64, len(vertices))
+	recommendedReplicas := make([]*int32, len(vertices))
+	nextReplicas := make([]*int32, len(vertices))
+	statuses := make([]string, len(vertices))
+	for i := range vertices {
+		v := vertices[i]
+		pk := pipelineKey{namespace: v.Namespace, name: v.Spec.PipelineName}
+		if _, ok := pipelines[pk]; !ok {
+			pl, err := nfClient.GetPipeline(ctx, pk.namespace, pk.name)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve pipeline for vertex", "namespace", pk.namespace, "pipeline", pk.name, "err", err)
+			}
+			pipelines[pk] = pl
+			buffers[pk] = make(map[string]*daemon.BufferInfo)
+			edges, err := nfClient.ListPipelineEdges(ctx, pk.namespace, pk.name)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve edges for pipeline", "namespace", pk.namespace, "pipeline", pk.name, "err", err)
+			}
+			for _, e := range edges {
+				if e.BufferName != nil {
+					buffers[pk][*e.BufferName] = e
+				}
+			}
+		}
+		var vMetrics *daemon.VertexMetrics
+		if m, err := nfClient.GetVertexMetrics(ctx, v.Namespace, v.Spec.PipelineName, v.Spec.Name); err != nil {
+			partialFailure(ctx, "failed to retrieve metrics for vertex", "namespace", v.Namespace, "pipeline", v.Spec.PipelineName, "vertex", v.Spec.Name, "err", err)
+		} else {
+			vMetrics = m
+		}
+		insight := newScalingInsight(&v, pipelines[pk], vMetrics, buffers[pk])
+
+		namespaces[i] = v.Namespace
+		pipelineNames[i] = v.Spec.PipelineName
+		names[i] = v.Spec.Name
+		scalable[i] = v.Scalable()
+		disabled[i] = v.Spec.Scale.Disabled
+		minReplicas[i] = v.Spec.Scale.GetMinReplicas()
+		maxReplicas[i] = v.Spec.Scale.GetMaxReplicas()
+		targetProcessingSeconds[i] = int64(v.Spec.Scale.GetTargetProcessingSeconds())
+		targetBufferUsage[i] = int64(v.Spec.Scale.GetTargetBufferUsage())
+		cooldownSeconds[i] = int64(v.Spec.Scale.GetCooldownSeconds())
+		replicasPerScale[i] = int64(v.Spec.Scale.GetReplicasPerScale())
+		if !v.Status.LastScaledAt.IsZero() {
+			t := v.Status.LastScaledAt.Time
+			lastScaledAt[i] = &t
+		}
+		replicas[i] = v.Status.Replicas
+		desiredReplicas[i] = int64(v.GetReplicas())
+		processingRate[i] = insight.rate
+		pendingMessages[i] = insight.pending
+		recommendedReplicas[i] = insight.recommended
+		nextReplicas[i] = insight.next
+		statuses[i] = insight.status
+	}
+
+	fields := []*data.Field{
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, pipelineNames),
+		data.NewField("name", nil, names),
+		data.NewField("scalable", nil, scalable),
+		data.NewField("disabled", nil, disabled),
+		data.NewField("min", nil, minReplicas),
+		data.NewField("max", nil, maxReplicas),
+		data.NewField("target processing seconds", nil, targetProcessingSeconds).SetConfig(unitConfig(secondsUnit)),
+		data.NewField("target buffer usage", nil, targetBufferUsage).SetConfig(unitConfig(percentUnit)),
+		data.NewField("cooldown seconds", nil, cooldownSeconds).SetConfig(unitConfig(secondsUnit)),
+		data.NewField("replicas per scale", nil, replicasPerScale),
+		data.NewField("last scaled at", nil, lastScaledAt),
+		data.NewField("replicas", nil, replicas),
+		data.NewField("desired replicas", nil, desiredReplicas),
+		data.NewField("processing rate", nil, processingRate).SetConfig(unitConfig(rateUnit)),
+		data.NewField("pending messages", nil, pendingMessages).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("recommended replicas", nil, recommendedReplicas),
+		data.NewField("next replicas", nil, nextReplicas),
+		data.NewField("status", nil, statuses),
+	}
+	return data.Frames{data.NewFrame("autoscaling", fields...)}, nil
+}
+
+// newScalingInsight follows the checks of Numaflow's autoscaler in order and reports the first one that stops it from scaling.
+func newScalingInsight(v *v1alpha1.Vertex, pl *v1alpha1.Pipeline, vMetrics *daemon.VertexMetrics, buffers map[string]*daemon.BufferInfo) scalingInsight {
+	insight := scalingInsight{}
+	if vMetrics != nil {
+		// the autoscaler always uses the default window, regardless of the query's window
+		insight.rate, insight.pending = defaultRateAndPending(vMetrics)
+	}
+
+	if v.Spec.Scale.Disabled {
+		insight.status = "autoscaling disabled"
+		return insight
+	}
+	if !v.Scalable() {
+		insight.status = "not scalable, only map UDFs, sinks and kafka sources are autoscaled"
+		return insight
+	}
+	if since := time.Since(v.Status.LastScaledAt.Time); since.Seconds() < float64(v.Spec.Scale.GetCooldownSeconds()) {
+		remaining := time.Duration(v.Spec.Scale.GetCooldownSeconds())*time.Second - since
+		insight.status = fmt.Sprintf("in cooldown, %s remaining", remaining.Round(time.Second))
+		return insight
+	}
+	if v.Status.Phase != v1alpha1.VertexPhaseRunning {
+		insight.status = fmt.Sprintf("vertex phase is %q, not running", v.Status.Phase)
+		return insight
+	}
+	if pl == nil {
+		insight.status = "pipeline unavailable"
+		return insight
+	}
+	if pl.Spec.Lifecycle.GetDesiredPhase() != v1alpha1.PipelinePhaseRunning {
+		insight.status = fmt.Sprintf("pipeline desired phase is %q, not running", pl.Spec.Lifecycle.GetDesiredPhase())
+		return insight
+	}
+	current := int32(v.Status.Replicas)
+	if int(current) != v.GetReplicas() {
+		insight.status = fmt.Sprintf("scaling in progress, %d of %d replicas", current, v.GetReplicas())
+		return insight
+	}
+	if current == 0 {
+		sleep := time.Duration(v.Spec.Scale.GetZeroReplicaSleepSeconds()) * time.Second
+		if since := time.Since(v.Status.LastScaledAt.Time); since < sleep {
+			insight.status = fmt.Sprintf("scaled to zero, peeking in %s", (sleep - since).Round(time.Second))
+			return insight
+		}
+		insight.next = pointer.Int32(1)
+		insight.status = "scaled to zero, scaling up to 1 to peek"
+		return insight
+	}
+	if vMetrics == nil {
+		insight.status = "daemon service unavailable"
+		return insight
+	}
+	if insight.rate == nil {
+		insight.status = "processing rate not available"
+		return insight
+	}
+	if insight.pending == nil {
+		insight.status = "pending not available"
+		return insight
+	}
+
+	totalBufferLength, targetAvailableBufferLength := int64(0), int64(0)
+	if !v.IsASource() {
+		bInfo, ok := buffers[v.GetFromBuffers()[0].Name]
+		if !ok || bInfo.BufferLength == nil || bInfo.BufferUsageLimit == nil {
+			insight.status = "read buffer information not available"
+			return insight
+		}
+		totalBufferLength = int64(float64(*bInfo.BufferLength) * *bInfo.BufferUsageLimit)
+		targetAvailableBufferLength = int64(float64(*bInfo.BufferLength) * float64(v.Spec.Scale.GetTargetBufferUsage()) / 100)
+	}
+	desired := desiredReplicas(v, *insight.rate, *insight.pending, totalBufferLength, targetAvailableBufferLength)
+	min, max := v.Spec.Scale.GetMinReplicas(), v.Spec.Scale.GetMaxReplicas()
+	atMax, atMin := desired > max, desired < min
+	if atMax {
+		desired = max
+	}
+	if atMin {
+		desired = min
+	}
+	insight.recommended = pointer.Int32(desired)
+	if current > max || current < min {
+		insight.next = pointer.Int32(desired)
+		insight.status = fmt.Sprintf("replicas outside of min %d and max %d, scaling to %d", min, max, desired)
+		return insight
+	}
+	maxAllowed := int32(v.Spec.Scale.GetReplicasPerScale())
+	switch {
+	case desired < current:
+		next := current - minInt32(current-desired, maxAllowed)
+		insight.next = pointer.Int32(next)
+		insight.status = fmt.Sprintf("scaling down to %d", next)
+	case desired > current:
+		directPressure, downstreamPressure := hasBackPressure(pl, v, buffers)
+		if directPressure {
+			if current > 1 {
+				insight.next = pointer.Int32(current - 1)
+				insight.status = "direct back pressure from downstream vertices, scaling down by one"
+			} else {
+				insight.status = "direct back pressure from downstream vertices, not scaling up"
+			}
+			return insight
+		}
+		if downstreamPressure {
+			insight.status = "back pressure further downstream, not scaling up"
+			return insight
+		}
+		next := current + minInt32(desired-current, maxAllowed)
+		insight.next = pointer.Int32(next)
+		insight.status = fmt.Sprintf("scaling up to %d", next)
+	case atMax:
+		insight.status = fmt.Sprintf("at max replicas %d", max)
+	case atMin:
+		insight.status = fmt.Sprintf("at min replicas %d", min)
+	default:
+		insight.status = "stable"
+	}
+	return insight
+}
+
+// desiredReplicas is a copy of the replica calculation of Numaflow's autoscaler.
+func desiredReplicas(v *v1alpha1.Vertex, rate float64, pending int64, totalBufferLength int64, targetAvailableBufferLength int64) int32 {
+	if rate == 0 && pending == 0 {
+		return 0
+	}
+	if rate == 0 {
+		return int32(v.Status.Replicas)
+	}
+	if v.IsASource() {
+		desired := int32(math.Round(((float64(pending) / rate) / float64(v.Spec.Scale.GetTargetProcessingSeconds())) * float64(v.Status.Replicas)))
+		if desired == 0 {
+			desired = 1
+		}
+		return desired
+	}
+	if pending >= totalBufferLength {
+		return int32(v.Status.Replicas) + int32(v.Spec.Scale.GetReplicasPerScale())
+	}
+	singleReplicaContribution := float64(totalBufferLength-pending) / float64(v.Status.Replicas)
+	desired := int32(math.Round(float64(targetAvailableBufferLength) / singleReplicaContribution))
+	if desired == 0 {
+		desired = 1
+	}
+	return desired
+}
+
+// hasBackPressure reports whether a buffer written to by the vertex, or by any vertex downstream of it, is over the threshold.
+func hasBackPressure(pl *v1alpha1.Pipeline, v *v1alpha1.Vertex, buffers map[string]*daemon.BufferInfo) (bool, bool) {
+	directPressure, downstreamPressure := false, false
+	for _, e := range pl.GetDownstreamEdges(v.Spec.Name) {
+		for _, bufferName := range v1alpha1.GenerateEdgeBufferNames(pl.Namespace, pl.Name, e) {
+			bInfo, ok := buffers[bufferName]
+			if !ok || bInfo.PendingCount == nil || bInfo.BufferLength == nil || bInfo.BufferUsageLimit == nil {
+				continue
+			}
+			length := float64(*bInfo.BufferLength) * *bInfo.BufferUsageLimit
+			if length > 0 && float64(*bInfo.PendingCount)/length >= backPressureThreshold {
+				downstreamPressure = true
+				if e.From == v.Spec.Name {
+					return true, true
+				}
+			}
+		}
+	}
+	return directPressure, downstreamPressure
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
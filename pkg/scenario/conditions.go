@@ -0,0 +1,90 @@
+package scenario
+
+import (
+	"context"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// conditionRows accumulates the conditions of resources as table rows.
+type conditionRows struct {
+	namespaces      []string
+	kinds           []string
+	pipelines       []*string
+	names           []string
+	types           []string
+	statuses        []string
+	reasons         []string
+	messages        []string
+	transitionTimes []time.Time
+}
+
+func (r *conditionRows) add(namespace string, kind string, pipeline *string, name string, conditions []metav1.Condition) {
+	for _, c := range conditions {
+		r.namespaces = append(r.namespaces, namespace)
+		r.kinds = append(r.kinds, kind)
+		r.pipelines = append(r.pipelines, pipeline)
+		r.names = append(r.names, name)
+		r.types = append(r.types, c.Type)
+		r.statuses = append(r.statuses, string(c.Status))
+		r.reasons = append(r.reasons, c.Reason)
+		r.messages = append(r.messages, c.Message)
+		r.transitionTimes = append(r.transitionTimes, c.LastTransitionTime.Time)
+	}
+}
+
+// newConditionsFrames returns a row per status condition of the selected resources.
+// Vertices have no status conditions in Numaflow, so vertex queries return no rows.
+func newConditionsFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	rows := &conditionRows{}
+	switch rq.ResourceType {
+	case query.PipelineResourceType:
+		pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for i := range pipelines {
+			rows.add(pipelines[i].Namespace, "Pipeline", &pipelines[i].Name, pipelines[i].Name, pipelines[i].Status.Conditions)
+		}
+	case query.VertexResourceType:
+		if _, err := listQueryVertices(ctx, nfClient, rq); err != nil {
+			return nil, err
+		}
+	case query.IsbsvcResourceType:
+		isbsvcs, err := listQueryInterStepBufferServices(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for i := range isbsvcs {
+			rows.add(isbsvcs[i].Namespace, "InterStepBufferService", nil, isbsvcs[i].Name, isbsvcs[i].Status.Conditions)
+		}
+	default:
+		return nil, query.Errorf(query.ValidationError, "conditions currently only supports pipelines, vertices and isbsvcs")
+	}
+
+	statusMappings := data.ValueMappings{data.ValueMapper{
+		string(metav1.ConditionTrue):    {Color: "green", Index: 0},
+		string(metav1.ConditionFalse):   {Color: "red", Index: 1},
+		string(metav1.ConditionUnknown): {Color: "orange", Index: 2},
+	}}
+	fields := []*data.Field{
+		data.NewField("namespace", nil, rows.namespaces),
+		data.NewField("kind", nil, rows.kinds),
+		data.NewField("pipeline", nil, rows.pipelines),
+		data.NewField("name", nil, rows.names),
+		data.NewField("type", nil, rows.types),
+		data.NewField("status", nil, rows.statuses).SetConfig(&data.FieldConfig{Mappings: statusMappings}),
+		data.NewField("reason", nil, rows.reasons),
+		data.NewField("message", nil, rows.messages),
+		data.NewField("last transition time", nil, rows.transitionTimes),
+	}
+	frame := data.NewFrame("conditions", fields...)
+	if rq.ResourceType == query.VertexResourceType {
+		frame.SetMeta(&data.FrameMeta{Notices: []data.Notice{{Severity: data.NoticeSeverityInfo, Text: "vertices have no status conditions, query their pipeline instead"}}})
+	}
+	return data.Frames{frame}, nil
+}
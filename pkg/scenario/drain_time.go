@@ -0,0 +1,170 @@
+package scenario
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"k8s.io/utils/pointer"
+)
+
+// drainTime estimates the seconds a vertex needs to process its pending messages at its current processing rate.
+// It is nil when the estimate is unknown, i.e. pending is not available, or there are pending messages but the rate
+// is zero or not available.
+func drainTime(pending *int64, rate *float64) *float64 {
+	if pending == nil {
+		return nil
+	}
+	if *pending == 0 {
+		return pointer.Float64(0)
+	}
+	if rate == nil || *rate <= 0 {
+		return nil
+	}
+	return pointer.Float64(roundFloat(float64(*pending) / *rate, 2))
+}
+
+// formatDrainTime renders a drain time for display, e.g. in node graph details.
+func formatDrainTime(seconds *float64) string {
+	if seconds == nil {
+		return "unknown"
+	}
+	return (time.Duration(*seconds * float64(time.Second))).Round(time.Second).String()
+}
+
+// criticalPath returns the path from a source to a sink with the largest total drain time, and that total.
+// The total is nil when a vertex on the path has an unknown drain time, as the path may then never drain.
+func criticalPath(pl *v1alpha1.Pipeline, drainTimes map[string]*float64) ([]string, *float64) {
+	type pathTotal struct {
+		path    []string
+		total   float64
+		unknown bool
+	}
+	longest := make(map[string]*pathTotal)
+	var visit func(vertex string) *pathTotal
+	visit = func(vertex string) *pathTotal {
+		if pt, ok := longest[vertex]; ok {
+			return pt
+		}
+		var upstream *pathTotal
+		// numaflow's GetFromEdges returns the edges into a vertex, and GetToEdges the edges out of it
+		for _, e := range pl.GetFromEdges(vertex) {
+			u := visit(e.From)
+			// an unknown drain time dominates any known total
+			if upstream == nil || (u.unknown && !upstream.unknown) || (u.unknown == upstream.unknown && u.total > upstream.total) {
+				upstream = u
+			}
+		}
+		pt := &pathTotal{path: []string{vertex}}
+		if upstream != nil {
+			pt.path = append(append([]string{}, upstream.path...), vertex)
+			pt.total = upstream.total
+			pt.unknown = upstream.unknown
+		}
+		if d := drainTimes[vertex]; d != nil {
+			pt.total += *d
+		} else {
+			pt.unknown = true
+		}
+		longest[vertex] = pt
+		return pt
+	}
+
+	var critical *pathTotal
+	for _, v := range pl.Spec.Vertices {
+		if len(pl.GetToEdges(v.Name)) > 0 {
+			continue
+		}
+		pt := visit(v.Name)
+		if critical == nil || (pt.unknown && !critical.unknown) || (pt.unknown == critical.unknown && pt.total > critical.total) {
+			critical = pt
+		}
+	}
+	if critical == nil {
+		return nil, nil
+	}
+	if critical.unknown {
+		return critical.path, nil
+	}
+	return critical.path, pointer.Float64(roundFloat(critical.total, 2))
+}
+
+// newDrainTimeFrames returns the estimated drain time of pipelines, along their critical path, or of vertices, as a
+// single sample per resource so that it can be used in alert rules and stat panels.
+// When all windows are selected, there is a sample per resource and window, labeled with the window.
+func newDrainTimeFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	windows, err := rq.GetWindows()
+	if err != nil {
+		return nil, err
+	}
+	labelsFor := func(labels data.Labels, w query.Window) data.Labels {
+		if len(windows) > 1 {
+			labels = labels.Copy()
+			labels["window"] = string(w)
+		}
+		return labels
+	}
+	now := time.Now()
+	fields := []*data.Field{data.NewField("time", nil, []time.Time{now})}
+	switch rq.ResourceType {
+	case query.PipelineResourceType:
+		pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for i := range pipelines {
+			drainTimes := make(map[query.Window]map[string]*float64)
+			for _, w := range windows {
+				drainTimes[w] = make(map[string]*float64)
+			}
+			for _, v := range pipelines[i].Spec.Vertices {
+				for w, d := range vertexDrainTimes(ctx, nfClient, pipelines[i].Namespace, pipelines[i].Name, v.Name, windows) {
+					drainTimes[w][v.Name] = d
+				}
+			}
+			labels := data.Labels{"namespace": pipelines[i].Namespace, "pipeline": pipelines[i].Name}
+			for _, w := range windows {
+				path, total := criticalPath(&pipelines[i], drainTimes[w])
+				field := data.NewField("drain time", labelsFor(labels, w), []*float64{total}).SetConfig(&data.FieldConfig{
+					Unit:        secondsUnit,
+					Description: "critical path: " + strings.Join(path, " -> "),
+				})
+				fields = append(fields, field)
+			}
+		}
+	case query.VertexResourceType:
+		vertices, err := listQueryVertices(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for i := range vertices {
+			labels := data.Labels{"namespace": vertices[i].Namespace, "pipeline": vertices[i].Spec.PipelineName, "vertex": vertices[i].Spec.Name}
+			drainTimes := vertexDrainTimes(ctx, nfClient, vertices[i].Namespace, vertices[i].Spec.PipelineName, vertices[i].Spec.Name, windows)
+			for _, w := range windows {
+				fields = append(fields, data.NewField("drain time", labelsFor(labels, w), []*float64{drainTimes[w]}).SetConfig(unitConfig(secondsUnit)))
+			}
+		}
+	default:
+		return nil, query.Errorf(query.ValidationError, "drain time currently only supports pipelines and vertices")
+	}
+	return data.Frames{data.NewFrame("drain time", fields...)}, nil
+}
+
+// vertexDrainTimes returns the drain time of a vertex per window, which is nil for all windows when its metrics are not available.
+func vertexDrainTimes(ctx context.Context, nfClient *client.Client, namespace string, pipeline string, vertex string, windows []query.Window) map[query.Window]*float64 {
+	drainTimes := make(map[query.Window]*float64)
+	vMetrics, err := nfClient.GetVertexMetrics(ctx, namespace, pipeline, vertex)
+	if err != nil {
+		partialFailure(ctx, "failed to retrieve metrics for vertex", "namespace", namespace, "pipeline", pipeline, "vertex", vertex, "err", err)
+		return drainTimes
+	}
+	for _, w := range windows {
+		rate, pending := rateAndPending(vMetrics, w)
+		drainTimes[w] = drainTime(pending, rate)
+	}
+	return drainTimes
+}
@@ -0,0 +1,175 @@
+package scenario
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	v1 "k8s.io/api/core/v1"
+)
+
+// eventObject is an object that events are reported for, i.e. the involved object of an event.
+type eventObject struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// eventObjects are the objects whose events are returned, with the pipeline, vertex or isbsvc of their rows.
+type eventObjects map[eventObject]resourceColumns
+
+func (o eventObjects) add(kind, namespace, name string, columns resourceColumns) {
+	o[eventObject{kind: kind, namespace: namespace, name: name}] = columns
+}
+
+// listEventObjects returns the selected pipelines, vertices, isbsvcs or pods, the vertices of the selected pipelines,
+// and the pods of the selected resources.
+func listEventObjects(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (eventObjects, error) {
+	objects := make(eventObjects)
+	switch rq.ResourceType {
+	case query.PipelineResourceType:
+		pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, pl := range pipelines {
+			name := pl.Name
+			objects.add("Pipeline", pl.Namespace, pl.Name, resourceColumns{pipeline: &name})
+			vertices, err := nfClient.ListPipelineVertices(ctx, pl.Namespace, pl.Name)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve vertices for pipeline", "namespace", pl.Namespace, "pipeline", pl.Name, "err", err)
+				continue
+			}
+			for _, v := range vertices {
+				vertex := v.Spec.Name
+				objects.add("Vertex", v.Namespace, v.Name, resourceColumns{pipeline: &name, vertex: &vertex})
+			}
+		}
+	case query.VertexResourceType:
+		vertices, err := listQueryVertices(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vertices {
+			pipeline, vertex := v.Spec.PipelineName, v.Spec.Name
+			objects.add("Vertex", v.Namespace, v.Name, resourceColumns{pipeline: &pipeline, vertex: &vertex})
+		}
+	case query.IsbsvcResourceType:
+		isbsvcs, err := listQueryInterStepBufferServices(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, isbsvc := range isbsvcs {
+			name := isbsvc.Name
+			objects.add("InterStepBufferService", isbsvc.Namespace, isbsvc.Name, resourceColumns{isbsvc: &name})
+		}
+	}
+	pods, err := listQueryPods(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pods {
+		objects.add("Pod", p.pod.Namespace, p.pod.Name, p.resourceColumns)
+	}
+	return objects, nil
+}
+
+// eventTime is when an event was last seen, events recorded with the events.k8s.io API only have an event time.
+func eventTime(e *v1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+// newEventsFrames returns the Kubernetes events, last seen within the time range, of the selected resources, their
+// vertices and their pods, newest first.
+func newEventsFrames(ctx context.Context, nfClient *client.Client, dq backend.DataQuery, rq query.RunnableQuery) (data.Frames, error) {
+	objects, err := listEventObjects(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]bool)
+	events := []v1.Event{}
+	for o := range objects {
+		if listed[o.namespace] {
+			continue
+		}
+		listed[o.namespace] = true
+		e, err := nfClient.ListEvents(ctx, o.namespace)
+		if err != nil {
+			partialFailure(ctx, "failed to retrieve events for namespace", "namespace", o.namespace, "err", err)
+			continue
+		}
+		for i := range e {
+			io := e[i].InvolvedObject
+			if _, ok := objects[eventObject{kind: io.Kind, namespace: io.Namespace, name: io.Name}]; !ok {
+				continue
+			}
+			if t := eventTime(&e[i]); !dq.TimeRange.From.IsZero() && (t.Before(dq.TimeRange.From) || t.After(dq.TimeRange.To)) {
+				continue
+			}
+			events = append(events, e[i])
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(&events[i]).After(eventTime(&events[j]))
+	})
+
+	times := make([]time.Time, len(events))
+	namespaces := make([]string, len(events))
+	pipelines := make([]*string, len(events))
+	vertices := make([]*string, len(events))
+	isbsvcs := make([]*string, len(events))
+	kinds := make([]string, len(events))
+	names := make([]string, len(events))
+	types := make([]string, len(events))
+	reasons := make([]string, len(events))
+	messages := make([]string, len(events))
+	counts := make([]int32, len(events))
+	firstTimes := make([]*time.Time, len(events))
+	for i := range events {
+		e := &events[i]
+		io := e.InvolvedObject
+		columns := objects[eventObject{kind: io.Kind, namespace: io.Namespace, name: io.Name}]
+		times[i] = eventTime(e)
+		namespaces[i] = io.Namespace
+		pipelines[i], vertices[i], isbsvcs[i] = columns.pipeline, columns.vertex, columns.isbsvc
+		kinds[i] = io.Kind
+		names[i] = io.Name
+		types[i] = e.Type
+		reasons[i] = e.Reason
+		messages[i] = e.Message
+		counts[i] = e.Count
+		if !e.FirstTimestamp.IsZero() {
+			firstTimes[i] = &e.FirstTimestamp.Time
+		}
+	}
+
+	typeMappings := data.ValueMappings{data.ValueMapper{
+		v1.EventTypeNormal:  {Color: "green", Index: 0},
+		v1.EventTypeWarning: {Color: "orange", Index: 1},
+	}}
+	fields := []*data.Field{
+		data.NewField("last seen", nil, times),
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, pipelines),
+		data.NewField("vertex", nil, vertices),
+		data.NewField("isbsvc", nil, isbsvcs),
+		data.NewField("kind", nil, kinds),
+		data.NewField("object", nil, names),
+		data.NewField("type", nil, types).SetConfig(&data.FieldConfig{Mappings: typeMappings}),
+		data.NewField("reason", nil, reasons),
+		data.NewField("message", nil, messages),
+		data.NewField("count", nil, counts),
+		data.NewField("first seen", nil, firstTimes),
+	}
+	return data.Frames{data.NewFrame("events", setDisplayNames(fields)...)}, nil
+}
@@ -0,0 +1,54 @@
+package scenario
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+)
+
+func TestEventsOfPipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the simulator restarts pods at times, and records their events, on every interval
+	nfClient, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Pipelines: 1, Vertices: 3, Interval: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	rq := query.RunnableQuery{Namespace: pointer.String("ns"), Pipeline: pointer.String("orders"), ResourceType: query.PipelineResourceType, ResourceName: "orders"}
+	var frames data.Frames
+	require.Eventually(t, func() bool {
+		now := time.Now()
+		dq := backend.DataQuery{TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now}}
+		frames, err = newEventsFrames(ctx, nfClient, dq, rq)
+		require.NoError(t, err)
+		return frames[0].Rows() > 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	kind, _ := frames[0].FieldByName("kind")
+	pipeline, _ := frames[0].FieldByName("pipeline")
+	vertex, _ := frames[0].FieldByName("vertex")
+	lastSeen, _ := frames[0].FieldByName("last seen")
+	for i := 0; i < frames[0].Rows(); i++ {
+		assert.Contains(t, []string{"Pod", "Vertex"}, kind.At(i))
+		p, _ := pipeline.ConcreteAt(i)
+		assert.Equal(t, "orders", p)
+		v, ok := vertex.ConcreteAt(i)
+		assert.True(t, ok && v != "", "vertex of row %d", i)
+		if i > 0 {
+			assert.False(t, lastSeen.At(i).(time.Time).After(lastSeen.At(i-1).(time.Time)), "newest first")
+		}
+	}
+
+	// events before the time range are left out
+	past := time.Now().Add(-24 * time.Hour)
+	frames, err = newEventsFrames(ctx, nfClient, backend.DataQuery{TimeRange: backend.TimeRange{From: past.Add(-time.Hour), To: past}}, rq)
+	require.NoError(t, err)
+	assert.Zero(t, frames[0].Rows())
+}
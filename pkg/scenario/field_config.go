@@ -0,0 +1,154 @@
+package scenario
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/dseapy/numaflow-datasource/pkg/resource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Grafana unit ids of the fields returned by the scenarios.
+const (
+	rateUnit = "suffix: msg/s"
+	// cpuUnit is millicores, written as in Kubernetes quantities.
+	cpuUnit = "suffix:m"
+	// memoryUnit is megabytes, see megabytes.
+	memoryUnit   = "decmbytes"
+	bytesUnit    = "bytes"
+	messagesUnit = "short"
+	// percentUnit is for 0 to 100 values, ratioUnit for 0 to 1 values.
+	percentUnit   = "percent"
+	ratioUnit     = "percentunit"
+	secondsUnit   = "s"
+	timestampUnit = "dateTimeAsIso"
+)
+
+const (
+	// bufferUsageWarning is Numaflow's default autoscaling target buffer usage.
+	bufferUsageWarning = 0.5
+	// bufferUsageCritical is Numaflow's default buffer usage limit, above which a buffer is full.
+	bufferUsageCritical = 0.8
+	// utilizationWarning and utilizationCritical are percents of requests or limits.
+	utilizationWarning  = 80
+	utilizationCritical = 100
+)
+
+func unitConfig(unit string) *data.FieldConfig {
+	return &data.FieldConfig{Unit: unit}
+}
+
+func withDisplayName(c *data.FieldConfig, name string) *data.FieldConfig {
+	c.DisplayName = name
+	return c
+}
+
+// displayNameAcronyms are the words of field names that are upper case in display names.
+var displayNameAcronyms = map[string]string{"cpu": "CPU", "ip": "IP"}
+
+// setDisplayNames gives fields without a display name their name in sentence case, e.g. "CPU usage" for "cpu usage".
+// The names themselves are kept, data links and transformations refer to fields by name.
+func setDisplayNames(fields []*data.Field) []*data.Field {
+	for _, f := range fields {
+		if f.Name == "" || f.Config != nil && f.Config.DisplayName != "" {
+			continue
+		}
+		words := strings.Split(f.Name, " ")
+		for i, w := range words {
+			if acronym, ok := displayNameAcronyms[w]; ok {
+				words[i] = acronym
+			}
+		}
+		name := strings.Join(words, " ")
+		if f.Config == nil {
+			f.Config = &data.FieldConfig{}
+		}
+		f.Config.DisplayName = strings.ToUpper(name[:1]) + name[1:]
+	}
+	return fields
+}
+
+func thresholds(warning, critical float64) *data.ThresholdsConfig {
+	return &data.ThresholdsConfig{
+		Mode: data.ThresholdsModeAbsolute,
+		Steps: []data.Threshold{
+			data.NewThreshold(math.Inf(-1), "green", ""),
+			data.NewThreshold(warning, "orange", ""),
+			data.NewThreshold(critical, "red", ""),
+		},
+	}
+}
+
+// bufferUsageConfig is for buffer usage ratios.
+func bufferUsageConfig() *data.FieldConfig {
+	c := (&data.FieldConfig{Unit: ratioUnit}).SetMin(0).SetMax(1)
+	c.Thresholds = thresholds(bufferUsageWarning, bufferUsageCritical)
+	return c
+}
+
+// utilizationConfig is for usage in percent of requests or limits.
+func utilizationConfig() *data.FieldConfig {
+	c := (&data.FieldConfig{Unit: percentUnit}).SetMin(0)
+	c.Thresholds = thresholds(utilizationWarning, utilizationCritical)
+	return c
+}
+
+// metricConfig is for time series of the metric store.
+func metricConfig(m metricstore.Metric) *data.FieldConfig {
+	switch m {
+	case metricstore.ProcessingRateMetric:
+		return unitConfig(rateUnit)
+	case metricstore.PendingMetric, metricstore.RedisStreamLengthMetric, metricstore.RedisPendingMetric, metricstore.RedisLagMetric:
+		return unitConfig(messagesUnit)
+	case metricstore.WatermarkMetric:
+		return unitConfig(timestampUnit)
+	case metricstore.BufferUsageMetric:
+		return bufferUsageConfig()
+	case metricstore.CPUMetric, metricstore.ContainerCPUMetric:
+		return unitConfig(cpuUnit)
+	case metricstore.MemoryMetric, metricstore.ContainerMemoryMetric, metricstore.RedisMemoryMetric:
+		return unitConfig(memoryUnit)
+	}
+	return nil
+}
+
+// preferredVisualizations are the visualizations Explore uses per query type, query types that are not listed have none.
+var preferredVisualizations = map[string]data.VisType{
+	resource.TableQueryType:       data.VisTypeTable,
+	resource.NodeGraphQueryType:   data.VisTypeNodeGraph,
+	resource.HistoryQueryType:     data.VisTypeTable,
+	resource.TimeSeriesQueryType:  data.VisTypeGraph,
+	resource.UtilizationQueryType: data.VisTypeTable,
+	resource.AutoscalingQueryType: data.VisTypeTable,
+	resource.DrainTimeQueryType:   data.VisTypeGraph,
+	resource.PartitionsQueryType:  data.VisTypeTable,
+	resource.HealthQueryType:      data.VisTypeTable,
+	resource.JetStreamQueryType:   data.VisTypeTable,
+	resource.RedisQueryType:       data.VisTypeTable,
+	resource.ConditionsQueryType:  data.VisTypeTable,
+	resource.WorkloadsQueryType:   data.VisTypeTable,
+	resource.PodsQueryType:        data.VisTypeTable,
+	resource.LogsQueryType:        data.VisTypeLogs,
+	resource.EventsQueryType:      data.VisTypeTable,
+}
+
+// setFrameMeta sets the executed query and preferred visualization of frames, keeping any notices set by the scenario.
+func setFrameMeta(frames data.Frames, dq backend.DataQuery, rq query.RunnableQuery) {
+	executed, err := json.Marshal(rq)
+	if err != nil {
+		executed = []byte(dq.JSON)
+	}
+	for _, f := range frames {
+		if f.Meta == nil {
+			f.Meta = &data.FrameMeta{}
+		}
+		f.Meta.ExecutedQueryString = string(executed)
+		if f.Meta.PreferredVisualization == "" {
+			f.Meta.PreferredVisualization = preferredVisualizations[dq.QueryType]
+		}
+	}
+}
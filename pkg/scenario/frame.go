@@ -1,21 +1,90 @@
 package scenario
 
 import (
-	"errors"
+	"context"
+	"time"
+
 	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/history"
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
 	"github.com/dseapy/numaflow-datasource/pkg/query"
 	"github.com/dseapy/numaflow-datasource/pkg/resource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
-func NewDataFrames(nfClient *client.Client, query backend.DataQuery, runnableQuery query.RunnableQuery) (data.Frames, error) {
-	switch query.QueryType {
+// Options holds datasource-level settings that some scenarios need in addition to the query.
+type Options struct {
+	Redaction RedactionRules
+	// History is nil when spec history is disabled.
+	History *history.Store
+	// Metrics is nil when the metric store is disabled.
+	Metrics *metricstore.Store
+	Links   LinkOptions
+}
+
+// UsesTimeRange reports whether the frames of a query type depend on the time range of the query.
+func UsesTimeRange(queryType string) bool {
+	switch queryType {
+	case resource.HistoryQueryType, resource.TimeSeriesQueryType, resource.UtilizationQueryType, resource.LogsQueryType, resource.EventsQueryType:
+		return true
+	}
+	return false
+}
+
+func NewDataFrames(ctx context.Context, nfClient *client.Client, dq backend.DataQuery, rq query.RunnableQuery, opts Options) (data.Frames, error) {
+	start := time.Now()
+	ctx, notices := withNotices(ctx)
+	frames, err := newDataFrames(ctx, nfClient, dq, rq, opts)
+	observeQuery(dq.QueryType, start, err)
+	if err == nil {
+		notices.attach(frames)
+		setFrameMeta(frames, dq, rq)
+		setFrameLinks(frames, dq, rq, opts.Links)
+	}
+	return frames, err
+}
+
+func newDataFrames(ctx context.Context, nfClient *client.Client, dq backend.DataQuery, runnableQuery query.RunnableQuery, opts Options) (data.Frames, error) {
+	if err := resource.CheckResourceType(dq.QueryType, runnableQuery); err != nil {
+		return nil, err
+	}
+	switch dq.QueryType {
 	case resource.TableQueryType:
-		return newTableFrames(nfClient, runnableQuery)
+		return newTableFrames(ctx, nfClient, runnableQuery)
 	case resource.NodeGraphQueryType:
-		return newNodeGraphFrames(nfClient, runnableQuery)
+		return newNodeGraphFrames(ctx, nfClient, runnableQuery)
+	case resource.SpecQueryType:
+		return newSpecFrames(ctx, nfClient, runnableQuery, opts.Redaction)
+	case resource.HistoryQueryType:
+		return newHistoryFrames(opts.History, dq, runnableQuery, opts.Redaction)
+	case resource.TimeSeriesQueryType:
+		return newTimeSeriesFrames(opts.Metrics, dq, runnableQuery)
+	case resource.UtilizationQueryType:
+		return newUtilizationFrames(ctx, nfClient, opts.Metrics, dq, runnableQuery)
+	case resource.AutoscalingQueryType:
+		return newAutoscalingFrames(ctx, nfClient, runnableQuery)
+	case resource.DrainTimeQueryType:
+		return newDrainTimeFrames(ctx, nfClient, runnableQuery)
+	case resource.PartitionsQueryType:
+		return newPartitionsFrames(ctx, nfClient, runnableQuery)
+	case resource.HealthQueryType:
+		return newHealthFrames(ctx, nfClient, opts.Metrics, runnableQuery)
+	case resource.JetStreamQueryType:
+		return newJetStreamFrames(ctx, nfClient, runnableQuery)
+	case resource.RedisQueryType:
+		return newRedisFrames(ctx, nfClient, runnableQuery)
+	case resource.ConditionsQueryType:
+		return newConditionsFrames(ctx, nfClient, runnableQuery)
+	case resource.WorkloadsQueryType:
+		return newWorkloadsFrames(ctx, nfClient, runnableQuery)
+	case resource.PodsQueryType:
+		return newPodsFrames(ctx, nfClient, runnableQuery)
+	case resource.LogsQueryType:
+		return newLogsFrames(ctx, nfClient, dq, runnableQuery)
+	case resource.EventsQueryType:
+		return newEventsFrames(ctx, nfClient, dq, runnableQuery)
 	}
 
-	return nil, errors.New("unsupported query type")
+	return nil, query.Errorf(query.ValidationError, "unsupported query type %q", dq.QueryType)
 }
@@ -0,0 +1,159 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/history"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pmezard/go-difflib/difflib"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/strings/slices"
+)
+
+func newHistoryFrames(store *history.Store, dq backend.DataQuery, rq query.RunnableQuery, rules RedactionRules) (data.Frames, error) {
+	if store == nil {
+		return nil, query.Errorf(query.ValidationError, "spec history is disabled for this datasource")
+	}
+	var kind history.Kind
+	switch rq.ResourceType {
+	case query.PipelineResourceType:
+		kind = history.PipelineKind
+	case query.VertexResourceType:
+		kind = history.VertexKind
+	default:
+		return nil, query.Errorf(query.ValidationError, "history currently only supports pipelines and vertices")
+	}
+	queryFilterNamespaces := rq.GetFilterNamespaces()
+	queryFilterPipelines := rq.GetFilterPipelines()
+	matches := func(rev history.Revision) bool {
+		if *rq.Namespace != v1.NamespaceAll && !slices.Contains(queryFilterNamespaces, rev.Namespace) {
+			return false
+		}
+		if *rq.Pipeline != "*" && *rq.Pipeline != "" && !slices.Contains(queryFilterPipelines, rev.Pipeline) {
+			return false
+		}
+		return kind != history.VertexKind || *rq.Vertex == "*" || *rq.Vertex == rev.Vertex
+	}
+
+	if rq.FromGeneration != nil || rq.ToGeneration != nil {
+		return newHistoryDiffFrames(store, kind, matches, rq, rules)
+	}
+
+	revisions := store.List(kind, func(rev history.Revision) bool {
+		return matches(rev) && !rev.IsBaseline() && !rev.Time.Before(dq.TimeRange.From) && !rev.Time.After(dq.TimeRange.To)
+	})
+	times := make([]time.Time, len(revisions))
+	kinds := make([]string, len(revisions))
+	namespaces := make([]string, len(revisions))
+	pipelines := make([]string, len(revisions))
+	vertices := make([]string, len(revisions))
+	generations := make([]int64, len(revisions))
+	titles := make([]string, len(revisions))
+	texts := make([]string, len(revisions))
+	for i, rev := range revisions {
+		times[i] = rev.Time
+		kinds[i] = string(rev.Kind)
+		namespaces[i] = rev.Namespace
+		pipelines[i] = rev.Pipeline
+		vertices[i] = rev.Vertex
+		generations[i] = rev.Generation
+		titles[i] = fmt.Sprintf("%s %s/%s generation %d", rev.Kind, rev.Namespace, rev.Name, rev.Generation)
+		if prev, ok := store.Previous(rev); ok {
+			diff, err := diffRevisions(prev, rev, rules)
+			if err != nil {
+				return nil, err
+			}
+			texts[i] = diff
+		}
+	}
+
+	fields := []*data.Field{
+		data.NewField("time", nil, times),
+		data.NewField("kind", nil, kinds),
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, pipelines),
+		data.NewField("vertex", nil, vertices),
+		data.NewField("generation", nil, generations),
+		data.NewField("title", nil, titles),
+		data.NewField("text", nil, texts),
+	}
+	return data.Frames{data.NewFrame("revisions", fields...)}, nil
+}
+
+func newHistoryDiffFrames(store *history.Store, kind history.Kind, matches func(history.Revision) bool, rq query.RunnableQuery, rules RedactionRules) (data.Frames, error) {
+	if rq.ResourceName == "*" || rq.IsMultiNamespaceFilter() || rq.IsMultiPipelineFilter() {
+		return nil, query.Errorf(query.ValidationError, "history diff currently only supports a single pipeline or vertex")
+	}
+	revisions := store.List(kind, matches)
+	if len(revisions) == 0 {
+		return nil, query.Errorf(query.NotFoundError, "no spec history recorded for %s %q", kind, rq.ResourceName)
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Generation < revisions[j].Generation
+	})
+	// to defaults to the latest revision, and from to the revision just before to
+	toIndex := len(revisions) - 1
+	if rq.ToGeneration != nil {
+		toIndex = generationIndex(revisions, *rq.ToGeneration)
+		if toIndex < 0 {
+			return nil, query.Errorf(query.NotFoundError, "generation %d of %s %q is not in the spec history", *rq.ToGeneration, kind, rq.ResourceName)
+		}
+	}
+	fromIndex := toIndex
+	if toIndex > 0 {
+		fromIndex = toIndex - 1
+	}
+	if rq.FromGeneration != nil {
+		fromIndex = generationIndex(revisions, *rq.FromGeneration)
+		if fromIndex < 0 {
+			return nil, query.Errorf(query.NotFoundError, "generation %d of %s %q is not in the spec history", *rq.FromGeneration, kind, rq.ResourceName)
+		}
+	}
+	from, to := revisions[fromIndex], revisions[toIndex]
+	diff, err := diffRevisions(from, to, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []*data.Field{
+		data.NewField("namespace", nil, []string{to.Namespace}),
+		data.NewField("name", nil, []string{to.Name}),
+		data.NewField("from generation", nil, []int64{from.Generation}),
+		data.NewField("to generation", nil, []int64{to.Generation}),
+		data.NewField("diff", nil, []string{diff}),
+	}
+	return data.Frames{data.NewFrame("diff", fields...)}, nil
+}
+
+// generationIndex returns the index of the revision with the given generation, or -1.
+func generationIndex(revisions []history.Revision, generation int64) int {
+	for i, rev := range revisions {
+		if rev.Generation == generation {
+			return i
+		}
+	}
+	return -1
+}
+
+// diffRevisions returns a unified diff of the redacted YAML specs of two revisions.
+func diffRevisions(from, to history.Revision, rules RedactionRules) (string, error) {
+	fromSpec, err := renderSpec(from.Spec, query.YAMLFormat, rules)
+	if err != nil {
+		return "", err
+	}
+	toSpec, err := renderSpec(to.Spec, query.YAMLFormat, rules)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(fromSpec),
+		B:        difflib.SplitLines(toSpec),
+		FromFile: fmt.Sprintf("%s (generation %d)", from.Name, from.Generation),
+		ToFile:   fmt.Sprintf("%s (generation %d)", to.Name, to.Generation),
+		Context:  3,
+	})
+}
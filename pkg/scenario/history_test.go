@@ -0,0 +1,77 @@
+package scenario
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/history"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+)
+
+func TestHistoryDiffDefaultsFromToRevisionBeforeTo(t *testing.T) {
+	store := history.NewStore(10)
+	for g := int64(1); g <= 4; g++ {
+		_, err := store.Record(history.Revision{
+			Kind:       history.PipelineKind,
+			Namespace:  "ns",
+			Name:       "pl",
+			Pipeline:   "pl",
+			Generation: g,
+			Time:       time.Unix(g, 0),
+			Spec:       []byte(fmt.Sprintf(`{"generation":%d}`, g)),
+		})
+		require.NoError(t, err)
+	}
+
+	for _, tc := range []struct {
+		from, to         *int64
+		wantFrom, wantTo int64
+	}{
+		{to: pointer.Int64(4), wantFrom: 3, wantTo: 4},
+		{to: pointer.Int64(2), wantFrom: 1, wantTo: 2},
+		{to: pointer.Int64(1), wantFrom: 1, wantTo: 1},
+		{from: pointer.Int64(1), wantFrom: 1, wantTo: 4},
+		{from: pointer.Int64(2), to: pointer.Int64(3), wantFrom: 2, wantTo: 3},
+	} {
+		rq := query.RunnableQuery{
+			Namespace:      pointer.String("ns"),
+			Pipeline:       pointer.String("pl"),
+			ResourceType:   query.PipelineResourceType,
+			ResourceName:   "pl",
+			FromGeneration: tc.from,
+			ToGeneration:   tc.to,
+		}
+		frames, err := newHistoryFrames(store, backend.DataQuery{}, rq, RedactionRules{})
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		from, _ := frames[0].FieldByName("from generation")
+		to, _ := frames[0].FieldByName("to generation")
+		assert.Equal(t, tc.wantFrom, from.At(0), "from of %v..%v", tc.from, tc.to)
+		assert.Equal(t, tc.wantTo, to.At(0), "to of %v..%v", tc.from, tc.to)
+	}
+}
+
+func TestHistoryListsNoBaselineRevisions(t *testing.T) {
+	store := history.NewStore(10)
+	// generation 3 is a baseline, observed when the plugin started
+	for _, rev := range []history.Revision{
+		{Generation: 3},
+		{Generation: 4, Time: time.Now().Add(-time.Minute)},
+	} {
+		rev.Kind, rev.Namespace, rev.Name, rev.Pipeline, rev.Spec = history.PipelineKind, "ns", "pl", "pl", []byte(`{}`)
+		_, err := store.Record(rev)
+		require.NoError(t, err)
+	}
+	rq := query.RunnableQuery{Namespace: pointer.String("ns"), Pipeline: pointer.String("pl"), ResourceType: query.PipelineResourceType, ResourceName: "pl"}
+	dq := backend.DataQuery{TimeRange: backend.TimeRange{From: time.Time{}, To: time.Now()}}
+	frames, err := newHistoryFrames(store, dq, rq, RedactionRules{})
+	require.NoError(t, err)
+	generation, _ := frames[0].FieldByName("generation")
+	require.Equal(t, 1, generation.Len())
+	assert.Equal(t, int64(4), generation.At(0))
+}
@@ -0,0 +1,87 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// jetStreamBufferSettingKeys are the buffer settings shown in the isbsvc table, as section and key of the buffer config.
+var jetStreamBufferSettingKeys = [][2]string{
+	{"stream", "maxMsgs"},
+	{"stream", "maxAge"},
+	{"stream", "maxBytes"},
+	{"stream", "replicas"},
+	{"consumer", "maxAckPending"},
+}
+
+// pipelineISBSvcName returns the name of the isbsvc a pipeline uses.
+func pipelineISBSvcName(pl *v1alpha1.Pipeline) string {
+	if pl.Spec.InterStepBufferServiceName == "" {
+		return v1alpha1.DefaultISBSvcName
+	}
+	return pl.Spec.InterStepBufferServiceName
+}
+
+// jetStreamBufferSettings summarizes the stream and consumer settings of the buffers created in a JetStream isbsvc.
+// The controller writes the buffer config with lower-cased keys, so keys are matched case-insensitively.
+func jetStreamBufferSettings(bufferConfig string) string {
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(bufferConfig), &config); err != nil {
+		return ""
+	}
+	settings := []string{}
+	for _, k := range jetStreamBufferSettingKeys {
+		section, ok := lookupFold(config, k[0]).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v := lookupFold(section, k[1]); v != nil {
+			settings = append(settings, fmt.Sprintf("%s.%s=%v", k[0], k[1], v))
+		}
+	}
+	return strings.Join(settings, ", ")
+}
+
+func lookupFold(m map[string]interface{}, key string) interface{} {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// persistenceSummary returns whether and how an isbsvc persists its data, and the volume size when it does.
+func persistenceSummary(p *v1alpha1.PersistenceStrategy) (string, *string) {
+	if p == nil {
+		return "none", nil
+	}
+	summary := "persistent"
+	if p.StorageClassName != nil {
+		summary += " (" + *p.StorageClassName + ")"
+	}
+	if p.VolumeSize == nil {
+		return summary, nil
+	}
+	size := p.VolumeSize.String()
+	return summary, &size
+}
+
+// conditionsSummary renders conditions as "Type=Status", with the message of conditions that are not true.
+func conditionsSummary(conditions []metav1.Condition) string {
+	summaries := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		s := fmt.Sprintf("%s=%s", c.Type, c.Status)
+		if c.Status != metav1.ConditionTrue && c.Message != "" {
+			s += " (" + c.Message + ")"
+		}
+		summaries = append(summaries, s)
+	}
+	sort.Strings(summaries)
+	return strings.Join(summaries, ", ")
+}
@@ -0,0 +1,135 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+)
+
+// newJetStreamFrames returns the JetStream stream and consumer statistics of the buffers of the selected pipelines.
+// Pipelines whose isbsvc is not JetStream, or whose isbsvc cannot be reached, get a row per buffer with the error.
+func newJetStreamFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := []string{}
+	pipelineNames := []string{}
+	isbsvcNames := []string{}
+	buffers := []string{}
+	streams := []string{}
+	messages := []*uint64{}
+	bytes := []*uint64{}
+	firstSequences := []*uint64{}
+	lastSequences := []*uint64{}
+	consumerPending := []*uint64{}
+	ackPending := []*int64{}
+	redelivered := []*int64{}
+	leaders := []*string{}
+	replicas := []*string{}
+	errs := []*string{}
+	for i := range pipelines {
+		pl := &pipelines[i]
+		isbsvcName := pipelineISBSvcName(pl)
+		bufferNames := []string{}
+		for _, b := range pl.GetAllBuffers() {
+			// only edge buffers are backed by streams
+			if b.Type == v1alpha1.EdgeBuffer {
+				bufferNames = append(bufferNames, b.Name)
+			}
+		}
+		stats, err := pipelineStreamStats(ctx, nfClient, pl, isbsvcName, bufferNames)
+		for j, buffer := range bufferNames {
+			namespaces = append(namespaces, pl.Namespace)
+			pipelineNames = append(pipelineNames, pl.Name)
+			isbsvcNames = append(isbsvcNames, isbsvcName)
+			buffers = append(buffers, buffer)
+			streams = append(streams, client.JetStreamStreamName(pl.Name, buffer))
+			var s *client.StreamStats
+			rowErr := err
+			if err == nil {
+				s = &stats[j]
+				rowErr = s.Err
+			}
+			if rowErr != nil {
+				msg := rowErr.Error()
+				errs = append(errs, &msg)
+			} else {
+				errs = append(errs, nil)
+			}
+			// replicas are only set once the stream has been read
+			if s != nil && s.Replicas > 0 {
+				r := fmt.Sprintf("%d/%d", s.CurrentReplicas, s.Replicas)
+				messages = append(messages, &s.Messages)
+				bytes = append(bytes, &s.Bytes)
+				firstSequences = append(firstSequences, &s.FirstSequence)
+				lastSequences = append(lastSequences, &s.LastSequence)
+				leaders = append(leaders, &s.Leader)
+				replicas = append(replicas, &r)
+			} else {
+				messages = append(messages, nil)
+				bytes = append(bytes, nil)
+				firstSequences = append(firstSequences, nil)
+				lastSequences = append(lastSequences, nil)
+				leaders = append(leaders, nil)
+				replicas = append(replicas, nil)
+			}
+			if s != nil && s.Err == nil {
+				a, r := int64(s.ConsumerAckPending), int64(s.Redelivered)
+				consumerPending = append(consumerPending, &s.ConsumerPending)
+				ackPending = append(ackPending, &a)
+				redelivered = append(redelivered, &r)
+			} else {
+				consumerPending = append(consumerPending, nil)
+				ackPending = append(ackPending, nil)
+				redelivered = append(redelivered, nil)
+			}
+		}
+	}
+
+	fields := []*data.Field{
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, pipelineNames),
+		data.NewField("isbsvc", nil, isbsvcNames),
+		data.NewField("buffer", nil, buffers),
+		data.NewField("stream", nil, streams),
+		data.NewField("messages", nil, messages).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("bytes", nil, bytes).SetConfig(unitConfig(bytesUnit)),
+		data.NewField("first sequence", nil, firstSequences),
+		data.NewField("last sequence", nil, lastSequences),
+		data.NewField("consumer pending", nil, consumerPending).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("ack pending", nil, ackPending).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("redelivered", nil, redelivered).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("leader", nil, leaders),
+		data.NewField("current replicas", nil, replicas),
+		data.NewField("error", nil, errs),
+	}
+	return data.Frames{data.NewFrame("jetstream", fields...)}, nil
+}
+
+// pipelineStreamStats connects to the pipeline's isbsvc and reads the stream statistics of the given buffers.
+func pipelineStreamStats(ctx context.Context, nfClient *client.Client, pl *v1alpha1.Pipeline, isbsvcName string, buffers []string) ([]client.StreamStats, error) {
+	isbsvc, err := nfClient.GetInterStepBufferService(ctx, pl.Namespace, isbsvcName)
+	if err != nil {
+		return nil, err
+	}
+	if isbsvc.Spec.JetStream == nil {
+		return nil, &query.Error{Kind: query.ValidationError, Object: fmt.Sprintf("isbsvc %q", pl.Namespace+"/"+isbsvcName), Err: errors.New("not a jetstream isbsvc")}
+	}
+	nc, err := nfClient.ConnectJetStream(ctx, isbsvc)
+	if err != nil {
+		return nil, err
+	}
+	defer nc.Close()
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetJetStreamBufferStats(ctx, js, pl.Name, buffers)
+}
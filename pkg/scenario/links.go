@@ -0,0 +1,151 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/dseapy/numaflow-datasource/pkg/resource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// LinkOptions are used to build data links from the resources in frames.
+type LinkOptions struct {
+	// UIURL is the base URL of the Numaflow server UI, links into the UI are left out when it is empty.
+	UIURL string
+	// DatasourceUID and DatasourceName are the datasource instance that drill-down queries run against in Explore.
+	DatasourceUID  string
+	DatasourceName string
+}
+
+// Grafana data link variables, resolved per row when a link is followed.
+const (
+	valueVar = "${__value.raw}"
+)
+
+func fieldVar(name string) string {
+	return fmt.Sprintf("${__data.fields.%s}", name)
+}
+
+// resourceRef holds the expressions, data link variables or literals, that resolve to the resource of a row.
+type resourceRef struct {
+	namespace string
+	pipeline  string
+	vertex    string
+	isbsvc    string
+}
+
+// uiLink opens the pipeline page of the Numaflow UI, which also shows the pipeline's vertices.
+// The Numaflow UI has no page for isbsvcs.
+func (o LinkOptions) uiLink(r resourceRef) []data.DataLink {
+	if o.UIURL == "" || r.pipeline == "" {
+		return nil
+	}
+	return []data.DataLink{{
+		Title:       "Open pipeline in Numaflow UI",
+		TargetBlank: true,
+		URL:         fmt.Sprintf("%s/namespaces/%s/pipelines/%s", strings.TrimSuffix(o.UIURL, "/"), r.namespace, r.pipeline),
+	}}
+}
+
+// exploreLink opens a query of this datasource in Explore.
+func (o LinkOptions) exploreLink(title string, queryType string, rawQuery map[string]string) data.DataLink {
+	// the raw query is a string of JSON within the query, as built by the query editor
+	raw, _ := json.Marshal(rawQuery)
+	return data.DataLink{
+		Title: title,
+		Internal: &data.InternalDataLink{
+			DatasourceUID:  o.DatasourceUID,
+			DatasourceName: o.DatasourceName,
+			Query: map[string]string{
+				"queryType": queryType,
+				"rawQuery":  string(raw),
+			},
+		},
+	}
+}
+
+func (o LinkOptions) pipelineLinks(r resourceRef) []data.DataLink {
+	q := map[string]string{"namespace": r.namespace, "pipeline": r.pipeline}
+	vertices := map[string]string{"namespace": r.namespace, "pipeline": r.pipeline, "vertex": "*"}
+	return append(o.uiLink(r),
+		o.exploreLink("Vertices", resource.TableQueryType, vertices),
+		o.exploreLink("Graph", resource.NodeGraphQueryType, q),
+		o.exploreLink("Health", resource.HealthQueryType, q),
+		o.exploreLink("Conditions", resource.ConditionsQueryType, q),
+		o.exploreLink("Workloads", resource.WorkloadsQueryType, q),
+		o.exploreLink("Pods", resource.PodsQueryType, q),
+		o.exploreLink("Logs", resource.LogsQueryType, q),
+		o.exploreLink("Events", resource.EventsQueryType, q),
+		o.exploreLink("Spec history", resource.HistoryQueryType, q),
+	)
+}
+
+func (o LinkOptions) vertexLinks(r resourceRef) []data.DataLink {
+	q := map[string]string{"namespace": r.namespace, "pipeline": r.pipeline, "vertex": r.vertex}
+	return append(o.uiLink(r),
+		o.exploreLink("Metrics", resource.TimeSeriesQueryType, q),
+		o.exploreLink("Utilization", resource.UtilizationQueryType, q),
+		o.exploreLink("Autoscaling", resource.AutoscalingQueryType, q),
+		o.exploreLink("Partitions", resource.PartitionsQueryType, q),
+		o.exploreLink("Pods", resource.PodsQueryType, q),
+		o.exploreLink("Logs", resource.LogsQueryType, q),
+		o.exploreLink("Events", resource.EventsQueryType, q),
+		o.exploreLink("Spec history", resource.HistoryQueryType, q),
+	)
+}
+
+func (o LinkOptions) isbsvcLinks(r resourceRef) []data.DataLink {
+	q := map[string]string{"namespace": r.namespace, "isbsvc": r.isbsvc}
+	return []data.DataLink{
+		o.exploreLink("Conditions", resource.ConditionsQueryType, q),
+		o.exploreLink("Workloads", resource.WorkloadsQueryType, q),
+		o.exploreLink("Pods", resource.PodsQueryType, q),
+		o.exploreLink("Logs", resource.LogsQueryType, q),
+		o.exploreLink("Events", resource.EventsQueryType, q),
+	}
+}
+
+// setFrameLinks adds data links to the pipeline, vertex and isbsvc fields of frames, and to the nodes of node graphs.
+// Rows are resolved through the namespace and pipeline fields of the same frame.
+func setFrameLinks(frames data.Frames, dq backend.DataQuery, rq query.RunnableQuery, o LinkOptions) {
+	for _, f := range frames {
+		if f.Name == "nodes" && dq.QueryType == resource.NodeGraphQueryType {
+			// a node graph is of a single pipeline
+			r := resourceRef{namespace: rq.GetNamespace(), pipeline: *rq.Pipeline, vertex: valueVar}
+			setFieldLinks(f, "id", o.vertexLinks(r))
+			continue
+		}
+		if _, i := f.FieldByName("namespace"); i < 0 {
+			continue
+		}
+		namespace := fieldVar("namespace")
+		setFieldLinks(f, "pipeline", o.pipelineLinks(resourceRef{namespace: namespace, pipeline: valueVar}))
+		setFieldLinks(f, "vertex", o.vertexLinks(resourceRef{namespace: namespace, pipeline: fieldVar("pipeline"), vertex: valueVar}))
+		setFieldLinks(f, "isbsvc", o.isbsvcLinks(resourceRef{namespace: namespace, isbsvc: valueVar}))
+		// the name of tables is the queried resource
+		if dq.QueryType == resource.TableQueryType {
+			switch rq.ResourceType {
+			case query.PipelineResourceType:
+				setFieldLinks(f, "name", o.pipelineLinks(resourceRef{namespace: namespace, pipeline: valueVar}))
+			case query.VertexResourceType:
+				setFieldLinks(f, "name", o.vertexLinks(resourceRef{namespace: namespace, pipeline: fieldVar("pipeline"), vertex: valueVar}))
+			case query.IsbsvcResourceType:
+				setFieldLinks(f, "name", o.isbsvcLinks(resourceRef{namespace: namespace, isbsvc: valueVar}))
+			}
+		}
+	}
+}
+
+func setFieldLinks(f *data.Frame, name string, links []data.DataLink) {
+	field, i := f.FieldByName(name)
+	if i < 0 || len(links) == 0 {
+		return
+	}
+	if field.Config == nil {
+		field.Config = &data.FieldConfig{}
+	}
+	field.Config.Links = append(field.Config.Links, links...)
+}
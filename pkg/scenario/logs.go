@@ -0,0 +1,105 @@
+package scenario
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// logTailLines and logLimitBytes bound the log read per container.
+	logTailLines  = 1000
+	logLimitBytes = 1 << 20
+)
+
+type logLine struct {
+	time      time.Time
+	line      string
+	pod       queryPod
+	container string
+}
+
+// parseLogLines splits a log read with timestamps into lines. A line without a timestamp, e.g. the continuation of a
+// multi-line entry, has the time of the line before it, or of fallback when it is the first.
+func parseLogLines(b []byte, fallback time.Time) []logLine {
+	lines := []logLine{}
+	t := fallback
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 0, 64*1024), logLimitBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if ts, rest, ok := strings.Cut(line, " "); ok {
+			if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+				t, line = parsed, rest
+			}
+		}
+		lines = append(lines, logLine{time: t, line: line})
+	}
+	return lines
+}
+
+// newLogsFrames returns the log lines, within the time range, of the containers of the selected pods, or of the pods of
+// the selected resources. Logs of init containers are left out.
+func newLogsFrames(ctx context.Context, nfClient *client.Client, dq backend.DataQuery, rq query.RunnableQuery) (data.Frames, error) {
+	pods, err := listQueryPods(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+	lines := []logLine{}
+	for _, p := range pods {
+		for _, c := range p.pod.Spec.Containers {
+			b, err := nfClient.GetPodLogs(ctx, p.pod.Namespace, p.pod.Name, c.Name, dq.TimeRange.From, logTailLines, logLimitBytes)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve logs for container", "namespace", p.pod.Namespace, "pod", p.pod.Name, "container", c.Name, "err", err)
+				continue
+			}
+			for _, l := range parseLogLines(b, dq.TimeRange.To) {
+				if !dq.TimeRange.To.IsZero() && l.time.After(dq.TimeRange.To) {
+					continue
+				}
+				l.pod, l.container = p, c.Name
+				lines = append(lines, l)
+			}
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].time.Before(lines[j].time)
+	})
+
+	times := make([]time.Time, len(lines))
+	bodies := make([]string, len(lines))
+	namespaces := make([]string, len(lines))
+	pipelines := make([]*string, len(lines))
+	vertices := make([]*string, len(lines))
+	isbsvcs := make([]*string, len(lines))
+	podNames := make([]string, len(lines))
+	containers := make([]string, len(lines))
+	for i, l := range lines {
+		times[i] = l.time
+		bodies[i] = l.line
+		namespaces[i] = l.pod.pod.Namespace
+		pipelines[i], vertices[i], isbsvcs[i] = l.pod.pipeline, l.pod.vertex, l.pod.isbsvc
+		podNames[i] = l.pod.pod.Name
+		containers[i] = l.container
+	}
+	// the logs visualization shows the first time field as the time, and the first string field as the line
+	fields := []*data.Field{
+		data.NewField("time", nil, times),
+		data.NewField("line", nil, bodies),
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, pipelines),
+		data.NewField("vertex", nil, vertices),
+		data.NewField("isbsvc", nil, isbsvcs),
+		data.NewField("pod", nil, podNames),
+		data.NewField("container", nil, containers),
+	}
+	return data.Frames{data.NewFrame("logs", fields...)}, nil
+}
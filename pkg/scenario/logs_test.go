@@ -0,0 +1,54 @@
+package scenario
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+)
+
+func TestParseLogLines(t *testing.T) {
+	fallback := time.Date(2023, 1, 2, 3, 4, 0, 0, time.UTC)
+	lines := parseLogLines([]byte("no timestamp\n"+
+		"2023-01-02T03:04:05.123456789Z {\"level\":\"error\",\"msg\":\"failed to write\"}\n"+
+		"\tat multi-line entry\n"), fallback)
+	require.Len(t, lines, 3)
+	assert.Equal(t, fallback, lines[0].time)
+	assert.Equal(t, "no timestamp", lines[0].line)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 123456789, time.UTC), lines[1].time)
+	assert.Equal(t, `{"level":"error","msg":"failed to write"}`, lines[1].line)
+	assert.Equal(t, lines[1].time, lines[2].time)
+	assert.Equal(t, "\tat multi-line entry", lines[2].line)
+}
+
+func TestLogsOfVertexContainers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nfClient, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Pipelines: 1, Vertices: 3})
+	require.NoError(t, err)
+
+	rq := query.RunnableQuery{Namespace: pointer.String("ns"), Pipeline: pointer.String("orders"), Vertex: pointer.String("*"), ResourceType: query.VertexResourceType, ResourceName: "*"}
+	now := time.Now()
+	dq := backend.DataQuery{TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now}}
+	frames, err := newLogsFrames(ctx, nfClient, dq, rq)
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	// the fake clientset has a single line without timestamp for any container, numa and udf containers of map vertices
+	containers, _ := frames[0].FieldByName("container")
+	seen := make(map[interface{}]bool)
+	for i := 0; i < containers.Len(); i++ {
+		seen[containers.At(i)] = true
+	}
+	assert.Equal(t, map[interface{}]bool{"numa": true, "udf": true}, seen)
+	vertex, _ := frames[0].FieldByName("vertex")
+	v, ok := vertex.ConcreteAt(0)
+	assert.True(t, ok && v != "", "vertex of the first line")
+	assert.Equal(t, "time", frames[0].Fields[0].Name)
+	assert.Equal(t, "line", frames[0].Fields[1].Name)
+}
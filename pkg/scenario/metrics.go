@@ -0,0 +1,21 @@
+package scenario
+
+import (
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "numaflow_datasource",
+	Subsystem: "scenario",
+	Name:      "query_duration_seconds",
+	Help:      "Duration of building the data frames of a query.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"query_type", "outcome"})
+
+func observeQuery(queryType string, start time.Time, err error) {
+	queryDuration.WithLabelValues(queryType, client.Outcome(err)).Observe(time.Since(start).Seconds())
+}
@@ -1,6 +1,7 @@
 package scenario
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/dseapy/numaflow-datasource/pkg/client"
@@ -8,22 +9,21 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
-	"github.com/numaproj/numaflow/pkg/isb"
+	"github.com/numaproj/numaflow/pkg/apis/proto/daemon"
+	"k8s.io/utils/strings/slices"
 	"math"
 	"strconv"
-	"time"
 )
 
 // Example on how you can structure data frames when returning node graph data.
-func newNodeGraphFrames(nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
-	if rq.ResourceType != query.PipelineResourceType {
-		return nil, errors.New("node graph currently only supports pipelines")
-	}
-	if *rq.Pipeline == "*" || rq.IsMultiPipelineFilter() {
-		return nil, errors.New("node graph currently only supports a single pipeline")
+func newNodeGraphFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	windows, err := rq.GetWindows()
+	if err != nil {
+		return nil, err
 	}
+	window := primaryWindow(windows)
 	queryNamespace := rq.GetNamespace()
-	pipeline, err := nfClient.GetPipeline(queryNamespace, *rq.Pipeline)
+	pipeline, err := nfClient.GetPipeline(ctx, queryNamespace, *rq.Pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -32,11 +32,11 @@ func newNodeGraphFrames(nfClient *client.Client, rq query.RunnableQuery) (data.F
 	}
 
 	// get vertices & edges
-	vertices, err := nfClient.ListPipelineVertices(queryNamespace, *rq.Pipeline)
+	vertices, err := nfClient.ListPipelineVertices(ctx, queryNamespace, *rq.Pipeline)
 	if err != nil {
 		return nil, err
 	}
-	edges, err := nfClient.ListPipelineEdges(queryNamespace, *rq.Pipeline)
+	edges, err := nfClient.ListPipelineEdges(ctx, queryNamespace, *rq.Pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -44,18 +44,35 @@ func newNodeGraphFrames(nfClient *client.Client, rq query.RunnableQuery) (data.F
 	// declare vertex and edge metrics
 	vertexIDs := make([]string, len(vertices))
 	vertexTitles := make([]string, len(vertices))
-	vertexSubtitles := make([]string, len(vertices))       // # pods
-	vertexMainStats := make([]string, len(vertices))       // processing rate
-	vertexSecondaryStats := make([]*string, len(vertices)) // watermark
+	vertexSubtitles := make([]string, len(vertices))      // # pods
+	vertexMainStats := make([]*float64, len(vertices))    // processing rate
+	vertexSecondaryStats := make([]*int64, len(vertices)) // watermark
 	vertexArcSuccess := make([]float32, len(vertices))
 	vertexArcFailure := make([]float32, len(vertices))
 	vertexArcNeutral := make([]float32, len(vertices))
+	vertexDrainTimes := make([]string, len(vertices))
+	vertexCriticalPath := make([]bool, len(vertices))
+	vertexPartitionSkew := make([]string, len(vertices))
+	buffers := make(map[string]*daemon.BufferInfo)
+	for _, e := range edges {
+		if e.BufferName != nil {
+			buffers[*e.BufferName] = e
+		}
+	}
+	drainTimes := make(map[string]*float64)
+	// the other windows, only when all windows are selected
+	vertexWindowStats := make(map[query.Window][]string)
+	for _, w := range windows {
+		if w != window {
+			vertexWindowStats[w] = make([]string, len(vertices))
+		}
+	}
 
 	edgeIDs := make([]string, len(edges))
 	edgeSources := make([]string, len(edges))
 	edgeTargets := make([]string, len(edges))
-	edgeMainStats := make([]string, len(edges))
-	edgeSecondaryStats := make([]string, len(edges))
+	edgeMainStats := make([]*int64, len(edges))        // pending and ack pending messages
+	edgeSecondaryStats := make([]*float64, len(edges)) // buffer usage
 
 	// populate vertex and edge metrics
 	for i := range vertices {
@@ -66,26 +83,30 @@ func newNodeGraphFrames(nfClient *client.Client, rq query.RunnableQuery) (data.F
 			specReplicas = *vertices[i].Spec.Replicas
 		}
 		vertexSubtitles[i] = fmt.Sprintf("%d/%d", vertices[i].Status.Replicas, specReplicas)
-		vMetrics, err := nfClient.GetVertexMetrics(queryNamespace, vertices[i].Spec.PipelineName, vertices[i].Spec.Name)
+		vMetrics, err := nfClient.GetVertexMetrics(ctx, queryNamespace, vertices[i].Spec.PipelineName, vertices[i].Spec.Name)
 		if err != nil {
-			backend.Logger.Error("failed to retrieve metrics for vertex", "namespace", queryNamespace, "pipeline", vertices[i].Spec.PipelineName, "vertex", vertices[i].Spec.Name)
+			partialFailure(ctx, "failed to retrieve metrics for vertex", "namespace", queryNamespace, "pipeline", vertices[i].Spec.PipelineName, "vertex", vertices[i].Spec.Name, "err", err)
 		} else {
-			// Avg rate and pending for autoscaling are both in the map with key "default", see "pkg/metrics/metrics.go".
-			rate, existing := vMetrics.ProcessingRates["default"]
-			if !existing || rate < 0 || rate == isb.RateNotAvailable { // Rate not available
-				backend.Logger.Debug("processing rate not available for vertex", "namespace", queryNamespace, "pipeline", vertices[i].Spec.PipelineName, "vertex", vertices[i].Spec.Name)
+			rate, pending := rateAndPending(vMetrics, window)
+			if rate == nil {
+				backend.Logger.Debug("processing rate not available for vertex", "namespace", queryNamespace, "pipeline", vertices[i].Spec.PipelineName, "vertex", vertices[i].Spec.Name, "window", window)
 			} else {
-				vertexMainStats[i] = strconv.FormatFloat(roundFloat(rate, 2), 'f', -1, 64) + " msg/s"
+				vertexMainStats[i] = rate
+			}
+			drainTimes[vertices[i].Spec.Name] = drainTime(pending, rate)
+			for w := range vertexWindowStats {
+				if wRate, wPending := rateAndPending(vMetrics, w); wRate != nil && wPending != nil {
+					vertexWindowStats[w][i] = fmt.Sprintf("%s, %d pending", formatRate(*wRate), *wPending)
+				}
 			}
 		}
-		vWatermark, err := nfClient.GetVertexWatermark(queryNamespace, vertices[i].Spec.PipelineName, vertices[i].Spec.Name)
+		vertexDrainTimes[i] = formatDrainTime(drainTimes[vertices[i].Spec.Name])
+		vertexPartitionSkew[i] = formatPartitionSkew(vertexPartitions(&vertices[i]), buffers)
+		vWatermark, err := nfClient.GetVertexWatermark(ctx, queryNamespace, vertices[i].Spec.PipelineName, vertices[i].Spec.Name)
 		if err != nil {
-			backend.Logger.Error("failed to retrieve watermark for vertex", "namespace", queryNamespace, "pipeline", vertices[i].Spec.PipelineName, "vertex", vertices[i].Spec.Name)
+			partialFailure(ctx, "failed to retrieve watermark for vertex", "namespace", queryNamespace, "pipeline", vertices[i].Spec.PipelineName, "vertex", vertices[i].Spec.Name, "err", err)
 		} else {
-			if vWatermark.Watermark != nil {
-				t := time.UnixMilli(*vWatermark.Watermark).Format("2006-01-02T15:04:05.000Z")
-				vertexSecondaryStats[i] = &t
-			}
+			vertexSecondaryStats[i] = vWatermark.Watermark
 		}
 		if vertices[i].Status.Phase == v1alpha1.VertexPhaseFailed {
 			vertexArcSuccess[i] = float32(0.0)
@@ -102,6 +123,10 @@ func newNodeGraphFrames(nfClient *client.Client, rq query.RunnableQuery) (data.F
 			vertexArcNeutral[i] = float32(1.0)
 		}
 	}
+	path, pathDrainTime := criticalPath(pipeline, drainTimes)
+	for i := range vertices {
+		vertexCriticalPath[i] = slices.Contains(path, vertices[i].Spec.Name)
+	}
 	for i := range edges {
 		if edges[i].FromVertex == nil {
 			return nil, errors.New("edge from-vertex was nil")
@@ -113,11 +138,10 @@ func newNodeGraphFrames(nfClient *client.Client, rq query.RunnableQuery) (data.F
 		edgeTargets[i] = *edges[i].ToVertex
 		edgeIDs[i] = fmt.Sprintf("%s-%s", edgeSources[i], edgeTargets[i])
 		if edges[i].PendingCount != nil && edges[i].AckPendingCount != nil {
-			edgeMainStats[i] = fmt.Sprintf("%d", *edges[i].PendingCount+*edges[i].AckPendingCount)
-		}
-		if edges[i].BufferUsage != nil {
-			edgeSecondaryStats[i] = strconv.FormatFloat(*edges[i].BufferUsage, 'f', -1, 64) + "%"
+			pending := *edges[i].PendingCount + *edges[i].AckPendingCount
+			edgeMainStats[i] = &pending
 		}
+		edgeSecondaryStats[i] = edges[i].BufferUsage
 	}
 
 	// set return fields with vertex and edge metrics
@@ -127,11 +151,23 @@ func newNodeGraphFrames(nfClient *client.Client, rq query.RunnableQuery) (data.F
 		data.NewField("id", nil, vertexIDs),
 		data.NewField("title", nil, vertexTitles),
 		data.NewField("subtitle", nil, vertexSubtitles),
-		data.NewField("mainstat", nil, vertexMainStats),
-		data.NewField("secondarystat", nil, vertexSecondaryStats),
+		data.NewField("mainstat", nil, vertexMainStats).SetConfig(&data.FieldConfig{DisplayName: "processing rate", Unit: rateUnit}),
+		data.NewField("secondarystat", nil, vertexSecondaryStats).SetConfig(&data.FieldConfig{DisplayName: "watermark", Unit: timestampUnit}),
 		data.NewField("arc__success", nil, vertexArcSuccess).SetConfig(arcSuccessConfig),
 		data.NewField("arc__failure", nil, vertexArcFailure).SetConfig(arcFailureConfig),
 		data.NewField("arc__neutral", nil, vertexArcNeutral),
+		data.NewField("detail__drain_time", nil, vertexDrainTimes).SetConfig(&data.FieldConfig{DisplayName: "drain time"}),
+		data.NewField("detail__critical_path", nil, vertexCriticalPath).SetConfig(&data.FieldConfig{
+			DisplayName: "on critical path (" + formatDrainTime(pathDrainTime) + ")",
+		}),
+		data.NewField("detail__partition_skew", nil, vertexPartitionSkew).SetConfig(&data.FieldConfig{DisplayName: "partition skew"}),
+	}
+	for _, w := range windows {
+		if w != window {
+			vertexFields = append(vertexFields, data.NewField("detail__window_"+string(w), nil, vertexWindowStats[w]).SetConfig(&data.FieldConfig{
+				DisplayName: "window" + windowSuffix(w),
+			}))
+		}
 	}
 	verticesFrame := data.NewFrame("nodes", vertexFields...)
 
@@ -139,14 +175,18 @@ func newNodeGraphFrames(nfClient *client.Client, rq query.RunnableQuery) (data.F
 		data.NewField("id", nil, edgeIDs),
 		data.NewField("source", nil, edgeSources),
 		data.NewField("target", nil, edgeTargets),
-		data.NewField("mainstat", nil, edgeMainStats),
-		data.NewField("secondarystat", nil, edgeSecondaryStats),
+		data.NewField("mainstat", nil, edgeMainStats).SetConfig(&data.FieldConfig{DisplayName: "pending messages", Unit: messagesUnit}),
+		data.NewField("secondarystat", nil, edgeSecondaryStats).SetConfig(withDisplayName(bufferUsageConfig(), "buffer usage")),
 	}
 	edgesFrame := data.NewFrame("edges", edgeFields...)
 
 	return data.Frames{verticesFrame, edgesFrame}, nil
 }
 
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(roundFloat(rate, 2), 'f', -1, 64) + " msg/s"
+}
+
 // TODO: move to a utils
 func roundFloat(val float64, precision uint) float64 {
 	ratio := math.Pow(10, float64(precision))
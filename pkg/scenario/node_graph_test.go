@@ -0,0 +1,45 @@
+package scenario
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+)
+
+func TestNodeGraphStatsHaveUnits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nfClient, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Pipelines: 1, Vertices: 3})
+	require.NoError(t, err)
+
+	rq := query.RunnableQuery{Namespace: pointer.String("ns"), Pipeline: pointer.String("orders"), ResourceType: query.PipelineResourceType}
+	frames, err := newNodeGraphFrames(ctx, nfClient, rq)
+	require.NoError(t, err)
+	require.Len(t, frames, 2)
+
+	for _, tc := range []struct {
+		frame     *data.Frame
+		field     string
+		fieldType data.FieldType
+		unit      string
+	}{
+		{frames[0], "mainstat", data.FieldTypeNullableFloat64, rateUnit},
+		{frames[0], "secondarystat", data.FieldTypeNullableInt64, timestampUnit},
+		{frames[1], "mainstat", data.FieldTypeNullableInt64, messagesUnit},
+		{frames[1], "secondarystat", data.FieldTypeNullableFloat64, ratioUnit},
+	} {
+		field, _ := tc.frame.FieldByName(tc.field)
+		require.NotNil(t, field, "%s of %s", tc.field, tc.frame.Name)
+		assert.Equal(t, tc.fieldType, field.Type(), "%s of %s", tc.field, tc.frame.Name)
+		require.NotNil(t, field.Config, "%s of %s", tc.field, tc.frame.Name)
+		assert.Equal(t, tc.unit, field.Config.Unit, "%s of %s", tc.field, tc.frame.Name)
+		assert.NotEmpty(t, field.Config.DisplayName, "%s of %s", tc.field, tc.frame.Name)
+		assert.Positive(t, tc.frame.Rows())
+	}
+}
@@ -0,0 +1,85 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// maxNotices keeps a query with many failing vertices or pods from flooding the panel.
+const maxNotices = 10
+
+type noticesKey struct{}
+
+// notices collects the partial failures of a query, i.e. failures that only leave out part of its data.
+type notices struct {
+	mu      sync.Mutex
+	texts   []string
+	seen    map[string]bool
+	dropped int
+}
+
+func withNotices(ctx context.Context) (context.Context, *notices) {
+	n := &notices{seen: make(map[string]bool)}
+	return context.WithValue(ctx, noticesKey{}, n), n
+}
+
+// partialFailure logs a failure that leaves out part of the data of a query, and adds it as a warning notice to the
+// frames of the query. keyvals are logged as is, and shown in the notice as key=value pairs.
+func partialFailure(ctx context.Context, msg string, keyvals ...interface{}) {
+	backend.Logger.Error(msg, keyvals...)
+	n, ok := ctx.Value(noticesKey{}).(*notices)
+	if !ok {
+		return
+	}
+	pairs := []string{}
+	var err interface{}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == "err" {
+			err = keyvals[i+1]
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%v=%v", keyvals[i], keyvals[i+1]))
+	}
+	text := msg
+	if len(pairs) > 0 {
+		text += " (" + strings.Join(pairs, ", ") + ")"
+	}
+	if err != nil {
+		text += ": " + fmt.Sprint(err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.seen[text] {
+		return
+	}
+	n.seen[text] = true
+	if len(n.texts) == maxNotices {
+		n.dropped++
+		return
+	}
+	n.texts = append(n.texts, text)
+}
+
+// attach adds the notices to the first frame, so that a panel shows them once.
+func (n *notices) attach(frames data.Frames) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(frames) == 0 || len(n.texts) == 0 {
+		return
+	}
+	texts := n.texts
+	if n.dropped > 0 {
+		texts = append(append([]string{}, texts...), fmt.Sprintf("and %d more failures, see the datasource logs", n.dropped))
+	}
+	if frames[0].Meta == nil {
+		frames[0].Meta = &data.FrameMeta{}
+	}
+	for _, t := range texts {
+		frames[0].Meta.Notices = append(frames[0].Meta.Notices, data.Notice{Severity: data.NoticeSeverityWarning, Text: t})
+	}
+}
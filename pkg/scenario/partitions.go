@@ -0,0 +1,272 @@
+package scenario
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/numaproj/numaflow/pkg/apis/proto/daemon"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// partitionSkewThreshold is the ratio of the busiest partition's pending to the mean pending above which a vertex is skewed.
+	partitionSkewThreshold = 2.0
+	// partitionSkewMinPending keeps a few pending messages from flagging an otherwise idle vertex as skewed.
+	partitionSkewMinPending = 100
+)
+
+// partition is a single partition of a keyed reduce vertex, i.e. an input buffer and the replica reading it.
+type partition struct {
+	index  int
+	buffer string
+}
+
+// vertexPartitions returns the partitions of a reduce vertex, ordered by index, or nil for other vertices.
+// In Numaflow each reduce replica reads the input buffer with its replica index as suffix, see GenerateEdgeBufferNames.
+func vertexPartitions(v *v1alpha1.Vertex) []partition {
+	if !v.IsReduceUDF() {
+		return nil
+	}
+	partitions := []partition{}
+	for _, b := range v.GetFromBuffers() {
+		i := strings.LastIndex(b.Name, "-")
+		index, err := strconv.Atoi(b.Name[i+1:])
+		if i < 0 || err != nil {
+			continue
+		}
+		partitions = append(partitions, partition{index: index, buffer: b.Name})
+	}
+	return partitions
+}
+
+// bufferPending is the number of messages in a buffer that are not yet acknowledged.
+func bufferPending(bInfo *daemon.BufferInfo) *int64 {
+	if bInfo == nil || bInfo.PendingCount == nil || bInfo.AckPendingCount == nil {
+		return nil
+	}
+	pending := *bInfo.PendingCount + *bInfo.AckPendingCount
+	return &pending
+}
+
+// partitionSkew returns the ratio of the busiest partition's pending to the mean pending, and whether it is skewed.
+// Partitions without buffer information are left out.
+func partitionSkew(partitions []partition, buffers map[string]*daemon.BufferInfo) (float64, bool) {
+	max, sum, n := int64(0), int64(0), 0
+	for _, p := range partitions {
+		pending := bufferPending(buffers[p.buffer])
+		if pending == nil {
+			continue
+		}
+		if *pending > max {
+			max = *pending
+		}
+		sum += *pending
+		n++
+	}
+	if n < 2 || sum == 0 {
+		return 1, false
+	}
+	ratio := float64(max) / (float64(sum) / float64(n))
+	return roundFloat(ratio, 2), ratio >= partitionSkewThreshold && max >= partitionSkewMinPending
+}
+
+// formatPartitionSkew renders the skew of a reduce vertex for node graph details, empty for other vertices.
+func formatPartitionSkew(partitions []partition, buffers map[string]*daemon.BufferInfo) string {
+	if len(partitions) < 2 {
+		return ""
+	}
+	ratio, skewed := partitionSkew(partitions, buffers)
+	s := strconv.FormatFloat(ratio, 'f', -1, 64) + "x"
+	if skewed {
+		s += " (skewed)"
+	}
+	return s
+}
+
+// newPartitionsFrames returns a row per partition of the keyed reduce vertices selected by the query.
+// Rates and pendings are scraped from each partition's pod, as the daemon service only reports the first partition.
+func newPartitionsFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	windows, err := rq.GetWindows()
+	if err != nil {
+		return nil, err
+	}
+	window := primaryWindow(windows)
+	vertices, err := listQueryVertices(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	type pipelineKey struct{ namespace, name string }
+	buffers := make(map[pipelineKey]map[string]*daemon.BufferInfo)
+
+	namespaces := []string{}
+	pipelineNames := []string{}
+	names := []string{}
+	indexes := []int64{}
+	bufferNames := []string{}
+	podNames := []*string{}
+	podPhases := []*string{}
+	podReady := []*bool{}
+	processingRate := []*float64{}
+	pendingMessages := []*int64{}
+	drainTimes := []*float64{}
+	windowRates := make(map[query.Window][]*float64)
+	windowPendings := make(map[query.Window][]*int64)
+	bufferPendings := []*int64{}
+	bufferUsages := []*float64{}
+	pendingRatios := []*float64{}
+	skewed := []bool{}
+	cpuUsage := []*int64{}
+	memoryUsage := []*int64{}
+	for i := range vertices {
+		v := vertices[i]
+		partitions := vertexPartitions(&v)
+		if len(partitions) == 0 {
+			continue
+		}
+		pk := pipelineKey{namespace: v.Namespace, name: v.Spec.PipelineName}
+		if _, ok := buffers[pk]; !ok {
+			buffers[pk] = make(map[string]*daemon.BufferInfo)
+			edges, err := nfClient.ListPipelineEdges(ctx, pk.namespace, pk.name)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve edges for pipeline", "namespace", pk.namespace, "pipeline", pk.name, "err", err)
+			}
+			for _, e := range edges {
+				if e.BufferName != nil {
+					buffers[pk][*e.BufferName] = e
+				}
+			}
+		}
+		pods := make(map[int]*v1.Pod)
+		vPods, err := nfClient.ListVertexPods(ctx, v.Namespace, v.Spec.PipelineName, v.Spec.Name)
+		if err != nil {
+			partialFailure(ctx, "failed to retrieve pods for vertex", "namespace", v.Namespace, "pipeline", v.Spec.PipelineName, "vertex", v.Spec.Name, "err", err)
+		}
+		for pi := range vPods {
+			if index, err := strconv.Atoi(vPods[pi].Annotations[v1alpha1.KeyReplica]); err == nil {
+				pods[index] = &vPods[pi]
+			}
+		}
+		_, isSkewed := partitionSkew(partitions, buffers[pk])
+		sum, n := int64(0), 0
+		for _, p := range partitions {
+			if pending := bufferPending(buffers[pk][p.buffer]); pending != nil {
+				sum += *pending
+				n++
+			}
+		}
+
+		for _, p := range partitions {
+			namespaces = append(namespaces, v.Namespace)
+			pipelineNames = append(pipelineNames, v.Spec.PipelineName)
+			names = append(names, v.Spec.Name)
+			indexes = append(indexes, int64(p.index))
+			bufferNames = append(bufferNames, p.buffer)
+			skewed = append(skewed, isSkewed)
+
+			bInfo := buffers[pk][p.buffer]
+			pending := bufferPending(bInfo)
+			bufferPendings = append(bufferPendings, pending)
+			var usage, ratio *float64
+			if bInfo != nil && bInfo.BufferUsage != nil {
+				u := roundFloat(*bInfo.BufferUsage, 4)
+				usage = &u
+			}
+			if pending != nil && sum > 0 {
+				r := roundFloat(float64(*pending)/(float64(sum)/float64(n)), 2)
+				ratio = &r
+			}
+			bufferUsages = append(bufferUsages, usage)
+			pendingRatios = append(pendingRatios, ratio)
+
+			var podName, podPhase *string
+			var ready *bool
+			var rate *float64
+			var podPending *int64
+			var cpu, memory *int64
+			pod, ok := pods[p.index]
+			wRates := make(map[query.Window]*float64)
+			wPendings := make(map[query.Window]*int64)
+			if ok {
+				name, phase, isReady := pod.Name, string(pod.Status.Phase), podIsReady(pod)
+				podName, podPhase, ready = &name, &phase, &isReady
+				if pMetrics, err := nfClient.GetVertexPodMetrics(ctx, pod); err != nil {
+					partialFailure(ctx, "failed to retrieve metrics for pod", "namespace", pod.Namespace, "pod", pod.Name, "err", err)
+				} else {
+					rate, podPending = rateAndPending(pMetrics, window)
+					for _, w := range windows {
+						if w != window {
+							wRates[w], wPendings[w] = rateAndPending(pMetrics, w)
+						}
+					}
+				}
+				if pUsage, err := nfClient.GetPodUsage(ctx, pod); err != nil {
+					partialFailure(ctx, "failed to retrieve usage for pod", "namespace", pod.Namespace, "pod", pod.Name, "err", err)
+				} else {
+					c, m := pUsage.CPUMilli(), megabytes(pUsage.MemoryBytes())
+					cpu, memory = &c, &m
+				}
+			}
+			podNames = append(podNames, podName)
+			podPhases = append(podPhases, podPhase)
+			podReady = append(podReady, ready)
+			processingRate = append(processingRate, rate)
+			pendingMessages = append(pendingMessages, podPending)
+			drainTimes = append(drainTimes, drainTime(podPending, rate))
+			for _, w := range windows {
+				if w != window {
+					windowRates[w] = append(windowRates[w], wRates[w])
+					windowPendings[w] = append(windowPendings[w], wPendings[w])
+				}
+			}
+			cpuUsage = append(cpuUsage, cpu)
+			memoryUsage = append(memoryUsage, memory)
+		}
+	}
+
+	fields := []*data.Field{
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, pipelineNames),
+		data.NewField("vertex", nil, names),
+		data.NewField("partition", nil, indexes),
+		data.NewField("buffer", nil, bufferNames),
+		data.NewField("pod", nil, podNames),
+		data.NewField("pod phase", nil, podPhases),
+		data.NewField("ready", nil, podReady),
+		data.NewField("processing rate", nil, processingRate).SetConfig(unitConfig(rateUnit)),
+		data.NewField("pending messages", nil, pendingMessages).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("drain time", nil, drainTimes).SetConfig(unitConfig(secondsUnit)),
+	}
+	for _, w := range windows {
+		if w == window {
+			continue
+		}
+		fields = append(fields,
+			data.NewField("processing rate"+windowSuffix(w), nil, windowRates[w]).SetConfig(unitConfig(rateUnit)),
+			data.NewField("pending messages"+windowSuffix(w), nil, windowPendings[w]).SetConfig(unitConfig(messagesUnit)),
+		)
+	}
+	fields = append(fields,
+		data.NewField("buffer pending", nil, bufferPendings).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("buffer usage", nil, bufferUsages).SetConfig(bufferUsageConfig()),
+		data.NewField("pending vs mean", nil, pendingRatios).SetConfig(&data.FieldConfig{Unit: "suffix:x", Thresholds: thresholds((1+partitionSkewThreshold)/2, partitionSkewThreshold)}),
+		data.NewField("skewed", nil, skewed),
+		data.NewField("cpu usage", nil, cpuUsage).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("memory usage", nil, memoryUsage).SetConfig(unitConfig(memoryUnit)),
+	)
+	return data.Frames{data.NewFrame("partitions", fields...)}, nil
+}
+
+func podIsReady(pod *v1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+)
+
+type healthStatus string
+
+const (
+	healthyStatus  healthStatus = "healthy"
+	warningStatus  healthStatus = "warning"
+	criticalStatus healthStatus = "critical"
+	// pausedStatus is not scored, as a paused pipeline has no running vertices by design.
+	pausedStatus healthStatus = "paused"
+)
+
+const (
+	criticalPenalty = 40
+	warningPenalty  = 10
+	// watermarkStallWindow is how long a vertex watermark may stay still while there are pending messages.
+	watermarkStallWindow = 5 * time.Minute
+)
+
+// pipelineHealth accumulates the reasons a pipeline is not healthy.
+type pipelineHealth struct {
+	criticals []string
+	warnings  []string
+}
+
+func (h *pipelineHealth) critical(format string, a ...interface{}) {
+	h.criticals = append(h.criticals, fmt.Sprintf(format, a...))
+}
+
+func (h *pipelineHealth) warning(format string, a ...interface{}) {
+	h.warnings = append(h.warnings, fmt.Sprintf(format, a...))
+}
+
+func (h *pipelineHealth) status() healthStatus {
+	if len(h.criticals) > 0 {
+		return criticalStatus
+	}
+	if len(h.warnings) > 0 {
+		return warningStatus
+	}
+	return healthyStatus
+}
+
+func (h *pipelineHealth) score() int64 {
+	score := int64(100 - criticalPenalty*len(h.criticals) - warningPenalty*len(h.warnings))
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+func (h *pipelineHealth) reasons() []string {
+	return append(append([]string{}, h.criticals...), h.warnings...)
+}
+
+// newHealthFrames returns a rolled-up health status, score and reasons per pipeline.
+func newHealthFrames(ctx context.Context, nfClient *client.Client, store *metricstore.Store, rq query.RunnableQuery) (data.Frames, error) {
+	pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	times := make([]time.Time, len(pipelines))
+	namespaces := make([]string, len(pipelines))
+	names := make([]string, len(pipelines))
+	statuses := make([]string, len(pipelines))
+	scores := make([]*int64, len(pipelines))
+	reasons := make([]string, len(pipelines))
+	for i := range pipelines {
+		times[i] = now
+		namespaces[i] = pipelines[i].Namespace
+		names[i] = pipelines[i].Name
+		switch pipelines[i].Status.Phase {
+		case v1alpha1.PipelinePhasePaused, v1alpha1.PipelinePhasePausing:
+			statuses[i] = string(pausedStatus)
+			reasons[i] = fmt.Sprintf("pipeline is %s", strings.ToLower(string(pipelines[i].Status.Phase)))
+			continue
+		}
+		h := checkPipelineHealth(ctx, nfClient, store, &pipelines[i], now)
+		score := h.score()
+		statuses[i] = string(h.status())
+		scores[i] = &score
+		reasons[i] = strings.Join(h.reasons(), "\n")
+	}
+
+	statusMappings := data.ValueMappings{data.ValueMapper{
+		string(healthyStatus):  {Color: "green", Index: 0},
+		string(warningStatus):  {Color: "orange", Index: 1},
+		string(criticalStatus): {Color: "red", Index: 2},
+		string(pausedStatus):   {Color: "blue", Index: 3},
+	}}
+	// any warning turns the score orange, a critical reason or four warnings turn it red
+	scoreConfig := (&data.FieldConfig{}).SetMin(0).SetMax(100)
+	scoreConfig.Thresholds = &data.ThresholdsConfig{
+		Mode: data.ThresholdsModeAbsolute,
+		Steps: []data.Threshold{
+			data.NewThreshold(math.Inf(-1), "red", ""),
+			data.NewThreshold(100-criticalPenalty+1, "orange", ""),
+			data.NewThreshold(100, "green", ""),
+		},
+	}
+	fields := []*data.Field{
+		data.NewField("time", nil, times),
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, names),
+		data.NewField("status", nil, statuses).SetConfig(&data.FieldConfig{Mappings: statusMappings}),
+		data.NewField("score", nil, scores).SetConfig(scoreConfig),
+		data.NewField("reasons", nil, reasons),
+	}
+	return data.Frames{data.NewFrame("health", fields...)}, nil
+}
+
+func checkPipelineHealth(ctx context.Context, nfClient *client.Client, store *metricstore.Store, pl *v1alpha1.Pipeline, now time.Time) *pipelineHealth {
+	h := &pipelineHealth{}
+	if pl.Status.Phase == v1alpha1.PipelinePhaseFailed {
+		h.critical("pipeline failed: %s", pl.Status.Message)
+	}
+
+	vertices, err := nfClient.ListPipelineVertices(ctx, pl.Namespace, pl.Name)
+	if err != nil {
+		h.critical("vertices unavailable: %v", err)
+	}
+	for _, v := range vertices {
+		switch v.Status.Phase {
+		case v1alpha1.VertexPhaseFailed:
+			h.critical("vertex %s failed: %s", v.Spec.Name, v.Status.Message)
+		case v1alpha1.VertexPhaseRunning, v1alpha1.VertexPhaseSucceeded:
+		default:
+			h.warning("vertex %s is %s", v.Spec.Name, strings.ToLower(string(v.Status.Phase)))
+		}
+		if desired := v.GetReplicas(); int(v.Status.Replicas) < desired {
+			h.warning("vertex %s has %d of %d replicas", v.Spec.Name, v.Status.Replicas, desired)
+		}
+	}
+
+	edges, err := nfClient.ListPipelineEdges(ctx, pl.Namespace, pl.Name)
+	if err != nil {
+		// buffers and watermarks are only available through the daemon service
+		h.critical("daemon service unreachable: %v", err)
+		return h
+	}
+	for _, e := range edges {
+		if e.IsFull != nil && *e.IsFull && e.BufferName != nil {
+			h.critical("buffer %s is full", *e.BufferName)
+		}
+	}
+
+	for _, v := range vertices {
+		if stalled, since := watermarkStalled(ctx, nfClient, store, &v, now); stalled {
+			h.warning("vertex %s watermark has not advanced for %s with pending messages", v.Spec.Name, since.Round(time.Second))
+		}
+	}
+	return h
+}
+
+// watermarkStalled reports whether a vertex with pending messages has a watermark that did not advance within watermarkStallWindow.
+// With the metric store, the recorded watermarks over the window are compared, otherwise the watermark lag is used,
+// which also flags vertices processing old events.
+func watermarkStalled(ctx context.Context, nfClient *client.Client, store *metricstore.Store, v *v1alpha1.Vertex, now time.Time) (bool, time.Duration) {
+	vMetrics, err := nfClient.GetVertexMetrics(ctx, v.Namespace, v.Spec.PipelineName, v.Spec.Name)
+	if err != nil {
+		partialFailure(ctx, "failed to retrieve metrics for vertex", "namespace", v.Namespace, "pipeline", v.Spec.PipelineName, "vertex", v.Spec.Name, "err", err)
+		return false, 0
+	}
+	if _, pending := defaultRateAndPending(vMetrics); pending == nil || *pending == 0 {
+		return false, 0
+	}
+	vWatermark, err := nfClient.GetVertexWatermark(ctx, v.Namespace, v.Spec.PipelineName, v.Spec.Name)
+	if err != nil {
+		partialFailure(ctx, "failed to retrieve watermark for vertex", "namespace", v.Namespace, "pipeline", v.Spec.PipelineName, "vertex", v.Spec.Name, "err", err)
+		return false, 0
+	}
+	if vWatermark.IsWatermarkEnabled == nil || !*vWatermark.IsWatermarkEnabled || vWatermark.Watermark == nil || *vWatermark.Watermark < 0 {
+		return false, 0
+	}
+	if store != nil {
+		key := metricstore.SeriesKey{Namespace: v.Namespace, Pipeline: v.Spec.PipelineName, Vertex: v.Spec.Name, Metric: metricstore.WatermarkMetric}
+		samples, err := store.Query(key, now.Add(-watermarkStallWindow), now)
+		if err != nil {
+			partialFailure(ctx, "failed to query watermarks of vertex", "namespace", v.Namespace, "pipeline", v.Spec.PipelineName, "vertex", v.Spec.Name, "err", err)
+			return false, 0
+		}
+		// too few samples to tell, e.g. right after the datasource started
+		if len(samples) < 2 || samples[len(samples)-1].Time.Sub(samples[0].Time) < watermarkStallWindow/2 {
+			return false, 0
+		}
+		first, last := samples[0], samples[len(samples)-1]
+		if float64(*vWatermark.Watermark) > last.Value || first.Value != last.Value {
+			return false, 0
+		}
+		return true, now.Sub(first.Time)
+	}
+	lag := now.Sub(time.UnixMilli(*vWatermark.Watermark))
+	return lag > watermarkStallWindow, lag
+}
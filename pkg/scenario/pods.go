@@ -0,0 +1,171 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// resourceColumns are the pipeline, vertex and isbsvc columns of a row about a Numaflow resource or one of its pods.
+// The pipeline, and the vertex unless it is the daemon service, are set for pipelines, the isbsvc for isbsvcs.
+type resourceColumns struct {
+	pipeline *string
+	vertex   *string
+	isbsvc   *string
+}
+
+// queryPod is a pod of a resource selected by a query.
+type queryPod struct {
+	pod *v1.Pod
+	resourceColumns
+}
+
+func newPipelinePod(pod *v1.Pod) queryPod {
+	p := queryPod{pod: pod}
+	if pl, ok := pod.Labels[v1alpha1.KeyPipelineName]; ok {
+		p.pipeline = &pl
+	}
+	if v, ok := pod.Labels[v1alpha1.KeyVertexName]; ok {
+		p.vertex = &v
+	}
+	return p
+}
+
+// listQueryPods returns the pods of the selected pipelines, vertices or isbsvcs, or the selected pods of a vertex or an
+// isbsvc. Pods of a resource that cannot be listed are left out as a partial failure.
+func listQueryPods(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) ([]queryPod, error) {
+	pods := []queryPod{}
+	switch {
+	case rq.ResourceType == query.PipelineResourceType:
+		pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, pl := range pipelines {
+			p, err := nfClient.ListPipelinePods(ctx, pl.Namespace, pl.Name)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve pods for pipeline", "namespace", pl.Namespace, "pipeline", pl.Name, "err", err)
+				continue
+			}
+			for i := range p {
+				pods = append(pods, newPipelinePod(&p[i]))
+			}
+		}
+	case rq.ResourceType == query.VertexResourceType || rq.ResourceType == query.PodResourceType && rq.Vertex != nil:
+		vertices, err := listQueryVertices(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vertices {
+			p, err := nfClient.ListVertexPods(ctx, v.Namespace, v.Spec.PipelineName, v.Spec.Name)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve pods for vertex", "namespace", v.Namespace, "pipeline", v.Spec.PipelineName, "vertex", v.Spec.Name, "err", err)
+				continue
+			}
+			for i := range p {
+				pods = append(pods, newPipelinePod(&p[i]))
+			}
+		}
+	case rq.ResourceType == query.IsbsvcResourceType || rq.ResourceType == query.PodResourceType:
+		isbsvcs, err := listQueryInterStepBufferServices(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, isbsvc := range isbsvcs {
+			p, err := nfClient.ListInterStepBufferServicePods(ctx, isbsvc.Namespace, isbsvc.Name)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve pods for isbsvc", "namespace", isbsvc.Namespace, "isbsvc", isbsvc.Name, "err", err)
+				continue
+			}
+			for i := range p {
+				name := isbsvc.Name
+				pods = append(pods, queryPod{pod: &p[i], resourceColumns: resourceColumns{isbsvc: &name}})
+			}
+		}
+	default:
+		return nil, query.Errorf(query.ValidationError, "pods currently only supports pipelines, vertices, isbsvcs and pods")
+	}
+	if rq.ResourceType != query.PodResourceType || rq.ResourceName == "*" {
+		return pods, nil
+	}
+	for _, p := range pods {
+		if p.pod.Name == rq.ResourceName {
+			return []queryPod{p}, nil
+		}
+	}
+	return nil, query.Errorf(query.NotFoundError, "pod %q not found", rq.ResourceName)
+}
+
+// podReadiness returns the ready and total containers of a pod, e.g. "1/2", its restarts, and the reason the last
+// restarted container terminated, e.g. OOMKilled.
+func podReadiness(pod *v1.Pod) (string, int64, *string) {
+	ready := 0
+	restarts := int64(0)
+	var lastTermination *string
+	var lastFinished time.Time
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Ready {
+			ready++
+		}
+		restarts += int64(cs.RestartCount)
+		if t := cs.LastTerminationState.Terminated; t != nil && !t.FinishedAt.Time.Before(lastFinished) {
+			reason := t.Reason
+			lastTermination, lastFinished = &reason, t.FinishedAt.Time
+		}
+	}
+	return fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)), restarts, lastTermination
+}
+
+// newPodsFrames returns a row per pod of the selected resources, with its readiness, restarts and node.
+func newPodsFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	pods, err := listQueryPods(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make([]string, len(pods))
+	pipelines := make([]*string, len(pods))
+	vertices := make([]*string, len(pods))
+	isbsvcs := make([]*string, len(pods))
+	names := make([]string, len(pods))
+	phases := make([]string, len(pods))
+	ready := make([]string, len(pods))
+	restarts := make([]int64, len(pods))
+	lastTerminations := make([]*string, len(pods))
+	nodes := make([]string, len(pods))
+	ips := make([]string, len(pods))
+	startTimes := make([]*time.Time, len(pods))
+	for i, p := range pods {
+		namespaces[i] = p.pod.Namespace
+		pipelines[i], vertices[i], isbsvcs[i] = p.pipeline, p.vertex, p.isbsvc
+		names[i] = p.pod.Name
+		phases[i] = string(p.pod.Status.Phase)
+		ready[i], restarts[i], lastTerminations[i] = podReadiness(p.pod)
+		nodes[i] = p.pod.Spec.NodeName
+		ips[i] = p.pod.Status.PodIP
+		if p.pod.Status.StartTime != nil {
+			startTimes[i] = &p.pod.Status.StartTime.Time
+		}
+	}
+
+	fields := []*data.Field{
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, pipelines),
+		data.NewField("vertex", nil, vertices),
+		data.NewField("isbsvc", nil, isbsvcs),
+		data.NewField("pod", nil, names),
+		data.NewField("phase", nil, phases),
+		data.NewField("ready", nil, ready),
+		data.NewField("restarts", nil, restarts),
+		data.NewField("last termination", nil, lastTerminations),
+		data.NewField("node", nil, nodes),
+		data.NewField("ip", nil, ips),
+		data.NewField("start time", nil, startTimes),
+	}
+	return data.Frames{data.NewFrame("pods", setDisplayNames(fields)...)}, nil
+}
@@ -0,0 +1,63 @@
+package scenario
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+)
+
+func TestPodsOfResources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nfClient, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Pipelines: 1, Vertices: 3})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name    string
+		rq      query.RunnableQuery
+		column  string
+		want    string
+		minRows int
+	}{
+		{"pipeline", query.RunnableQuery{Pipeline: pointer.String("orders"), ResourceType: query.PipelineResourceType, ResourceName: "orders"}, "pipeline", "orders", 3},
+		{"vertex", query.RunnableQuery{Pipeline: pointer.String("orders"), Vertex: pointer.String("in"), ResourceType: query.VertexResourceType, ResourceName: "in"}, "vertex", "in", 1},
+		{"isbsvc", query.RunnableQuery{InterStepBufferService: pointer.String("default"), ResourceType: query.IsbsvcResourceType, ResourceName: "default"}, "isbsvc", "default", 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.rq.Namespace = pointer.String("ns")
+			frames, err := newPodsFrames(ctx, nfClient, tc.rq)
+			require.NoError(t, err)
+			require.Len(t, frames, 1)
+			require.GreaterOrEqual(t, frames[0].Rows(), tc.minRows)
+			column, _ := frames[0].FieldByName(tc.column)
+			for i := 0; i < column.Len(); i++ {
+				v, ok := column.ConcreteAt(i)
+				require.True(t, ok, "%s of row %d", tc.column, i)
+				assert.Equal(t, tc.want, v)
+			}
+		})
+	}
+
+	// pod names end with a random suffix
+	rq := query.RunnableQuery{Namespace: pointer.String("ns"), Pipeline: pointer.String("orders"), Vertex: pointer.String("in"), ResourceType: query.VertexResourceType, ResourceName: "in"}
+	frames, err := newPodsFrames(ctx, nfClient, rq)
+	require.NoError(t, err)
+	pod, _ := frames[0].FieldByName("pod")
+	rq.Pod = pointer.String(pod.At(0).(string))
+	rq.ResourceType, rq.ResourceName = query.PodResourceType, *rq.Pod
+	frames, err = newPodsFrames(ctx, nfClient, rq)
+	require.NoError(t, err)
+	require.Equal(t, 1, frames[0].Rows())
+	ready, _ := frames[0].FieldByName("ready")
+	assert.Regexp(t, `^\d+/\d+$`, ready.At(0))
+
+	rq.Pod = pointer.String("orders-in-9-xxxxx")
+	rq.ResourceName = *rq.Pod
+	_, err = newPodsFrames(ctx, nfClient, rq)
+	assert.Equal(t, query.NotFoundError, query.ClassifyError(err, "").Kind)
+}
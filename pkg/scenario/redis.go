@@ -0,0 +1,118 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+)
+
+// newRedisFrames returns the Redis stream and consumer group statistics of the buffers of the selected pipelines.
+// Pipelines whose isbsvc is not Redis, or whose isbsvc cannot be reached, get a row per buffer with the error.
+func newRedisFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := []string{}
+	pipelineNames := []string{}
+	isbsvcNames := []string{}
+	buffers := []string{}
+	toVertices := []string{}
+	streams := []string{}
+	groups := []string{}
+	lengths := []*int64{}
+	pending := []*int64{}
+	lags := []*int64{}
+	consumers := []*int64{}
+	lastDeliveredIDs := []*string{}
+	memory := []*int64{}
+	errs := []*string{}
+	for i := range pipelines {
+		pl := &pipelines[i]
+		isbsvcName := pipelineISBSvcName(pl)
+		bufferNames := []string{}
+		for _, b := range pl.GetAllBuffers() {
+			// only edge buffers are backed by streams
+			if b.Type == v1alpha1.EdgeBuffer {
+				bufferNames = append(bufferNames, b.Name)
+			}
+		}
+		bufferVertices := make(map[string]string)
+		for _, e := range pl.ListAllEdges() {
+			for _, name := range v1alpha1.GenerateEdgeBufferNames(pl.Namespace, pl.Name, e) {
+				bufferVertices[name] = e.To
+			}
+		}
+		stats, err := pipelineRedisStats(ctx, nfClient, pl, isbsvcName, bufferNames)
+		for j, buffer := range bufferNames {
+			namespaces = append(namespaces, pl.Namespace)
+			pipelineNames = append(pipelineNames, pl.Name)
+			isbsvcNames = append(isbsvcNames, isbsvcName)
+			buffers = append(buffers, buffer)
+			toVertices = append(toVertices, bufferVertices[buffer])
+			streams = append(streams, client.RedisStreamName(buffer))
+			groups = append(groups, client.RedisGroupName(buffer))
+			s := &client.RedisStreamStats{Err: err}
+			if err == nil {
+				s = &stats[j]
+			}
+			if s.Err != nil {
+				msg := s.Err.Error()
+				errs = append(errs, &msg)
+			} else {
+				errs = append(errs, nil)
+			}
+			var lastDeliveredID *string
+			if s.LastDeliveredID != "" {
+				lastDeliveredID = &s.LastDeliveredID
+			}
+			lengths = append(lengths, s.Length)
+			pending = append(pending, s.Pending)
+			lags = append(lags, s.Lag)
+			consumers = append(consumers, s.Consumers)
+			lastDeliveredIDs = append(lastDeliveredIDs, lastDeliveredID)
+			memory = append(memory, s.MemoryBytes)
+		}
+	}
+
+	fields := []*data.Field{
+		data.NewField("namespace", nil, namespaces),
+		data.NewField("pipeline", nil, pipelineNames),
+		data.NewField("isbsvc", nil, isbsvcNames),
+		data.NewField("buffer", nil, buffers),
+		data.NewField("to vertex", nil, toVertices),
+		data.NewField("stream", nil, streams),
+		data.NewField("group", nil, groups),
+		data.NewField("length", nil, lengths).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("pending", nil, pending).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("lag", nil, lags).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("consumers", nil, consumers),
+		data.NewField("last delivered id", nil, lastDeliveredIDs),
+		data.NewField("memory", nil, memory).SetConfig(unitConfig(bytesUnit)),
+		data.NewField("error", nil, errs),
+	}
+	return data.Frames{data.NewFrame("redis", fields...)}, nil
+}
+
+// pipelineRedisStats connects to the pipeline's isbsvc and reads the stream statistics of the given buffers.
+func pipelineRedisStats(ctx context.Context, nfClient *client.Client, pl *v1alpha1.Pipeline, isbsvcName string, buffers []string) ([]client.RedisStreamStats, error) {
+	isbsvc, err := nfClient.GetInterStepBufferService(ctx, pl.Namespace, isbsvcName)
+	if err != nil {
+		return nil, err
+	}
+	if isbsvc.Spec.Redis == nil {
+		return nil, &query.Error{Kind: query.ValidationError, Object: fmt.Sprintf("isbsvc %q", pl.Namespace+"/"+isbsvcName), Err: errors.New("not a redis isbsvc")}
+	}
+	rdb, err := nfClient.ConnectRedis(ctx, isbsvc)
+	if err != nil {
+		return nil, err
+	}
+	defer rdb.Close()
+	return client.GetRedisBufferStats(ctx, rdb, buffers)
+}
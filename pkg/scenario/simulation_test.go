@@ -0,0 +1,84 @@
+package scenario
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/history"
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/dseapy/numaflow-datasource/pkg/resource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+)
+
+// TestQueryTypesAgainstSimulatedCluster runs every query type against the simulated cluster, which is what the
+// datasource serves when it is configured with the simulated cluster.
+func TestQueryTypesAgainstSimulatedCluster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nfClient, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Pipelines: 3, Vertices: 4})
+	require.NoError(t, err)
+	metrics, err := metricstore.Open(filepath.Join(t.TempDir(), "metrics.db"))
+	require.NoError(t, err)
+	defer metrics.Close()
+	now := time.Now()
+	// the metric store is filled by a collector in the plugin, a sample is enough for time series queries
+	require.NoError(t, metrics.Append(now.Add(-time.Minute), map[metricstore.SeriesKey]float64{
+		{Namespace: "ns", Pipeline: "clickstream", Vertex: "in", Metric: metricstore.ProcessingRateMetric}: 250,
+	}))
+	opts := Options{History: history.NewStore(10), Metrics: metrics}
+	timeRange := backend.TimeRange{From: now.Add(-time.Hour), To: now}
+
+	// clickstream is on the JetStream isbsvc and has a reduce vertex, payments is on the Redis isbsvc
+	for _, queryType := range resource.QueryTypes() {
+		t.Run(queryType, func(t *testing.T) {
+			rq := query.RunnableQuery{
+				Namespace:    pointer.String("ns"),
+				Pipeline:     pointer.String("clickstream"),
+				ResourceType: query.PipelineResourceType,
+				ResourceName: "clickstream",
+			}
+			switch queryType {
+			case resource.UtilizationQueryType, resource.AutoscalingQueryType, resource.PartitionsQueryType:
+				rq.Vertex, rq.ResourceType, rq.ResourceName = pointer.String("reduce"), query.VertexResourceType, "reduce"
+			case resource.RedisQueryType:
+				rq.Pipeline, rq.ResourceName = pointer.String("payments"), "payments"
+			}
+			dq := backend.DataQuery{RefID: "A", QueryType: queryType, TimeRange: timeRange}
+			frames, err := NewDataFrames(ctx, nfClient, dq, rq, opts)
+			require.NoError(t, err)
+			require.NotEmpty(t, frames)
+			for _, f := range frames {
+				require.NotNil(t, f.Meta, "meta of frame %q", f.Name)
+				assert.NotEmpty(t, f.Meta.ExecutedQueryString, "executed query of frame %q", f.Name)
+			}
+		})
+	}
+}
+
+func TestSimulatedSpecRedactsFakeToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nfClient, err := client.NewSimulatedClient(ctx, "ns", client.SimulationOptions{Pipelines: 2, Vertices: 4})
+	require.NoError(t, err)
+
+	rq := query.RunnableQuery{
+		Namespace:    pointer.String("ns"),
+		Pipeline:     pointer.String("clickstream"),
+		ResourceType: query.PipelineResourceType,
+		ResourceName: "clickstream",
+	}
+	frames, err := newSpecFrames(ctx, nfClient, rq, RedactionRules{EnvNamePattern: regexp.MustCompile("(?i)token")})
+	require.NoError(t, err)
+	spec, _ := frames[0].FieldByName("spec")
+	out := spec.At(0).(string)
+	assert.Contains(t, out, "FAKE_SIMULATED_TOKEN")
+	assert.NotContains(t, out, "not-a-real-token")
+}
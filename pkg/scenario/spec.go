@@ -0,0 +1,177 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+const redactedValue = "<redacted>"
+
+// RedactionRules controls which parts of a resource spec are hidden before it is returned.
+type RedactionRules struct {
+	// EnvValues redacts the value of every environment variable.
+	EnvValues bool
+	// EnvNamePattern redacts the value of environment variables whose name matches, when EnvValues is false.
+	EnvNamePattern *regexp.Regexp
+	// SecretRefs redacts the secret name and key of secretKeyRef and secretRef entries, and of every other
+	// SecretKeySelector, e.g. redis passwords and kafka TLS secrets.
+	SecretRefs bool
+}
+
+func newSpecFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery, rules RedactionRules) (data.Frames, error) {
+	if rq.IsMultiNamespaceFilter() {
+		return nil, query.Errorf(query.ValidationError, "spec queries only support a single namespace")
+	}
+	queryNamespace := rq.GetNamespace()
+	var obj interface{}
+	switch rq.ResourceType {
+	case query.PipelineResourceType:
+		pl, err := nfClient.GetPipeline(ctx, queryNamespace, *rq.Pipeline)
+		if err != nil {
+			return nil, err
+		}
+		pl = pl.DeepCopy()
+		pl.ManagedFields = nil
+		pl.SetGroupVersionKind(v1alpha1.PipelineGroupVersionKind)
+		obj = pl
+	case query.VertexResourceType:
+		vertex, err := nfClient.GetPipelineVertex(ctx, queryNamespace, *rq.Pipeline, *rq.Vertex)
+		if err != nil {
+			return nil, err
+		}
+		vertex = vertex.DeepCopy()
+		vertex.ManagedFields = nil
+		vertex.SetGroupVersionKind(v1alpha1.VertexGroupVersionKind)
+		obj = vertex
+	case query.IsbsvcResourceType:
+		isbsvc, err := nfClient.GetInterStepBufferService(ctx, queryNamespace, *rq.InterStepBufferService)
+		if err != nil {
+			return nil, err
+		}
+		isbsvc = isbsvc.DeepCopy()
+		isbsvc.ManagedFields = nil
+		isbsvc.SetGroupVersionKind(v1alpha1.ISBGroupVersionKind)
+		obj = isbsvc
+	default:
+		return nil, query.Errorf(query.ValidationError, "spec does not support resource type %q", rq.ResourceType)
+	}
+
+	spec, err := renderSpec(obj, rq.GetFormat(), rules)
+	if err != nil {
+		return nil, err
+	}
+	fields := []*data.Field{
+		data.NewField("namespace", nil, []string{queryNamespace}),
+		data.NewField("name", nil, []string{rq.ResourceName}),
+		data.NewField("spec", nil, []string{spec}),
+	}
+	return data.Frames{data.NewFrame("spec", fields...)}, nil
+}
+
+// renderSpec converts obj to generic JSON, applies the redaction rules and encodes it in the requested format.
+func renderSpec(obj interface{}, format query.Format, rules RedactionRules) (string, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return "", err
+	}
+	redact(m, rules)
+
+	switch format {
+	case query.YAMLFormat:
+		out, err := yaml.Marshal(m)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	case query.JSONFormat:
+		out, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+	return "", query.Errorf(query.ValidationError, "unsupported format %q, must be %q or %q", format, query.YAMLFormat, query.JSONFormat)
+}
+
+// redact walks the generic object and replaces sensitive values in place.
+func redact(v interface{}, rules RedactionRules) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			switch k {
+			case "env":
+				if envs, ok := child.([]interface{}); ok {
+					redactEnv(envs, rules)
+				}
+			case "secretKeyRef", "secretRef":
+				if rules.SecretRefs {
+					if ref, ok := child.(map[string]interface{}); ok {
+						redactSecretRef(ref)
+					}
+				}
+			case "configMapKeyRef":
+			default:
+				// numaflow references secrets, e.g. redis and NATS passwords, kafka TLS certificates and SASL
+				// credentials, with SecretKeySelectors under keys of their own
+				if ref, ok := child.(map[string]interface{}); ok && rules.SecretRefs && isSecretKeySelector(ref) {
+					redactSecretRef(ref)
+				}
+			}
+			redact(child, rules)
+		}
+	case []interface{}:
+		for _, child := range t {
+			redact(child, rules)
+		}
+	}
+}
+
+func redactSecretRef(ref map[string]interface{}) {
+	for _, rk := range []string{"name", "key"} {
+		if _, ok := ref[rk]; ok {
+			ref[rk] = redactedValue
+		}
+	}
+}
+
+// isSecretKeySelector returns whether m has the shape of a SecretKeySelector, a name and key and optionally optional.
+func isSecretKeySelector(m map[string]interface{}) bool {
+	_, hasName := m["name"]
+	_, hasKey := m["key"]
+	if !hasName || !hasKey {
+		return false
+	}
+	for k := range m {
+		if k != "name" && k != "key" && k != "optional" {
+			return false
+		}
+	}
+	return true
+}
+
+func redactEnv(envs []interface{}, rules RedactionRules) {
+	for _, e := range envs {
+		env, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := env["value"]; !ok {
+			continue
+		}
+		name, _ := env["name"].(string)
+		if rules.EnvValues || (rules.EnvNamePattern != nil && rules.EnvNamePattern.MatchString(name)) {
+			env["value"] = redactedValue
+		}
+	}
+}
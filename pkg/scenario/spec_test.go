@@ -0,0 +1,110 @@
+package scenario
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func secretKeySelector(name, key string) *corev1.SecretKeySelector {
+	return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+}
+
+func TestRenderSpecRedactsSecretKeySelectors(t *testing.T) {
+	rules := RedactionRules{EnvNamePattern: regexp.MustCompile("(?i)token"), SecretRefs: true}
+	tls := &v1alpha1.TLS{
+		InsecureSkipVerify: true,
+		CACertSecret:       secretKeySelector("kafka-ca-secret", "ca-key"),
+		CertSecret:         secretKeySelector("kafka-cert-secret", "cert-key"),
+		KeySecret:          secretKeySelector("kafka-key-secret", "key-key"),
+	}
+	pl := &v1alpha1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pl"},
+		Spec: v1alpha1.PipelineSpec{
+			Vertices: []v1alpha1.AbstractVertex{
+				{Name: "in", Source: &v1alpha1.Source{Kafka: &v1alpha1.KafkaSource{Topic: "in", TLS: tls}}},
+				{Name: "http", Source: &v1alpha1.Source{HTTP: &v1alpha1.HTTPSource{Auth: &v1alpha1.Authorization{Token: secretKeySelector("http-token-secret", "token-key")}}}},
+				{
+					Name: "map",
+					UDF: &v1alpha1.UDF{Container: &v1alpha1.Container{
+						Image: "map",
+						Env: []corev1.EnvVar{
+							{Name: "API_TOKEN", Value: "env-token-value"},
+							{Name: "DB_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: secretKeySelector("env-secret", "env-key")}},
+							{Name: "LEVEL", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "log-config"}, Key: "level"}}},
+						},
+					}},
+				},
+				{Name: "out", Sink: &v1alpha1.Sink{Kafka: &v1alpha1.KafkaSink{Topic: "out", TLS: tls}}},
+			},
+		},
+	}
+	isbsvc := &v1alpha1.InterStepBufferService{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "default"},
+		Status: v1alpha1.InterStepBufferServiceStatus{
+			Config: v1alpha1.BufferServiceConfig{
+				Redis: &v1alpha1.RedisConfig{
+					URL:              "redis:6379",
+					Password:         secretKeySelector("redis-password-secret", "redis-password-key"),
+					SentinelPassword: secretKeySelector("redis-sentinel-secret", "redis-sentinel-key"),
+				},
+				JetStream: &v1alpha1.JetStreamConfig{
+					URL: "nats://isbsvc-default-js-svc:4222",
+					Auth: &v1alpha1.NATSAuth{
+						User:     secretKeySelector("nats-user-secret", "nats-user-key"),
+						Password: secretKeySelector("nats-password-secret", "nats-password-key"),
+					},
+				},
+			},
+		},
+	}
+	// numaflow v0.6 has no SASL type yet, later versions reference the credentials with SecretKeySelectors
+	sasl := map[string]interface{}{
+		"kafka": map[string]interface{}{
+			"sasl": map[string]interface{}{
+				"mechanism": "PLAIN",
+				"plain": map[string]interface{}{
+					"user":     map[string]interface{}{"name": "sasl-user-secret", "key": "sasl-user-key"},
+					"password": map[string]interface{}{"name": "sasl-password-secret", "key": "sasl-password-key", "optional": false},
+				},
+			},
+		},
+	}
+
+	for name, obj := range map[string]interface{}{"pipeline": pl, "isbsvc": isbsvc, "sasl": sasl} {
+		t.Run(name, func(t *testing.T) {
+			out, err := renderSpec(obj, query.YAMLFormat, rules)
+			require.NoError(t, err)
+			for _, leaked := range regexp.MustCompile(`\b[a-z]+(-[a-z]+)*-(secret|key|value)\b`).FindAllString(out, -1) {
+				t.Errorf("%s is not redacted in\n%s", leaked, out)
+			}
+			assert.Contains(t, out, redactedValue)
+		})
+	}
+
+	out, err := renderSpec(pl, query.YAMLFormat, rules)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(out, "name: log-config") && strings.Contains(out, "key: level"), "config map references are kept")
+	assert.Contains(t, out, "name: API_TOKEN")
+}
+
+func TestRenderSpecKeepsSecretRefsWhenDisabled(t *testing.T) {
+	isbsvc := &v1alpha1.InterStepBufferService{
+		Status: v1alpha1.InterStepBufferServiceStatus{
+			Config: v1alpha1.BufferServiceConfig{
+				Redis: &v1alpha1.RedisConfig{Password: secretKeySelector("redis-password-secret", "redis-password-key")},
+			},
+		},
+	}
+	out, err := renderSpec(isbsvc, query.YAMLFormat, RedactionRules{})
+	require.NoError(t, err)
+	assert.Contains(t, out, "redis-password-secret")
+	assert.NotContains(t, out, redactedValue)
+}
@@ -1,37 +1,40 @@
 package scenario
 
 import (
+	"context"
 	"errors"
 	"github.com/dseapy/numaflow-datasource/pkg/client"
 	"github.com/dseapy/numaflow-datasource/pkg/query"
 	"k8s.io/utils/pointer"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/numaproj/numaflow/pkg/apis/numaflow/v1alpha1"
-	"github.com/numaproj/numaflow/pkg/isb"
 	"k8s.io/utils/strings/slices"
 )
 
-func newTableFrames(client *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+func newTableFrames(ctx context.Context, client *client.Client, rq query.RunnableQuery) (data.Frames, error) {
 	switch rq.ResourceType {
 	case query.PipelineResourceType:
-		return newPipelineTableFrames(client, rq)
+		return newPipelineTableFrames(ctx, client, rq)
 	case query.VertexResourceType:
-		return newVertexTableFrames(client, rq)
+		return newVertexTableFrames(ctx, client, rq)
 	case query.IsbsvcResourceType:
-		return newIsbsvcTableFrames(client, rq)
+		return newIsbsvcTableFrames(ctx, client, rq)
 	}
 	return nil, errors.New("unknown query resource type, this shouldn't happen")
 }
 
-func newPipelineTableFrames(nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+// listQueryPipelines returns the pipelines selected by a pipeline query.
+func listQueryPipelines(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) ([]v1alpha1.Pipeline, error) {
 	queryNamespace := rq.GetNamespace()
 	queryFilterNamespaces := rq.GetFilterNamespaces()
 	pipelines := []v1alpha1.Pipeline{}
 	if *rq.Pipeline == "*" {
-		p, err := nfClient.ListPipelines(queryNamespace)
+		p, err := nfClient.ListPipelines(ctx, queryNamespace)
 		if err != nil {
 			return nil, err
 		}
@@ -41,12 +44,20 @@ func newPipelineTableFrames(nfClient *client.Client, rq query.RunnableQuery) (da
 			}
 		}
 	} else {
-		pipeline, err := nfClient.GetPipeline(queryNamespace, *rq.Pipeline)
+		pipeline, err := nfClient.GetPipeline(ctx, queryNamespace, *rq.Pipeline)
 		if err != nil {
 			return nil, err
 		}
 		pipelines = []v1alpha1.Pipeline{*pipeline}
 	}
+	return pipelines, nil
+}
+
+func newPipelineTableFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
 	namespaces := make([]string, len(pipelines))
 	names := make([]string, len(pipelines))
 	phases := make([]string, len(pipelines))
@@ -76,17 +87,18 @@ func newPipelineTableFrames(nfClient *client.Client, rq query.RunnableQuery) (da
 		data.NewField("UDFs", nil, numUDFs),
 		data.NewField("creation time", nil, creationTime),
 	}
-	return data.Frames{data.NewFrame("pipelines", fields...)}, nil
+	return data.Frames{data.NewFrame("pipelines", setDisplayNames(fields)...)}, nil
 }
 
-func newVertexTableFrames(nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+// listQueryVertices returns the vertices selected by a vertex query.
+func listQueryVertices(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) ([]v1alpha1.Vertex, error) {
 	queryNamespace := rq.GetNamespace()
 	queryFilterNamespaces := rq.GetFilterNamespaces()
 	vertices := []v1alpha1.Vertex{}
 	queryPipeline := *rq.Pipeline
 	if *rq.Vertex == "*" {
 		queryFilterPipelines := rq.GetFilterPipelines()
-		v, err := nfClient.ListVertices(queryNamespace)
+		v, err := nfClient.ListVertices(ctx, queryNamespace)
 		if err != nil {
 			return nil, err
 		}
@@ -97,12 +109,25 @@ func newVertexTableFrames(nfClient *client.Client, rq query.RunnableQuery) (data
 			}
 		}
 	} else {
-		vertex, err := nfClient.GetPipelineVertex(queryNamespace, queryPipeline, *rq.Vertex)
+		vertex, err := nfClient.GetPipelineVertex(ctx, queryNamespace, queryPipeline, *rq.Vertex)
 		if err != nil {
 			return nil, err
 		}
 		vertices = []v1alpha1.Vertex{*vertex}
 	}
+	return vertices, nil
+}
+
+func newVertexTableFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	windows, err := rq.GetWindows()
+	if err != nil {
+		return nil, err
+	}
+	window := primaryWindow(windows)
+	vertices, err := listQueryVertices(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
 	namespaces := make([]string, len(vertices))
 	pipelineNames := make([]string, len(vertices))
 	names := make([]string, len(vertices))
@@ -112,13 +137,26 @@ func newVertexTableFrames(nfClient *client.Client, rq query.RunnableQuery) (data
 	phases := make([]string, len(vertices))
 	processingRate := make([]*float64, len(vertices))
 	pendingMessages := make([]*int64, len(vertices))
+	drainTimes := make([]*float64, len(vertices))
+	// the other windows, only when all windows are selected
+	windowRates := make(map[query.Window][]*float64)
+	windowPendings := make(map[query.Window][]*int64)
+	for _, w := range windows {
+		if w != window {
+			windowRates[w] = make([]*float64, len(vertices))
+			windowPendings[w] = make([]*int64, len(vertices))
+		}
+	}
 	watermark := make([]*time.Time, len(vertices))
 	cpuUsage := make([]*int64, len(vertices))
 	memoryUsage := make([]*int64, len(vertices))
 	creationTime := make([]time.Time, len(vertices))
 	for i := range vertices {
-		namespaces[i] = vertices[i].Namespace
-		pipelineNames[i] = vertices[i].Spec.PipelineName
+		// vertices of all pipelines, and namespaces, are listed when the query has no pipeline
+		vNamespace := vertices[i].Namespace
+		vPipeline := vertices[i].Spec.PipelineName
+		namespaces[i] = vNamespace
+		pipelineNames[i] = vPipeline
 		names[i] = vertices[i].Spec.Name
 		if vertices[i].IsASource() {
 			vtype[i] = "source"
@@ -132,53 +170,51 @@ func newVertexTableFrames(nfClient *client.Client, rq query.RunnableQuery) (data
 		phases[i] = string(vertices[i].Status.Phase)
 		desiredReplicas[i] = vertices[i].Spec.Replicas
 		replicas[i] = vertices[i].Status.Replicas
-		vMetrics, err := nfClient.GetVertexMetrics(queryNamespace, queryPipeline, vertices[i].Spec.Name)
+		vMetrics, err := nfClient.GetVertexMetrics(ctx, vNamespace, vPipeline, vertices[i].Spec.Name)
 		if err != nil {
-			backend.Logger.Error("failed to retrieve metrics for vertex", "namespace", queryNamespace, "pipeline", queryPipeline, "vertex", vertices[i].Spec.Name)
+			partialFailure(ctx, "failed to retrieve metrics for vertex", "namespace", vNamespace, "pipeline", vPipeline, "vertex", vertices[i].Spec.Name, "err", err)
 		} else {
-			// Avg rate and pending for autoscaling are both in the map with key "default", see "pkg/metrics/metrics.go".
-			rate, existing := vMetrics.ProcessingRates["default"]
-			if !existing || rate < 0 || rate == isb.RateNotAvailable { // Rate not available
-				backend.Logger.Debug("processing rate not available for vertex", "namespace", queryNamespace, "pipeline", queryPipeline, "vertex", vertices[i].Spec.Name)
-			} else {
-				processingRate[i] = pointer.Float64(rate)
+			processingRate[i], pendingMessages[i] = rateAndPending(vMetrics, window)
+			if processingRate[i] == nil {
+				backend.Logger.Debug("processing rate not available for vertex", "namespace", vNamespace, "pipeline", vPipeline, "vertex", vertices[i].Spec.Name, "window", window)
 			}
-			pending, existing := vMetrics.Pendings["default"]
-			if !existing || pending < 0 || pending == isb.PendingNotAvailable {
-				backend.Logger.Debug("pending not available for vertex", "namespace", queryNamespace, "pipeline", queryPipeline, "vertex", vertices[i].Spec.Name)
-			} else {
-				pendingMessages[i] = pointer.Int64(pending)
+			if pendingMessages[i] == nil {
+				backend.Logger.Debug("pending not available for vertex", "namespace", vNamespace, "pipeline", vPipeline, "vertex", vertices[i].Spec.Name, "window", window)
+			}
+			drainTimes[i] = drainTime(pendingMessages[i], processingRate[i])
+			for w := range windowRates {
+				windowRates[w][i], windowPendings[w][i] = rateAndPending(vMetrics, w)
 			}
 		}
-		vWatermark, err := nfClient.GetVertexWatermark(queryNamespace, queryPipeline, vertices[i].Spec.Name)
+		vWatermark, err := nfClient.GetVertexWatermark(ctx, vNamespace, vPipeline, vertices[i].Spec.Name)
 		if err != nil {
-			backend.Logger.Error("failed to retrieve watermark for vertex", "namespace", queryNamespace, "pipeline", queryPipeline, "vertex", vertices[i].Spec.Name)
+			partialFailure(ctx, "failed to retrieve watermark for vertex", "namespace", vNamespace, "pipeline", vPipeline, "vertex", vertices[i].Spec.Name, "err", err)
 		} else {
 			if vWatermark.Watermark != nil {
 				t := time.UnixMilli(*vWatermark.Watermark)
 				watermark[i] = &t
 			}
 		}
-		pods, err := nfClient.ListVertexPods(queryNamespace, queryPipeline, vertices[i].Spec.Name)
+		pods, err := nfClient.ListVertexPods(ctx, vNamespace, vPipeline, vertices[i].Spec.Name)
 		if err != nil {
-			backend.Logger.Error("failed to retrieve pods for vertex", "namespace", queryNamespace, "pipeline", queryPipeline, "vertex", vertices[i].Spec.Name)
+			partialFailure(ctx, "failed to retrieve pods for vertex", "namespace", vNamespace, "pipeline", vPipeline, "vertex", vertices[i].Spec.Name, "err", err)
 		} else {
+			// a pod without usage only leaves out its own contribution
 			pCpu := int64(0)
 			pMemory := int64(0)
+			pUsages := 0
 			for pi := range pods {
-				pMetrics, err := nfClient.GetPodMetrics(queryNamespace, pods[pi].Name)
+				pUsage, err := nfClient.GetPodUsage(ctx, &pods[pi])
 				if err != nil {
-					backend.Logger.Error("failed to retrieve pod metrics for pod", "namespace", queryNamespace, "pod", pods[pi].Name)
-					pCpu = int64(0)
-					pMemory = int64(0)
-					break
-				}
-				for _, c := range pMetrics.Containers {
-					pCpu += c.Usage.Cpu().MilliValue()
-					pMemory += c.Usage.Memory().ScaledValue(6)
+					partialFailure(ctx, "failed to retrieve usage for pod", "namespace", vNamespace, "pod", pods[pi].Name, "err", err)
+					continue
 				}
+				pCpu += pUsage.CPUMilli()
+				pMemory += pUsage.MemoryBytes()
+				pUsages++
 			}
-			if pCpu != int64(0) && pMemory != int64(0) {
+			if pUsages > 0 {
+				pMemory = megabytes(pMemory)
 				cpuUsage[i] = &pCpu
 				memoryUsage[i] = &pMemory
 			}
@@ -194,22 +230,35 @@ func newVertexTableFrames(nfClient *client.Client, rq query.RunnableQuery) (data
 		data.NewField("phase", nil, phases),
 		data.NewField("replicas", nil, replicas),
 		data.NewField("desired replicas", nil, desiredReplicas),
-		data.NewField("processing rate", nil, processingRate),
-		data.NewField("pending messages", nil, pendingMessages),
+		data.NewField("processing rate", nil, processingRate).SetConfig(unitConfig(rateUnit)),
+		data.NewField("pending messages", nil, pendingMessages).SetConfig(unitConfig(messagesUnit)),
+		data.NewField("drain time", nil, drainTimes).SetConfig(unitConfig(secondsUnit)),
+	}
+	for _, w := range windows {
+		if w == window {
+			continue
+		}
+		fields = append(fields,
+			data.NewField("processing rate"+windowSuffix(w), nil, windowRates[w]).SetConfig(unitConfig(rateUnit)),
+			data.NewField("pending messages"+windowSuffix(w), nil, windowPendings[w]).SetConfig(unitConfig(messagesUnit)),
+		)
+	}
+	fields = append(fields,
 		data.NewField("watermark", nil, watermark),
-		data.NewField("cpu usage", nil, cpuUsage),
-		data.NewField("memory usage", nil, memoryUsage),
+		data.NewField("cpu usage", nil, cpuUsage).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("memory usage", nil, memoryUsage).SetConfig(unitConfig(memoryUnit)),
 		data.NewField("creation time", nil, creationTime),
-	}
-	return data.Frames{data.NewFrame("vertices", fields...)}, nil
+	)
+	return data.Frames{data.NewFrame("vertices", setDisplayNames(fields)...)}, nil
 }
 
-func newIsbsvcTableFrames(nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+// listQueryInterStepBufferServices returns the isbsvcs selected by an isbsvc query.
+func listQueryInterStepBufferServices(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) ([]v1alpha1.InterStepBufferService, error) {
 	queryNamespace := rq.GetNamespace()
 	queryFilterNamespaces := rq.GetFilterNamespaces()
 	isbsvcs := []v1alpha1.InterStepBufferService{}
 	if *rq.InterStepBufferService == "*" {
-		is, err := nfClient.ListInterStepBufferServices(queryNamespace)
+		is, err := nfClient.ListInterStepBufferServices(ctx, queryNamespace)
 		if err != nil {
 			return nil, err
 		}
@@ -219,39 +268,134 @@ func newIsbsvcTableFrames(nfClient *client.Client, rq query.RunnableQuery) (data
 			}
 		}
 	} else {
-		isbsvc, err := nfClient.GetInterStepBufferService(queryNamespace, *rq.InterStepBufferService)
+		isbsvc, err := nfClient.GetInterStepBufferService(ctx, queryNamespace, *rq.InterStepBufferService)
 		if err != nil {
 			return nil, err
 		}
 		isbsvcs = []v1alpha1.InterStepBufferService{*isbsvc}
 	}
+	return isbsvcs, nil
+}
+
+func newIsbsvcTableFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	isbsvcs, err := listQueryInterStepBufferServices(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+	// pipelines per namespace, to find the pipelines using each isbsvc
+	pipelines := make(map[string][]v1alpha1.Pipeline)
 	namespaces := make([]string, len(isbsvcs))
 	names := make([]string, len(isbsvcs))
 	itype := make([]string, len(isbsvcs))
+	versions := make([]*string, len(isbsvcs))
+	desiredReplicas := make([]*int32, len(isbsvcs))
+	readyPods := make([]*int32, len(isbsvcs))
+	persistence := make([]string, len(isbsvcs))
+	storageSize := make([]*string, len(isbsvcs))
+	bufferSettings := make([]string, len(isbsvcs))
 	phases := make([]string, len(isbsvcs))
+	conditions := make([]string, len(isbsvcs))
+	cpuUsage := make([]*int64, len(isbsvcs))
+	memoryUsage := make([]*int64, len(isbsvcs))
+	usedBy := make([]string, len(isbsvcs))
 	creationTime := make([]time.Time, len(isbsvcs))
 	for i := range isbsvcs {
-		namespaces[i] = isbsvcs[i].Namespace
-		names[i] = isbsvcs[i].Name
-		if isbsvcs[i].Spec.Redis != nil {
-			if isbsvcs[i].Spec.Redis.External != nil {
+		isbsvc := &isbsvcs[i]
+		namespaces[i] = isbsvc.Namespace
+		names[i] = isbsvc.Name
+		var p *v1alpha1.PersistenceStrategy
+		if isbsvc.Spec.Redis != nil {
+			if isbsvc.Spec.Redis.External != nil {
 				itype[i] = "redis (external)"
 			} else {
 				itype[i] = "redis (internal)"
 			}
-		} else if isbsvcs[i].Spec.JetStream != nil {
+			if native := isbsvc.Spec.Redis.Native; native != nil {
+				versions[i] = pointer.String(native.Version)
+				desiredReplicas[i] = pointer.Int32(int32(native.GetReplicas()))
+				p = native.Persistence
+			}
+		} else if isbsvc.Spec.JetStream != nil {
 			itype[i] = "jetstream"
+			versions[i] = pointer.String(isbsvc.Spec.JetStream.Version)
+			desiredReplicas[i] = pointer.Int32(int32(isbsvc.Spec.JetStream.GetReplicas()))
+			p = isbsvc.Spec.JetStream.Persistence
+		}
+		persistence[i], storageSize[i] = persistenceSummary(p)
+		if isbsvc.Status.Config.JetStream != nil {
+			bufferSettings[i] = jetStreamBufferSettings(isbsvc.Status.Config.JetStream.BufferConfig)
 		}
-		phases[i] = string(isbsvcs[i].Status.Phase)
-		creationTime[i] = isbsvcs[i].CreationTimestamp.Time
+		phases[i] = string(isbsvc.Status.Phase)
+		conditions[i] = conditionsSummary(isbsvc.Status.Conditions)
+
+		pods, err := nfClient.ListInterStepBufferServicePods(ctx, isbsvc.Namespace, isbsvc.Name)
+		if err != nil {
+			partialFailure(ctx, "failed to retrieve pods for isbsvc", "namespace", isbsvc.Namespace, "isbsvc", isbsvc.Name, "err", err)
+		} else {
+			ready := int32(0)
+			pCpu := int64(0)
+			pMemory := int64(0)
+			pUsages := 0
+			for pi := range pods {
+				if podIsReady(&pods[pi]) {
+					ready++
+				}
+				pUsage, err := nfClient.GetPodUsage(ctx, &pods[pi])
+				if err != nil {
+					partialFailure(ctx, "failed to retrieve usage for pod", "namespace", isbsvc.Namespace, "pod", pods[pi].Name, "err", err)
+					continue
+				}
+				pCpu += pUsage.CPUMilli()
+				pMemory += pUsage.MemoryBytes()
+				pUsages++
+			}
+			readyPods[i] = &ready
+			if pUsages > 0 {
+				pMemory = megabytes(pMemory)
+				cpuUsage[i] = &pCpu
+				memoryUsage[i] = &pMemory
+			}
+		}
+
+		if _, ok := pipelines[isbsvc.Namespace]; !ok {
+			pls, err := nfClient.ListPipelines(ctx, isbsvc.Namespace)
+			if err != nil {
+				partialFailure(ctx, "failed to retrieve pipelines for isbsvc", "namespace", isbsvc.Namespace, "isbsvc", isbsvc.Name, "err", err)
+			}
+			pipelines[isbsvc.Namespace] = pls
+		}
+		users := []string{}
+		for _, pl := range pipelines[isbsvc.Namespace] {
+			if pipelineISBSvcName(&pl) == isbsvc.Name {
+				users = append(users, pl.Name)
+			}
+		}
+		sort.Strings(users)
+		usedBy[i] = strings.Join(users, ", ")
+		creationTime[i] = isbsvc.CreationTimestamp.Time
 	}
 
 	fields := []*data.Field{
 		data.NewField("namespace", nil, namespaces),
 		data.NewField("name", nil, names),
 		data.NewField("type", nil, itype),
+		data.NewField("version", nil, versions),
 		data.NewField("phase", nil, phases),
+		data.NewField("conditions", nil, conditions),
+		data.NewField("desired replicas", nil, desiredReplicas),
+		data.NewField("ready pods", nil, readyPods),
+		data.NewField("persistence", nil, persistence),
+		data.NewField("storage size", nil, storageSize),
+		data.NewField("buffer settings", nil, bufferSettings),
+		data.NewField("cpu usage", nil, cpuUsage).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("memory usage", nil, memoryUsage).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("pipelines", nil, usedBy),
 		data.NewField("creation time", nil, creationTime),
 	}
-	return data.Frames{data.NewFrame("isbsvcs", fields...)}, nil
+	return data.Frames{data.NewFrame("isbsvcs", setDisplayNames(fields)...)}, nil
+}
+
+// megabytes converts bytes to megabytes, rounding up.
+func megabytes(b int64) int64 {
+	return (b + 999999) / 1000000
 }
@@ -0,0 +1,42 @@
+package scenario
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/pointer"
+)
+
+func TestVertexTableAcrossPipelinesUsesVertexPipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nfClient, err := client.NewSimulatedClient(ctx, "", client.SimulationOptions{Namespaces: 2, Pipelines: 2, Vertices: 3})
+	require.NoError(t, err)
+
+	for _, pipeline := range []string{"", "{orders,clickstream}"} {
+		rq := query.RunnableQuery{
+			Namespace: pointer.String("{simulated-1,simulated-2}"),
+			Pipeline:  pointer.String(pipeline),
+			Vertex:    pointer.String("*"),
+		}
+		frames, err := newVertexTableFrames(ctx, nfClient, rq)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, 12, frames[0].Rows(), "vertices of pipeline %q", pipeline)
+		for _, name := range []string{"processing rate", "cpu usage", "memory usage"} {
+			field, _ := frames[0].FieldByName(name)
+			require.NotNil(t, field, name)
+			for i := 0; i < field.Len(); i++ {
+				v, ok := field.ConcreteAt(i)
+				assert.True(t, ok && v != nil, "%s of row %d of pipeline %q", name, i, pipeline)
+			}
+			assert.NotEmpty(t, field.Config.DisplayName, name)
+		}
+		cpu, _ := frames[0].FieldByName("cpu usage")
+		assert.Equal(t, "CPU usage", cpu.Config.DisplayName)
+	}
+}
@@ -0,0 +1,84 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/strings/slices"
+)
+
+func newTimeSeriesFrames(store *metricstore.Store, dq backend.DataQuery, rq query.RunnableQuery) (data.Frames, error) {
+	if store == nil {
+		return nil, query.Errorf(query.ValidationError, "metric store is disabled for this datasource")
+	}
+	metrics := metricstore.Metrics()
+	if rq.Metric != nil {
+		if !slices.Contains(metricNames(metrics), *rq.Metric) {
+			return nil, query.Errorf(query.ValidationError, "unknown metric %q, must be one of %v", *rq.Metric, metricNames(metrics))
+		}
+		metrics = []metricstore.Metric{metricstore.Metric(*rq.Metric)}
+	}
+	queryFilterNamespaces := rq.GetFilterNamespaces()
+	queryFilterPipelines := rq.GetFilterPipelines()
+	keys, err := store.Series(func(k metricstore.SeriesKey) bool {
+		if *rq.Namespace != v1.NamespaceAll && !slices.Contains(queryFilterNamespaces, k.Namespace) {
+			return false
+		}
+		if *rq.Pipeline != "*" && *rq.Pipeline != "" && !slices.Contains(queryFilterPipelines, k.Pipeline) {
+			return false
+		}
+		if rq.ResourceType == query.VertexResourceType && *rq.Vertex != "*" && *rq.Vertex != k.Vertex {
+			return false
+		}
+		return slices.Contains(metricNames(metrics), string(k.Metric))
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Metric != keys[j].Metric {
+			return keys[i].Metric < keys[j].Metric
+		}
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+
+	frames := data.Frames{}
+	for _, k := range keys {
+		samples, err := store.Query(k, dq.TimeRange.From, dq.TimeRange.To)
+		if err != nil {
+			return nil, err
+		}
+		times := make([]time.Time, len(samples))
+		values := make([]float64, len(samples))
+		for i := range samples {
+			times[i] = samples[i].Time
+			values[i] = samples[i].Value
+		}
+		labels := data.Labels{"namespace": k.Namespace, "pipeline": k.Pipeline, "vertex": k.Vertex}
+		if k.Container != "" {
+			labels["container"] = k.Container
+		}
+		if k.Buffer != "" {
+			labels["buffer"] = k.Buffer
+		}
+		frames = append(frames, data.NewFrame(string(k.Metric),
+			data.NewField("time", nil, times),
+			data.NewField(string(k.Metric), labels, values).SetConfig(metricConfig(k.Metric)),
+		))
+	}
+	return frames, nil
+}
+
+func metricNames(metrics []metricstore.Metric) []string {
+	names := make([]string, len(metrics))
+	for i := range metrics {
+		names[i] = string(metrics[i])
+	}
+	return names
+}
@@ -0,0 +1,301 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/metricstore"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// recommendationHeadroom is added on top of the observed peak when recommending requests.
+	recommendationHeadroom = 1.2
+	// limitPressure is the fraction of a limit above which the peak is reported as close to the limit.
+	limitPressure = 0.9
+)
+
+// containerUtilization is the usage, requests and limits of a container summed over the pods of a vertex.
+type containerUtilization struct {
+	name string
+	init bool
+	pods int
+
+	cpuUsage, cpuRequest, cpuLimit          int64 // millicores
+	memoryUsage, memoryRequest, memoryLimit int64 // bytes
+
+	// requests and limits of a single pod, and the highest usage of a single pod
+	podCPURequest, podCPULimit, podMemoryRequest, podMemoryLimit int64
+	peakCPU, peakMemory                                          int64
+}
+
+func newUtilizationFrames(ctx context.Context, nfClient *client.Client, store *metricstore.Store, dq backend.DataQuery, rq query.RunnableQuery) (data.Frames, error) {
+	vertices, err := listQueryVertices(ctx, nfClient, rq)
+	if err != nil {
+		return nil, err
+	}
+
+	vNamespaces, vPipelines, vNames := []string{}, []string{}, []string{}
+	vCPUUsage, vCPURequest, vCPULimit := []*int64{}, []*int64{}, []*int64{}
+	vCPUOfRequest, vCPUOfLimit := []*float64{}, []*float64{}
+	vMemoryUsage, vMemoryRequest, vMemoryLimit := []*int64{}, []*int64{}, []*int64{}
+	vMemoryOfRequest, vMemoryOfLimit := []*float64{}, []*float64{}
+
+	cNamespaces, cPipelines, cVertices, cNames, cTypes := []string{}, []string{}, []string{}, []string{}, []string{}
+	cPods := []int64{}
+	cCPUUsage, cCPURequest, cCPULimit := []int64{}, []int64{}, []int64{}
+	cCPUOfRequest, cCPUOfLimit := []*float64{}, []*float64{}
+	cMemoryUsage, cMemoryRequest, cMemoryLimit := []int64{}, []int64{}, []int64{}
+	cMemoryOfRequest, cMemoryOfLimit := []*float64{}, []*float64{}
+	cPeakCPU, cPeakMemory := []int64{}, []int64{}
+	cRecommendedCPU, cRecommendedMemory := []int64{}, []int64{}
+	cRecommendations := []string{}
+
+	for _, vertex := range vertices {
+		ns, pl, vName := vertex.Namespace, vertex.Spec.PipelineName, vertex.Spec.Name
+		pods, err := nfClient.ListVertexPods(ctx, ns, pl, vName)
+		if err != nil {
+			partialFailure(ctx, "failed to retrieve pods for vertex", "namespace", ns, "pipeline", pl, "vertex", vName, "err", err)
+			continue
+		}
+		containers := utilizationByContainer(ctx, nfClient, pods)
+
+		var cpuUsage, cpuRequest, cpuLimit, memoryUsage, memoryRequest, memoryLimit *int64
+		for _, c := range containers {
+			if c.pods == 0 {
+				continue
+			}
+			cpuUsage = addInt64(cpuUsage, c.cpuUsage)
+			cpuRequest = addInt64(cpuRequest, c.cpuRequest)
+			cpuLimit = addInt64(cpuLimit, c.cpuLimit)
+			memoryUsage = addInt64(memoryUsage, megabytes(c.memoryUsage))
+			memoryRequest = addInt64(memoryRequest, megabytes(c.memoryRequest))
+			memoryLimit = addInt64(memoryLimit, megabytes(c.memoryLimit))
+
+			if store != nil {
+				key := metricstore.SeriesKey{Namespace: ns, Pipeline: pl, Vertex: vName, Container: c.name}
+				key.Metric = metricstore.ContainerCPUMetric
+				if peak, ok, err := store.Max(key, dq.TimeRange.From, dq.TimeRange.To); err == nil && ok {
+					c.peakCPU = maxInt64(c.peakCPU, int64(math.Ceil(peak)))
+				}
+				key.Metric = metricstore.ContainerMemoryMetric
+				if peak, ok, err := store.Max(key, dq.TimeRange.From, dq.TimeRange.To); err == nil && ok {
+					c.peakMemory = maxInt64(c.peakMemory, int64(math.Ceil(peak*1000000)))
+				}
+			}
+			recommendedCPU := int64(math.Ceil(float64(c.peakCPU) * recommendationHeadroom))
+			recommendedMemory := int64(math.Ceil(float64(c.peakMemory) * recommendationHeadroom))
+
+			cNamespaces = append(cNamespaces, ns)
+			cPipelines = append(cPipelines, pl)
+			cVertices = append(cVertices, vName)
+			cNames = append(cNames, c.name)
+			if c.init {
+				cTypes = append(cTypes, "init")
+			} else {
+				cTypes = append(cTypes, "main")
+			}
+			cPods = append(cPods, int64(c.pods))
+			cCPUUsage = append(cCPUUsage, c.cpuUsage)
+			cCPURequest = append(cCPURequest, c.cpuRequest)
+			cCPULimit = append(cCPULimit, c.cpuLimit)
+			cCPUOfRequest = append(cCPUOfRequest, percent(c.cpuUsage, c.cpuRequest))
+			cCPUOfLimit = append(cCPUOfLimit, percent(c.cpuUsage, c.cpuLimit))
+			cMemoryUsage = append(cMemoryUsage, megabytes(c.memoryUsage))
+			cMemoryRequest = append(cMemoryRequest, megabytes(c.memoryRequest))
+			cMemoryLimit = append(cMemoryLimit, megabytes(c.memoryLimit))
+			cMemoryOfRequest = append(cMemoryOfRequest, percent(c.memoryUsage, c.memoryRequest))
+			cMemoryOfLimit = append(cMemoryOfLimit, percent(c.memoryUsage, c.memoryLimit))
+			cPeakCPU = append(cPeakCPU, c.peakCPU)
+			cPeakMemory = append(cPeakMemory, megabytes(c.peakMemory))
+			cRecommendedCPU = append(cRecommendedCPU, recommendedCPU)
+			cRecommendedMemory = append(cRecommendedMemory, megabytes(recommendedMemory))
+			cRecommendations = append(cRecommendations, recommendation(c, recommendedCPU, recommendedMemory))
+		}
+
+		vNamespaces = append(vNamespaces, ns)
+		vPipelines = append(vPipelines, pl)
+		vNames = append(vNames, vName)
+		vCPUUsage = append(vCPUUsage, cpuUsage)
+		vCPURequest = append(vCPURequest, cpuRequest)
+		vCPULimit = append(vCPULimit, cpuLimit)
+		vCPUOfRequest = append(vCPUOfRequest, percentOf(cpuUsage, cpuRequest))
+		vCPUOfLimit = append(vCPUOfLimit, percentOf(cpuUsage, cpuLimit))
+		vMemoryUsage = append(vMemoryUsage, memoryUsage)
+		vMemoryRequest = append(vMemoryRequest, memoryRequest)
+		vMemoryLimit = append(vMemoryLimit, memoryLimit)
+		vMemoryOfRequest = append(vMemoryOfRequest, percentOf(memoryUsage, memoryRequest))
+		vMemoryOfLimit = append(vMemoryOfLimit, percentOf(memoryUsage, memoryLimit))
+	}
+
+	vertexFields := []*data.Field{
+		data.NewField("namespace", nil, vNamespaces),
+		data.NewField("pipeline", nil, vPipelines),
+		data.NewField("name", nil, vNames),
+		data.NewField("cpu usage", nil, vCPUUsage).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("cpu requests", nil, vCPURequest).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("cpu limits", nil, vCPULimit).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("cpu % of requests", nil, vCPUOfRequest).SetConfig(utilizationConfig()),
+		data.NewField("cpu % of limits", nil, vCPUOfLimit).SetConfig(utilizationConfig()),
+		data.NewField("memory usage", nil, vMemoryUsage).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("memory requests", nil, vMemoryRequest).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("memory limits", nil, vMemoryLimit).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("memory % of requests", nil, vMemoryOfRequest).SetConfig(utilizationConfig()),
+		data.NewField("memory % of limits", nil, vMemoryOfLimit).SetConfig(utilizationConfig()),
+	}
+	containerFields := []*data.Field{
+		data.NewField("namespace", nil, cNamespaces),
+		data.NewField("pipeline", nil, cPipelines),
+		data.NewField("vertex", nil, cVertices),
+		data.NewField("container", nil, cNames),
+		data.NewField("type", nil, cTypes),
+		data.NewField("pods", nil, cPods),
+		data.NewField("cpu usage", nil, cCPUUsage).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("cpu requests", nil, cCPURequest).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("cpu limits", nil, cCPULimit).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("cpu % of requests", nil, cCPUOfRequest).SetConfig(utilizationConfig()),
+		data.NewField("cpu % of limits", nil, cCPUOfLimit).SetConfig(utilizationConfig()),
+		data.NewField("memory usage", nil, cMemoryUsage).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("memory requests", nil, cMemoryRequest).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("memory limits", nil, cMemoryLimit).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("memory % of requests", nil, cMemoryOfRequest).SetConfig(utilizationConfig()),
+		data.NewField("memory % of limits", nil, cMemoryOfLimit).SetConfig(utilizationConfig()),
+		data.NewField("peak cpu per pod", nil, cPeakCPU).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("peak memory per pod", nil, cPeakMemory).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("recommended cpu request", nil, cRecommendedCPU).SetConfig(unitConfig(cpuUnit)),
+		data.NewField("recommended memory request", nil, cRecommendedMemory).SetConfig(unitConfig(memoryUnit)),
+		data.NewField("recommendation", nil, cRecommendations),
+	}
+	return data.Frames{
+		data.NewFrame("vertex utilization", vertexFields...),
+		data.NewFrame("container utilization", containerFields...),
+	}, nil
+}
+
+type containerSpec struct {
+	container v1.Container
+	init      bool
+}
+
+// utilizationByContainer sums usage, requests and limits per container name over the pods with known usage.
+func utilizationByContainer(ctx context.Context, nfClient *client.Client, pods []v1.Pod) []*containerUtilization {
+	byName := make(map[string]*containerUtilization)
+	order := []string{}
+	for pi := range pods {
+		pUsage, err := nfClient.GetPodUsage(ctx, &pods[pi])
+		if err != nil {
+			partialFailure(ctx, "failed to retrieve usage for pod", "namespace", pods[pi].Namespace, "pod", pods[pi].Name, "err", err)
+			continue
+		}
+		usages := make(map[string]client.ContainerUsage)
+		for _, cu := range pUsage.Containers {
+			usages[cu.Name] = cu
+		}
+		specs := []containerSpec{}
+		for _, c := range pods[pi].Spec.InitContainers {
+			specs = append(specs, containerSpec{container: c, init: true})
+		}
+		for _, c := range pods[pi].Spec.Containers {
+			specs = append(specs, containerSpec{container: c})
+		}
+		for _, spec := range specs {
+			c, ok := byName[spec.container.Name]
+			if !ok {
+				c = &containerUtilization{
+					name:             spec.container.Name,
+					init:             spec.init,
+					podCPURequest:    spec.container.Resources.Requests.Cpu().MilliValue(),
+					podCPULimit:      spec.container.Resources.Limits.Cpu().MilliValue(),
+					podMemoryRequest: spec.container.Resources.Requests.Memory().Value(),
+					podMemoryLimit:   spec.container.Resources.Limits.Memory().Value(),
+				}
+				byName[spec.container.Name] = c
+				order = append(order, spec.container.Name)
+			}
+			usage := usages[spec.container.Name]
+			c.pods++
+			c.cpuUsage += usage.CPUMilli
+			c.memoryUsage += usage.MemoryBytes
+			c.cpuRequest += spec.container.Resources.Requests.Cpu().MilliValue()
+			c.cpuLimit += spec.container.Resources.Limits.Cpu().MilliValue()
+			c.memoryRequest += spec.container.Resources.Requests.Memory().Value()
+			c.memoryLimit += spec.container.Resources.Limits.Memory().Value()
+			c.peakCPU = maxInt64(c.peakCPU, usage.CPUMilli)
+			c.peakMemory = maxInt64(c.peakMemory, usage.MemoryBytes)
+		}
+	}
+	containers := make([]*containerUtilization, len(order))
+	for i, name := range order {
+		containers[i] = byName[name]
+	}
+	return containers
+}
+
+// recommendation compares the per pod requests and limits of a container with its observed peak.
+func recommendation(c *containerUtilization, recommendedCPU, recommendedMemory int64) string {
+	if c.init {
+		return "ok"
+	}
+	r := []string{}
+	switch {
+	case c.podCPURequest == 0:
+		r = append(r, fmt.Sprintf("set cpu request to %dm", recommendedCPU))
+	case recommendedCPU > c.podCPURequest*11/10:
+		r = append(r, fmt.Sprintf("increase cpu request to %dm", recommendedCPU))
+	case recommendedCPU < c.podCPURequest/2:
+		r = append(r, fmt.Sprintf("decrease cpu request to %dm", recommendedCPU))
+	}
+	switch {
+	case c.podMemoryRequest == 0:
+		r = append(r, fmt.Sprintf("set memory request to %dMB", megabytes(recommendedMemory)))
+	case recommendedMemory > c.podMemoryRequest*11/10:
+		r = append(r, fmt.Sprintf("increase memory request to %dMB", megabytes(recommendedMemory)))
+	case recommendedMemory < c.podMemoryRequest/2:
+		r = append(r, fmt.Sprintf("decrease memory request to %dMB", megabytes(recommendedMemory)))
+	}
+	if c.podCPULimit > 0 && float64(c.peakCPU) >= limitPressure*float64(c.podCPULimit) {
+		r = append(r, "cpu peak close to limit, likely throttled")
+	}
+	if c.podMemoryLimit > 0 && float64(c.peakMemory) >= limitPressure*float64(c.podMemoryLimit) {
+		r = append(r, "memory peak close to limit, risk of OOM kill")
+	}
+	if len(r) == 0 {
+		return "ok"
+	}
+	return strings.Join(r, "; ")
+}
+
+func percent(usage, total int64) *float64 {
+	if total == 0 {
+		return nil
+	}
+	return pointer.Float64(roundFloat(float64(usage)/float64(total)*100, 2))
+}
+
+func percentOf(usage, total *int64) *float64 {
+	if usage == nil || total == nil {
+		return nil
+	}
+	return percent(*usage, *total)
+}
+
+func addInt64(total *int64, v int64) *int64 {
+	if total == nil {
+		return pointer.Int64(v)
+	}
+	return pointer.Int64(*total + v)
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
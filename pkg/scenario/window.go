@@ -0,0 +1,40 @@
+package scenario
+
+import (
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/numaproj/numaflow/pkg/apis/proto/daemon"
+	"github.com/numaproj/numaflow/pkg/isb"
+	"k8s.io/utils/pointer"
+)
+
+// rateAndPending returns the processing rate and pending of a window, or nil when they are not available.
+func rateAndPending(vMetrics *daemon.VertexMetrics, window query.Window) (*float64, *int64) {
+	var rate *float64
+	var pending *int64
+	if r, ok := vMetrics.ProcessingRates[string(window)]; ok && r >= 0 && r != isb.RateNotAvailable {
+		rate = pointer.Float64(r)
+	}
+	if p, ok := vMetrics.Pendings[string(window)]; ok && p >= 0 && p != isb.PendingNotAvailable {
+		pending = pointer.Int64(p)
+	}
+	return rate, pending
+}
+
+// defaultRateAndPending returns the rate and pending Numaflow uses for autoscaling, or nil when they are not available.
+func defaultRateAndPending(vMetrics *daemon.VertexMetrics) (*float64, *int64) {
+	// Avg rate and pending for autoscaling are both in the map with key "default", see "pkg/metrics/metrics.go".
+	return rateAndPending(vMetrics, query.DefaultWindow)
+}
+
+// primaryWindow is the window shown in single-valued columns and stats, the default window when all are selected.
+func primaryWindow(windows []query.Window) query.Window {
+	if len(windows) != 1 {
+		return query.DefaultWindow
+	}
+	return windows[0]
+}
+
+// windowSuffix distinguishes the columns and details of the non-primary windows, e.g. "processing rate (5m)".
+func windowSuffix(window query.Window) string {
+	return " (" + string(window) + ")"
+}
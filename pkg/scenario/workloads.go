@@ -0,0 +1,125 @@
+package scenario
+
+import (
+	"context"
+	"time"
+
+	"github.com/dseapy/numaflow-datasource/pkg/client"
+	"github.com/dseapy/numaflow-datasource/pkg/query"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// workloadRoot is a pipeline or isbsvc whose owned workloads are listed.
+type workloadRoot struct {
+	namespace string
+	kind      string
+	name      string
+	uid       types.UID
+}
+
+// newWorkloadsFrames returns the Kubernetes objects owned, directly or transitively, by the selected pipelines or isbsvcs,
+// e.g. the daemon deployment, vertices and their pods and services, or the isbsvc stateful set.
+func newWorkloadsFrames(ctx context.Context, nfClient *client.Client, rq query.RunnableQuery) (data.Frames, error) {
+	roots := []workloadRoot{}
+	switch rq.ResourceType {
+	case query.PipelineResourceType:
+		pipelines, err := listQueryPipelines(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, pl := range pipelines {
+			roots = append(roots, workloadRoot{namespace: pl.Namespace, kind: "Pipeline", name: pl.Name, uid: pl.UID})
+		}
+	case query.IsbsvcResourceType:
+		isbsvcs, err := listQueryInterStepBufferServices(ctx, nfClient, rq)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range isbsvcs {
+			roots = append(roots, workloadRoot{namespace: i.Namespace, kind: "InterStepBufferService", name: i.Name, uid: i.UID})
+		}
+	default:
+		return nil, query.Errorf(query.ValidationError, "workloads currently only supports pipelines and isbsvcs")
+	}
+
+	// owned workloads by owner uid, per namespace
+	owned := make(map[string]map[types.UID][]client.Workload)
+	namespaces := []string{}
+	rootNames := []string{}
+	owners := []string{}
+	kinds := []string{}
+	names := []string{}
+	ready := []*string{}
+	generations := []int64{}
+	observedGenerations := []*int64{}
+	rollouts := []*string{}
+	creationTimes := []time.Time{}
+	for _, root := range roots {
+		if _, ok := owned[root.namespace]; !ok {
+			workloads, err := nfClient.ListWorkloads(ctx, root.namespace)
+			if err != nil {
+				return nil, err
+			}
+			owned[root.namespace] = make(map[types.UID][]client.Workload)
+			for _, w := range workloads {
+				for _, o := range w.Owners {
+					owned[root.namespace][o] = append(owned[root.namespace][o], w)
+				}
+			}
+		}
+		// breadth first, so owners come before the objects they own
+		type ownerRef struct {
+			uid  types.UID
+			name string
+		}
+		queue := []ownerRef{{uid: root.uid, name: root.kind + "/" + root.name}}
+		visited := map[types.UID]bool{root.uid: true}
+		for len(queue) > 0 {
+			owner := queue[0]
+			queue = queue[1:]
+			for _, w := range owned[root.namespace][owner.uid] {
+				if visited[w.UID] {
+					continue
+				}
+				visited[w.UID] = true
+				queue = append(queue, ownerRef{uid: w.UID, name: w.Kind + "/" + w.Name})
+				namespaces = append(namespaces, w.Namespace)
+				rootNames = append(rootNames, root.name)
+				owners = append(owners, owner.name)
+				kinds = append(kinds, w.Kind)
+				names = append(names, w.Name)
+				ready = append(ready, optionalString(w.Ready))
+				generations = append(generations, w.Generation)
+				observedGenerations = append(observedGenerations, w.ObservedGeneration)
+				rollouts = append(rollouts, optionalString(w.Rollout))
+				creationTimes = append(creationTimes, w.CreationTime)
+			}
+		}
+	}
+
+	rootField := "pipeline"
+	if rq.ResourceType == query.IsbsvcResourceType {
+		rootField = "isbsvc"
+	}
+	fields := []*data.Field{
+		data.NewField("namespace", nil, namespaces),
+		data.NewField(rootField, nil, rootNames),
+		data.NewField("owner", nil, owners),
+		data.NewField("kind", nil, kinds),
+		data.NewField("name", nil, names),
+		data.NewField("ready", nil, ready),
+		data.NewField("generation", nil, generations),
+		data.NewField("observed generation", nil, observedGenerations),
+		data.NewField("rollout", nil, rollouts),
+		data.NewField("creation time", nil, creationTimes),
+	}
+	return data.Frames{data.NewFrame("workloads", fields...)}, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -3,11 +3,33 @@ import { FieldSet, InlineField, InlineSwitch, Input } from '@grafana/ui';
 import type { EditorProps } from './types';
 import { useChangeOptions } from './useChangeOptions';
 import { useChangeSwitch } from './useChangeSwitch';
+import { useChangeNumber } from './useChangeNumber';
 
 export function ConfigEditor(props: EditorProps): ReactElement {
   const { jsonData } = props.options;
   const onNamespacedChange = useChangeSwitch(props, 'namespaced');
   const onNamespaceChange = useChangeOptions(props, 'namespace');
+  const onPodUsageSourceChange = useChangeOptions(props, 'podUsageSource');
+  const onRedactEnvValuesChange = useChangeSwitch(props, 'redactEnvValues');
+  const onRedactEnvNamePatternChange = useChangeOptions(props, 'redactEnvNamePattern');
+  const onRedactSecretRefsChange = useChangeSwitch(props, 'redactSecretRefs');
+  const onHistoryMaxRevisionsChange = useChangeNumber(props, 'historyMaxRevisions');
+  const onHistoryDirectoryChange = useChangeOptions(props, 'historyDirectory');
+  const onNumaflowUiUrlChange = useChangeOptions(props, 'numaflowUiUrl');
+  const onCacheTtlChange = useChangeOptions(props, 'cacheTtl');
+  const onMetricStoreEnabledChange = useChangeSwitch(props, 'metricStoreEnabled');
+  const onMetricStoreDirectoryChange = useChangeOptions(props, 'metricStoreDirectory');
+  const onMetricStoreIntervalChange = useChangeOptions(props, 'metricStoreInterval');
+  const onMetricStoreRetentionChange = useChangeOptions(props, 'metricStoreRetention');
+  const onMetricStoreDownsampleAfterChange = useChangeOptions(props, 'metricStoreDownsampleAfter');
+  const onMetricStoreDownsampleIntervalChange = useChangeOptions(props, 'metricStoreDownsampleInterval');
+  const onSimulatedChange = useChangeSwitch(props, 'simulated');
+  const onSimulationNamespacesChange = useChangeNumber(props, 'simulationNamespaces');
+  const onSimulationPipelinesChange = useChangeNumber(props, 'simulationPipelines');
+  const onSimulationVerticesChange = useChangeNumber(props, 'simulationVertices');
+  const onSimulationFailureRateChange = useChangeNumber(props, 'simulationFailureRate');
+  const onSimulationFailingPipelinesChange = useChangeOptions(props, 'simulationFailingPipelines');
+  const onSimulationIntervalChange = useChangeOptions(props, 'simulationInterval');
 
   return (
     <>
@@ -18,6 +40,175 @@ export function ConfigEditor(props: EditorProps): ReactElement {
         <InlineField label="Namespace" tooltip='The namespace to query when "namespaced" is enabled.'>
           <Input onChange={onNamespaceChange} placeholder="namespace" value={jsonData?.namespace ?? ''} />
         </InlineField>
+        <InlineField
+          label="Pod usage source"
+          tooltip='Where pod cpu and memory usage is read from: "metrics-server", "kubelet" (summary API through the node proxy) or "auto" (metrics-server, falling back to kubelet per pod).'
+        >
+          <Input onChange={onPodUsageSourceChange} placeholder="auto" value={jsonData?.podUsageSource ?? ''} />
+        </InlineField>
+      </FieldSet>
+      <FieldSet label="Query cache">
+        <InlineField
+          label="TTL"
+          tooltip='How long query results are reused for identical queries, e.g. of viewers of the same dashboard. Set to "0" to disable.'
+        >
+          <Input onChange={onCacheTtlChange} placeholder="10s" value={jsonData?.cacheTtl ?? ''} />
+        </InlineField>
+      </FieldSet>
+      <FieldSet label="Links">
+        <InlineField
+          label="Numaflow UI URL"
+          tooltip="Base URL of the Numaflow server UI. Pipeline and vertex fields link to their pipeline page when set."
+        >
+          <Input
+            onChange={onNumaflowUiUrlChange}
+            placeholder="https://numaflow.example.com"
+            value={jsonData?.numaflowUiUrl ?? ''}
+          />
+        </InlineField>
+      </FieldSet>
+      <FieldSet label="Spec redaction">
+        <InlineField label="Redact env values" tooltip="Whether to redact the value of every environment variable.">
+          <InlineSwitch
+            onChange={onRedactEnvValuesChange}
+            placeholder="redactEnvValues"
+            value={jsonData?.redactEnvValues ?? false}
+          />
+        </InlineField>
+        <InlineField
+          label="Redact env name pattern"
+          tooltip="Regular expression of environment variable names whose values are redacted."
+        >
+          <Input
+            onChange={onRedactEnvNamePatternChange}
+            placeholder="(?i)(password|secret|token|credential|key)"
+            value={jsonData?.redactEnvNamePattern ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Redact secret refs" tooltip="Whether to redact the name and key of secret references.">
+          <InlineSwitch
+            onChange={onRedactSecretRefsChange}
+            placeholder="redactSecretRefs"
+            value={jsonData?.redactSecretRefs ?? true}
+          />
+        </InlineField>
+      </FieldSet>
+      <FieldSet label="Spec history">
+        <InlineField
+          label="Max revisions"
+          tooltip="Number of spec revisions kept per pipeline and vertex. Set to 0 to disable watching for spec changes."
+        >
+          <Input
+            type="number"
+            onChange={onHistoryMaxRevisionsChange}
+            placeholder="20"
+            value={jsonData?.historyMaxRevisions ?? ''}
+          />
+        </InlineField>
+        <InlineField
+          label="Directory"
+          tooltip="Writable directory the spec history is kept in across restarts. Kept in memory when empty."
+        >
+          <Input
+            onChange={onHistoryDirectoryChange}
+            placeholder="/var/lib/grafana/numaflow-datasource"
+            value={jsonData?.historyDirectory ?? ''}
+          />
+        </InlineField>
+      </FieldSet>
+      <FieldSet label="Metric store">
+        <InlineField label="Enabled" tooltip="Whether to periodically record vertex metrics for time series queries.">
+          <InlineSwitch
+            onChange={onMetricStoreEnabledChange}
+            placeholder="metricStoreEnabled"
+            value={jsonData?.metricStoreEnabled ?? false}
+          />
+        </InlineField>
+        <InlineField label="Directory" tooltip="Writable directory of the on-disk metric store, outside of the plugin's installation directory. Defaults to a directory under Grafana's data path.">
+          <Input
+            onChange={onMetricStoreDirectoryChange}
+            placeholder="$GF_PATHS_DATA/numaflow-datasource"
+            value={jsonData?.metricStoreDirectory ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Interval" tooltip="How often metrics are recorded.">
+          <Input onChange={onMetricStoreIntervalChange} placeholder="30s" value={jsonData?.metricStoreInterval ?? ''} />
+        </InlineField>
+        <InlineField label="Retention" tooltip="How long metrics are kept.">
+          <Input
+            onChange={onMetricStoreRetentionChange}
+            placeholder="168h"
+            value={jsonData?.metricStoreRetention ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Downsample after" tooltip="Age after which metrics are downsampled.">
+          <Input
+            onChange={onMetricStoreDownsampleAfterChange}
+            placeholder="6h"
+            value={jsonData?.metricStoreDownsampleAfter ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Downsample interval" tooltip="Resolution of downsampled metrics.">
+          <Input
+            onChange={onMetricStoreDownsampleIntervalChange}
+            placeholder="5m"
+            value={jsonData?.metricStoreDownsampleInterval ?? ''}
+          />
+        </InlineField>
+      </FieldSet>
+      <FieldSet label="Simulation">
+        <InlineField
+          label="Simulated"
+          tooltip="Whether to query a generated cluster instead of Kubernetes, for developing dashboards offline."
+        >
+          <InlineSwitch onChange={onSimulatedChange} placeholder="simulated" value={jsonData?.simulated ?? false} />
+        </InlineField>
+        <InlineField
+          label="Namespaces"
+          tooltip='Number of generated namespaces. Ignored when "namespaced" is enabled, which simulates the namespace.'
+        >
+          <Input
+            type="number"
+            onChange={onSimulationNamespacesChange}
+            placeholder="2"
+            value={jsonData?.simulationNamespaces ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Pipelines" tooltip="Number of generated pipelines per namespace.">
+          <Input
+            type="number"
+            onChange={onSimulationPipelinesChange}
+            placeholder="3"
+            value={jsonData?.simulationPipelines ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Vertices" tooltip="Number of vertices per generated pipeline, including source and sink.">
+          <Input
+            type="number"
+            onChange={onSimulationVerticesChange}
+            placeholder="4"
+            value={jsonData?.simulationVertices ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Failure rate" tooltip="Probability between 0 and 1 that a metrics or buffer call fails.">
+          <Input
+            type="number"
+            step={0.05}
+            onChange={onSimulationFailureRateChange}
+            placeholder="0"
+            value={jsonData?.simulationFailureRate ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Failing pipelines" tooltip="Comma-separated names of pipelines that are failed and always fail.">
+          <Input
+            onChange={onSimulationFailingPipelinesChange}
+            placeholder="payments"
+            value={jsonData?.simulationFailingPipelines ?? ''}
+          />
+        </InlineField>
+        <InlineField label="Interval" tooltip="How often the generated cluster autoscales and restarts pods.">
+          <Input onChange={onSimulationIntervalChange} placeholder="1m" value={jsonData?.simulationInterval ?? ''} />
+        </InlineField>
       </FieldSet>
     </>
   );
@@ -1,6 +1,13 @@
 import { DataSourceWithBackend, getTemplateSrv } from '@grafana/runtime';
 import type { DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
-import { MetricNamesResponse, NumaflowDataQuery, NumaflowDataSourceOptions, QueryTypesResponse } from './types';
+import {
+  CompleteResponse,
+  MetricNamesResponse,
+  NumaflowDataQuery,
+  NumaflowDataSourceOptions,
+  QueryTypesResponse,
+  ValidateResponse,
+} from './types';
 import { MultiValueVariable, TextValuePair } from './components/QueryEditor/types';
 import _ from 'lodash';
 
@@ -26,6 +33,14 @@ export class NumaflowDataSource extends DataSourceWithBackend<NumaflowDataQuery,
     return this.postResource('/metric-names', { rawQuery: query });
   }
 
+  validateQuery(query: NumaflowDataQuery): Promise<ValidateResponse> {
+    return this.postResource('/validate', { queryType: query.queryType, rawQuery: query.rawQuery });
+  }
+
+  completeQuery(query: NumaflowDataQuery, cursor: number): Promise<CompleteResponse> {
+    return this.postResource('/complete', { queryType: query.queryType, rawQuery: query.rawQuery, cursor });
+  }
+
   async metricFindQuery(query: string, options?: any) {
     let payload = query;
     payload = getTemplateSrv().replace(payload, { ...this.getVariables });
@@ -7,6 +7,27 @@ export interface NumaflowDataQuery extends DataQuery {
 export interface NumaflowDataSourceOptions extends DataSourceJsonData {
   namespaced?: boolean;
   namespace?: string;
+  podUsageSource?: string;
+  redactEnvValues?: boolean;
+  redactEnvNamePattern?: string;
+  redactSecretRefs?: boolean;
+  historyMaxRevisions?: number;
+  historyDirectory?: string;
+  numaflowUiUrl?: string;
+  cacheTtl?: string;
+  metricStoreEnabled?: boolean;
+  metricStoreDirectory?: string;
+  metricStoreInterval?: string;
+  metricStoreRetention?: string;
+  metricStoreDownsampleAfter?: string;
+  metricStoreDownsampleInterval?: string;
+  simulated?: boolean;
+  simulationNamespaces?: number;
+  simulationPipelines?: number;
+  simulationVertices?: number;
+  simulationFailureRate?: number;
+  simulationFailingPipelines?: string;
+  simulationInterval?: string;
 }
 
 export type QueryTypesResponse = {
@@ -16,3 +37,29 @@ export type QueryTypesResponse = {
 export type MetricNamesResponse = {
   metricNames: string[];
 };
+
+export type Diagnostic = {
+  key: string;
+  severity: 'error' | 'warning';
+  kind: string;
+  message: string;
+  from?: number;
+  to?: number;
+};
+
+export type ValidateResponse = {
+  diagnostics: Diagnostic[];
+};
+
+export type Suggestion = {
+  label: string;
+  insertText: string;
+  detail?: string;
+  kind: 'key' | 'value';
+};
+
+export type CompleteResponse = {
+  from: number;
+  to: number;
+  suggestions: Suggestion[];
+};